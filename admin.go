@@ -0,0 +1,145 @@
+package cron
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NewAdminHandler creates HTTP handler to manage entries of the scheduler store
+//
+//	GET    /entries                  list entries including paused entries
+//	POST   /entries                  add an entry, body: {"name":"", "expression":"", "location":"", "meta":""}
+//	                                 409 Conflict if an entry with the name exists
+//	DELETE /entries/{name}           delete entries with the name
+//	GET    /events?from=...&to=...   list events on [from, to), time is in RFC3339 format
+func NewAdminHandler(s *Scheduler) http.Handler {
+	a := &admin{s: s, store: s.store}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /entries", a.getEntries)
+	mux.HandleFunc("POST /entries", a.addEntry)
+	mux.HandleFunc("DELETE /entries/{name}", a.deleteEntry)
+	mux.HandleFunc("GET /events", a.getEvents)
+
+	return mux
+}
+
+type admin struct {
+	s     *Scheduler
+	store Store
+}
+
+func (a *admin) getEntries(w http.ResponseWriter, r *http.Request) {
+	var entries []Entry
+	err := a.s.withLock(r.Context(), func() (err error) {
+		entries, err = a.store.GetEntries(r.Context(), IncludeInactive())
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get entries: %v", err))
+		return
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (a *admin) addEntry(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name       string `json:"name"`
+		Expression string `json:"expression"`
+		Location   string `json:"location"`
+		Meta       string `json:"meta"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed decoding body: %v", err))
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("empty name"))
+		return
+	}
+	loc, err := time.LoadLocation(req.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to load location %q: %v", req.Location, err))
+		return
+	}
+	entry, err := Parse(req.Expression, loc, req.Name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse expression %q: %v", req.Expression, err))
+		return
+	}
+	entry.Meta = req.Meta
+
+	err = a.s.addEntry(r.Context(), entry, false)
+	if errors.Is(err, errEntryExists) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func (a *admin) deleteEntry(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	err := a.s.RemoveEntry(r.Context(), name, false)
+	if errors.Is(err, ErrEntryNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("entry %q not found", name))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *admin) getEvents(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid 'from': %v", err))
+		return
+	}
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid 'to': %v", err))
+		return
+	}
+
+	var events []Event
+	err = a.s.withLock(r.Context(), func() (err error) {
+		events, err = a.store.GetEvents(r.Context(), from, to)
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get events: %v", err))
+		return
+	}
+	if events == nil {
+		events = []Event{}
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log(fmt.Errorf("failed writing response: %v", err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminHandler(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.AddEntry(ctx, entry)
+	store.AddEvent(ctx, Event{Entry: entry, Time: time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)})
+	store.AddEvent(ctx, Event{Entry: entry, Time: time.Date(2018, 12, 15, 0, 1, 0, 0, time.UTC)})
+
+	handler := NewAdminHandler(NewScheduler(nil, store))
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "list entries", method: "GET", target: "/entries", wantStatus: http.StatusOK,
+			wantBody: `[{"name":"ENTRY_1","expression":"* * * * *","location":"UTC"}]`,
+		},
+		{
+			name: "add entry", method: "POST", target: "/entries", wantStatus: http.StatusCreated,
+			body:     `{"name":"ENTRY_2","expression":"*/5 * * * *","location":"Asia/Jakarta","meta":"META"}`,
+			wantBody: `{"name":"ENTRY_2","expression":"*/5 * * * *","location":"Asia/Jakarta","meta":"META"}`,
+		},
+		{
+			name: "add existing entry", method: "POST", target: "/entries", wantStatus: http.StatusConflict,
+			body:     `{"name":"ENTRY_2","expression":"0 * * * *","location":"UTC"}`,
+			wantBody: `{"error":"failed to add entry \"ENTRY_2\": entry already exists"}`,
+		},
+		{
+			name: "list added entries", method: "GET", target: "/entries", wantStatus: http.StatusOK,
+			wantBody: `[{"name":"ENTRY_1","expression":"* * * * *","location":"UTC"},` +
+				`{"name":"ENTRY_2","expression":"*/5 * * * *","location":"Asia/Jakarta","meta":"META"}]`,
+		},
+		{
+			name: "add entry invalid expression", method: "POST", target: "/entries", wantStatus: http.StatusBadRequest,
+			body:     `{"name":"ENTRY_3","expression":"60 * * * *"}`,
+			wantBody: `{"error":"failed to parse expression \"60 * * * *\": failed parsing 'minute' field \"60\": value out of range (0 - 59): 60"}`,
+		},
+		{
+			name: "add entry never matches", method: "POST", target: "/entries", wantStatus: http.StatusBadRequest,
+			body:     `{"name":"ENTRY_3","expression":"0 0 30 2 *"}`,
+			wantBody: `{"error":"failed to parse expression \"0 0 30 2 *\": day of month \"30\" never occurs in month \"2\""}`,
+		},
+		{
+			name: "add entry zero interval", method: "POST", target: "/entries", wantStatus: http.StatusBadRequest,
+			body:     `{"name":"ENTRY_3","expression":"*/0 * * * *"}`,
+			wantBody: `{"error":"failed to parse expression \"*/0 * * * *\": failed parsing 'minute' field \"*/0\": invalid interval 0 in expression \"*/0\""}`,
+		},
+		{
+			name: "add entry invalid location", method: "POST", target: "/entries", wantStatus: http.StatusBadRequest,
+			body:     `{"name":"ENTRY_3","expression":"* * * * *","location":"Mars/Phobos"}`,
+			wantBody: `{"error":"failed to load location \"Mars/Phobos\": unknown time zone Mars/Phobos"}`,
+		},
+		{
+			name: "add entry empty name", method: "POST", target: "/entries", wantStatus: http.StatusBadRequest,
+			body:     `{"expression":"* * * * *"}`,
+			wantBody: `{"error":"empty name"}`,
+		},
+		{
+			name: "add entry invalid body", method: "POST", target: "/entries", wantStatus: http.StatusBadRequest,
+			body:     `{`,
+			wantBody: `{"error":"failed decoding body: unexpected EOF"}`,
+		},
+		{
+			name: "delete entry", method: "DELETE", target: "/entries/ENTRY_2", wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "delete unknown entry", method: "DELETE", target: "/entries/ENTRY_2", wantStatus: http.StatusNotFound,
+			wantBody: `{"error":"entry \"ENTRY_2\" not found"}`,
+		},
+		{
+			name: "list events", method: "GET", target: "/events?from=2018-12-15T00:00:00Z&to=2018-12-15T00:01:00Z",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"entry":{"name":"ENTRY_1","expression":"* * * * *","location":"UTC"},"time":"2018-12-15T00:00:00Z"}]`,
+		},
+		{
+			name: "list events invalid time", method: "GET", target: "/events?from=yesterday&to=2018-12-15T00:01:00Z",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid 'from': parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got, want := rec.Code, tt.wantStatus; got != want {
+				t.Errorf("got status %d want %d", got, want)
+			}
+			if got, want := strings.TrimSpace(rec.Body.String()), tt.wantBody; got != want {
+				t.Errorf("got body %s want %s", got, want)
+			}
+		})
+	}
+}
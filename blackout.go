@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventStatusSuppressedBlackout is Event.Status of a run suppressed by a blackout window, see WithBlackoutRecord
+const EventStatusSuppressedBlackout = "suppressed-blackout"
+
+// Blackout is a window where entries are not triggered (ex: during deployment). The window starts on every minute
+// that matches a cron expression and lasts for a duration.
+type Blackout struct {
+	start    Entry
+	duration time.Duration
+}
+
+// NewBlackout creates a blackout window that starts on every minute matching expression and lasts for d.
+// ex: NewBlackout("0 2 * * 0", 2*time.Hour, nil) is every Sunday between 02:00 and 04:00 UTC.
+func NewBlackout(expression string, d time.Duration, loc *time.Location) (Blackout, error) {
+	if d <= 0 {
+		return Blackout{}, fmt.Errorf("invalid blackout duration %s", d)
+	}
+	e, err := Parse(expression, loc, "blackout")
+	if err != nil {
+		return Blackout{}, fmt.Errorf("failed to parse blackout expression %q: %v", expression, err)
+	}
+
+	return Blackout{start: e, duration: d}, nil
+}
+
+// NewWeeklyBlackout creates a blackout window on a day of week between start and end, given as offset from the
+// midnight. If end is before start the window ends on the next day.
+// ex: NewWeeklyBlackout(time.Sunday, 2*time.Hour, 4*time.Hour, nil)
+func NewWeeklyBlackout(day time.Weekday, start, end time.Duration, loc *time.Location) (Blackout, error) {
+	if start < 0 || start >= 24*time.Hour || end < 0 || end > 24*time.Hour {
+		return Blackout{}, fmt.Errorf("blackout start %s and end %s must be within a day", start, end)
+	}
+	d := end - start
+	if d <= 0 {
+		d += 24 * time.Hour
+	}
+	start = start.Truncate(time.Minute)
+	expression := fmt.Sprintf("%d %d * * %d", int(start.Minutes())%60, int(start.Hours()), day)
+
+	return NewBlackout(expression, d, loc)
+}
+
+// Active reports whether t is within the window
+func (b Blackout) Active(t time.Time) bool {
+	t = t.Truncate(time.Minute)
+	for start := t; t.Sub(start) < b.duration; start = start.Add(-time.Minute) {
+		if b.start.Match(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithBlackout sets the windows where matched entries are skipped (see Entry.IgnoreBlackout). There is no catch up,
+// the runs within the window are missed.
+func WithBlackout(windows ...Blackout) Option {
+	return func(s *Scheduler) {
+		s.blackouts = append(s.blackouts, windows...)
+	}
+}
+
+// WithBlackoutRecord records the runs suppressed by a blackout window as events with EventStatusSuppressedBlackout
+// status instead of only skipping them.
+func WithBlackoutRecord() Option {
+	return func(s *Scheduler) {
+		s.blackoutRecord = true
+	}
+}
+
+// inBlackout reports whether t is within any of the blackout windows
+func (s *Scheduler) inBlackout(t time.Time) bool {
+	for _, b := range s.blackouts {
+		if b.Active(t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBlackout_Active(t *testing.T) {
+	weekly, err := NewWeeklyBlackout(time.Sunday, 2*time.Hour, 4*time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overnight, err := NewWeeklyBlackout(time.Saturday, 23*time.Hour, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2018-12-16 is a Sunday
+	tests := []struct {
+		name     string
+		blackout Blackout
+		t        time.Time
+		want     bool
+	}{
+		{"before", weekly, time.Date(2018, 12, 16, 1, 59, 0, 0, time.UTC), false},
+		{"start", weekly, time.Date(2018, 12, 16, 2, 0, 0, 0, time.UTC), true},
+		{"within", weekly, time.Date(2018, 12, 16, 3, 59, 30, 0, time.UTC), true},
+		{"end", weekly, time.Date(2018, 12, 16, 4, 0, 0, 0, time.UTC), false},
+		{"other day", weekly, time.Date(2018, 12, 17, 3, 0, 0, 0, time.UTC), false},
+		{"overnight start", overnight, time.Date(2018, 12, 15, 23, 0, 0, 0, time.UTC), true},
+		{"overnight next day", overnight, time.Date(2018, 12, 16, 0, 30, 0, 0, time.UTC), true},
+		{"overnight end", overnight, time.Date(2018, 12, 16, 1, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.blackout.Active(tt.t); got != tt.want {
+				t.Errorf("got active %t want %t", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewBlackout("0 2 * * 0", 0, nil); err == nil {
+		t.Errorf("expected error for zero duration")
+	}
+	if _, err := NewWeeklyBlackout(time.Sunday, 25*time.Hour, time.Hour, nil); err == nil {
+		t.Errorf("expected error for start beyond a day")
+	}
+}
+
+func TestScheduler_blackout(t *testing.T) {
+	ctx := context.Background()
+	window, err := NewBlackout("0 2 * * 0", 2*time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := time.Date(2018, 12, 16, 3, 0, 0, 0, time.UTC)
+
+	for _, record := range []bool{false, true} {
+		store := &MemStore{}
+		for _, name := range []string{"ENTRY_1", "CRITICAL"} {
+			e, err := Parse("* * * * *", time.UTC, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.IgnoreBlackout = name == "CRITICAL"
+			store.AddEntry(ctx, e)
+		}
+
+		triggered := make(chan string, 2)
+		var skipped []Event
+		opts := []Option{WithBlackout(window), WithHooks(Hooks{OnSkip: func(ev Event) { skipped = append(skipped, ev) }})}
+		if record {
+			opts = append(opts, WithBlackoutRecord())
+		}
+		s := NewScheduler(func(ctx context.Context, e Entry) error {
+			triggered <- e.Name
+			return nil
+		}, store, opts...)
+
+		if err := s.check(ctx, on); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := <-triggered, "CRITICAL"; got != want {
+			t.Errorf("got triggered %q want %q", got, want)
+		}
+		if got, want := len(skipped), 1; got != want {
+			t.Fatalf("got %d skipped events want %d", got, want)
+		}
+		if got, want := skipped[0].Status, EventStatusSuppressedBlackout; got != want {
+			t.Errorf("got status %q want %q", got, want)
+		}
+
+		wantEvents := 1
+		if record {
+			wantEvents = 2
+		}
+		if got, want := len(store.events), wantEvents; got != want {
+			t.Errorf("record %t: got %d events want %d", record, got, want)
+		}
+
+		// suppressed run is missed, the entry runs again after the window
+		if err := s.check(ctx, on.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			<-triggered
+		}
+	}
+}
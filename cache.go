@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EntriesNotifier is an optional interface of a Store that can notify when the entries are changed (ex: by another
+// instance). The scheduler registers a callback to reload the entry cache early (see WithEntryCache).
+type EntriesNotifier interface {
+	// NotifyEntriesChanged registers fn to be called when the entries in the store are changed
+	NotifyEntriesChanged(fn func())
+}
+
+// entryCache keeps the active entries of the store between checks
+type entryCache struct {
+	mu       sync.Mutex
+	refresh  time.Duration // zero disables the cache
+	entries  []Entry
+	loadedAt time.Time
+	valid    bool
+}
+
+// WithEntryCache caches the active entries of the store and reloads them every refresh interval instead of on every
+// check. Events are still read and recorded on every check while the store is locked. The cache is invalidated by
+// AddEntry, RemoveEntry, Pause, Resume and ForceReload and when a store implementing EntriesNotifier reports a change.
+func WithEntryCache(refresh time.Duration) Option {
+	return func(s *Scheduler) {
+		s.cache.refresh = refresh
+		if n, ok := s.store.(EntriesNotifier); ok {
+			n.NotifyEntriesChanged(s.ForceReload)
+		}
+	}
+}
+
+// ForceReload invalidates the entry cache so that the entries are read from the store on the next check
+func (s *Scheduler) ForceReload() {
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+
+	s.cache.valid = false
+	s.cache.entries = nil
+}
+
+// activeEntries returns active entries from the cache or from the store when the cache is disabled or expired.
+// It must be called while the store is locked.
+func (s *Scheduler) activeEntries(ctx context.Context) ([]Entry, error) {
+	if s.cache.refresh <= 0 {
+		entries, err := s.store.GetEntries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.reportDependencies(entries)
+		s.reportWarnings(entries)
+		return entries, nil
+	}
+
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+
+	now := s.now()
+	if s.cache.valid && now.Sub(s.cache.loadedAt) < s.cache.refresh {
+		return s.cache.entries, nil
+	}
+
+	entries, err := s.store.GetEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.entries, s.cache.loadedAt, s.cache.valid = entries, now, true
+	s.reportDependencies(entries)
+	s.reportWarnings(entries)
+
+	return entries, nil
+}
@@ -0,0 +1,103 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingStore counts GetEntries calls and notifies entries changes on demand
+type countingStore struct {
+	*MemStore
+	getEntries int
+	notify     func()
+}
+
+func (c *countingStore) GetEntries(ctx context.Context, opts ...EntriesOption) ([]Entry, error) {
+	c.getEntries++
+	return c.MemStore.GetEntries(ctx, opts...)
+}
+
+func (c *countingStore) NotifyEntriesChanged(fn func()) {
+	c.notify = fn
+}
+
+func TestScheduler_entryCache(t *testing.T) {
+	ctx := context.Background()
+	entry, err := Parse("0 0 * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &countingStore{MemStore: &MemStore{}}
+	store.MemStore.AddEntry(ctx, entry)
+
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store,
+		WithClock(func() time.Time { return now }),
+		WithEntryCache(5*time.Minute),
+	)
+	if store.notify == nil {
+		t.Fatal("entries change notification is not registered")
+	}
+
+	check := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			now = now.Add(time.Minute)
+			if err := s.check(ctx, now); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	check(5)
+	if got, want := store.getEntries, 1; got != want {
+		t.Errorf("got %d GetEntries calls want %d", got, want)
+	}
+
+	// refreshed after the interval
+	check(5)
+	if got, want := store.getEntries, 2; got != want {
+		t.Errorf("got %d GetEntries calls want %d", got, want)
+	}
+
+	// invalidated by the scheduler
+	entry2, err := Parse("0 0 * * *", time.UTC, "ENTRY_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddEntry(ctx, entry2); err != nil {
+		t.Fatal(err)
+	}
+	check(1)
+	if err := s.Pause(ctx, "ENTRY_2"); err != nil {
+		t.Fatal(err)
+	}
+	check(1)
+	if got, want := store.getEntries, 4; got != want {
+		t.Errorf("got %d GetEntries calls want %d", got, want)
+	}
+
+	// invalidated by the store
+	store.notify()
+	check(1)
+	if got, want := store.getEntries, 5; got != want {
+		t.Errorf("got %d GetEntries calls want %d", got, want)
+	}
+}
+
+func TestScheduler_entryCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemStore: &MemStore{}}
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store)
+
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := s.check(ctx, on.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := store.getEntries, 3; got != want {
+		t.Errorf("got %d GetEntries calls want %d", got, want)
+	}
+}
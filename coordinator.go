@@ -0,0 +1,128 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLeaseLost is returned by Store.AddEvent when the lease in the context (see LeaseFromContext) is no longer the
+// current lease, ex: the instance was paused longer than the lease TTL and another instance took over.
+var ErrLeaseLost = errors.New("lease lost")
+
+// Coordinator decides which instance runs a check when more than one instance shares the store
+type Coordinator interface {
+	// Acquire is called at the start of every check. ok is false when another instance is in charge, the check is
+	// then skipped. The returned context is used by the check for the store operations.
+	Acquire(ctx context.Context) (_ context.Context, ok bool, err error)
+	// Release is called at the end of a check that acquired
+	Release(ctx context.Context) error
+}
+
+// WithCoordinator sets how instances coordinate the checks (default NewLockCoordinator of the store)
+func WithCoordinator(c Coordinator) Option {
+	return func(s *Scheduler) {
+		s.coordinator = c
+	}
+}
+
+// NewLockCoordinator coordinates the checks by locking the store (Store.Lock and Store.Unlock). Every instance runs
+// the check one after another and the events recorded by the first one prevent the others from triggering.
+func NewLockCoordinator(store Store) Coordinator {
+	return lockCoordinator{store: store}
+}
+
+type lockCoordinator struct {
+	store Store
+}
+
+func (c lockCoordinator) Acquire(ctx context.Context) (context.Context, bool, error) {
+	if err := c.store.Lock(ctx); err != nil {
+		return ctx, false, err
+	}
+	return ctx, true, nil
+}
+
+func (c lockCoordinator) Release(ctx context.Context) error {
+	return c.store.Unlock(ctx)
+}
+
+// Lease is held by one instance at a time to run the checks, see NewLeaseCoordinator
+type Lease struct {
+	Name      string
+	Holder    string
+	ExpiresAt time.Time
+	// Token is the fencing token, it is incremented every time the lease changes holder
+	Token int64
+}
+
+// LeaseStore is an optional interface of a Store that can hold leases
+type LeaseStore interface {
+	// AcquireLease takes the lease with the name for holder until now+ttl if it is free, expired or already held by
+	// holder. It returns the current lease, Holder is another instance if the lease could not be taken.
+	AcquireLease(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (Lease, error)
+	// ReleaseLease expires the lease on now if it is held by holder
+	ReleaseLease(ctx context.Context, name, holder string, now time.Time) error
+}
+
+type leaseKey struct{}
+
+// LeaseFromContext returns the lease of the instance running the check. Store.AddEvent must return ErrLeaseLost if
+// the token of the lease is not the current one so that a former leader can not record events.
+func LeaseFromContext(ctx context.Context) (Lease, bool) {
+	l, ok := ctx.Value(leaseKey{}).(Lease)
+	return l, ok
+}
+
+// LeaseName is the name of the lease held by the leader
+const LeaseName = "check"
+
+// LeaseCoordinator coordinates the checks with a lease so that only the leader runs the check and the store is not
+// locked. The lease is renewed on every check, when the leader stops renewing (ex: it crashed) another instance takes
+// over on its first check after the lease expires. The events are recorded with the fencing token of the lease so
+// that a former leader that has not noticed it lost the lease can not record events.
+type LeaseCoordinator struct {
+	store    LeaseStore
+	instance string
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewLeaseCoordinator creates a lease coordinator for the instance, instance must be unique among the instances
+// sharing the store. ttl must be longer than a minute (the check interval) plus the clock skew between the instances,
+// ex: 90 seconds.
+func NewLeaseCoordinator(store LeaseStore, instance string, ttl time.Duration) (*LeaseCoordinator, error) {
+	if instance == "" {
+		return nil, errors.New("empty instance")
+	}
+	if ttl <= time.Minute {
+		return nil, fmt.Errorf("lease ttl %s must be longer than a minute", ttl)
+	}
+
+	return &LeaseCoordinator{store: store, instance: instance, ttl: ttl, now: time.Now}, nil
+}
+
+// Acquire takes or renews the lease. The lease is attached to the returned context for the fencing in AddEvent.
+func (c *LeaseCoordinator) Acquire(ctx context.Context) (context.Context, bool, error) {
+	l, err := c.store.AcquireLease(ctx, LeaseName, c.instance, c.now(), c.ttl)
+	if err != nil {
+		return ctx, false, fmt.Errorf("failed to acquire lease: %v", err)
+	}
+	if l.Holder != c.instance {
+		return ctx, false, nil
+	}
+
+	return context.WithValue(ctx, leaseKey{}, l), true, nil
+}
+
+// Release keeps the lease, it is renewed on the next check
+func (c *LeaseCoordinator) Release(ctx context.Context) error {
+	return nil
+}
+
+// Resign releases the lease so that another instance can take over on its next check instead of after the lease
+// expires. Call it after Run returns.
+func (c *LeaseCoordinator) Resign(ctx context.Context) error {
+	return c.store.ReleaseLease(ctx, LeaseName, c.instance, c.now())
+}
@@ -0,0 +1,234 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLeaseCoordinator(t *testing.T, store LeaseStore, instance string, now *time.Time) *LeaseCoordinator {
+	t.Helper()
+	c, err := NewLeaseCoordinator(store, instance, 90*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestLeaseCoordinator_failover(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	store := &MemStore{}
+	c1 := newTestLeaseCoordinator(t, store, "instance-1", &now)
+	c2 := newTestLeaseCoordinator(t, store, "instance-2", &now)
+
+	acquire := func(c *LeaseCoordinator) (Lease, bool) {
+		t.Helper()
+		lctx, ok, err := c.Acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, _ := LeaseFromContext(lctx)
+		return l, ok
+	}
+
+	l1, ok := acquire(c1)
+	if !ok {
+		t.Fatal("instance-1 did not acquire the free lease")
+	}
+	if _, ok := acquire(c2); ok {
+		t.Fatal("instance-2 acquired the lease held by instance-1")
+	}
+
+	// instance-1 renews on every tick
+	now = now.Add(time.Minute)
+	if l, ok := acquire(c1); !ok || l.Token != l1.Token {
+		t.Fatalf("instance-1 renewal got ok %t token %d want token %d", ok, l.Token, l1.Token)
+	}
+
+	// instance-1 stops renewing, instance-2 takes over only when the lease expires
+	now = now.Add(89 * time.Second)
+	if _, ok := acquire(c2); ok {
+		t.Fatal("instance-2 acquired the lease before it expired")
+	}
+	now = now.Add(time.Second)
+	l2, ok := acquire(c2)
+	if !ok {
+		t.Fatal("instance-2 did not take over the expired lease")
+	}
+	if l2.Token <= l1.Token {
+		t.Errorf("got token %d after failover want greater than %d", l2.Token, l1.Token)
+	}
+	if _, ok := acquire(c1); ok {
+		t.Fatal("instance-1 acquired the lease held by instance-2")
+	}
+
+	// resign hands over on the next check instead of after the ttl
+	if err := c2.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := acquire(c1); !ok {
+		t.Fatal("instance-1 did not acquire the resigned lease")
+	}
+}
+
+func TestScheduler_checkLease(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	triggered := make(chan string, 10)
+	newScheduler := func(instance string) *Scheduler {
+		return NewScheduler(func(ctx context.Context, e Entry) error {
+			triggered <- instance
+			return nil
+		}, store, WithCoordinator(newTestLeaseCoordinator(t, store, instance, &now)))
+	}
+	s1, s2 := newScheduler("instance-1"), newScheduler("instance-2")
+
+	// only the leader checks, the store is not locked
+	for _, s := range []*Scheduler{s1, s2} {
+		if err := s.check(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := <-triggered, "instance-1"; got != want {
+		t.Errorf("got triggered by %q want %q", got, want)
+	}
+	if got, want := len(store.events), 1; got != want {
+		t.Fatalf("got %d events want %d", got, want)
+	}
+	if !store.Mutex.TryLock() {
+		t.Fatal("store is locked")
+	}
+	store.Mutex.Unlock()
+
+	// leader stops, the follower takes over on the first check after the lease expires
+	now = now.Add(time.Minute)
+	if err := s2.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(store.events), 1; got != want {
+		t.Fatalf("got %d events before the lease expires want %d", got, want)
+	}
+	now = now.Add(time.Minute)
+	if err := s2.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-triggered, "instance-2"; got != want {
+		t.Errorf("got triggered by %q want %q", got, want)
+	}
+}
+
+func TestScheduler_checkLeaseConcurrent(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	store := &MemStore{}
+	for i := 0; i < 5; i++ {
+		entry, err := Parse("* * * * *", time.UTC, fmt.Sprintf("ENTRY_%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(ctx, entry)
+	}
+	newScheduler := func(instance string) *Scheduler {
+		return NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store,
+			WithCoordinator(newTestLeaseCoordinator(t, store, instance, &now)))
+	}
+	s1, s2 := newScheduler("instance-1"), newScheduler("instance-2")
+
+	// the checks do not lock the store while the handlers record the status of the events, run with -race
+	var wg sync.WaitGroup
+	for _, s := range []*Scheduler{s1, s2} {
+		wg.Add(1)
+		go func(s *Scheduler) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if err := s.check(ctx, now.Add(time.Duration(i)*time.Minute)); err != nil {
+					t.Error(err)
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+	s1.inFlight.Wait()
+	s2.inFlight.Wait()
+
+	events, err := store.GetEvents(ctx, now, now.Add(10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(events), 50; got != want {
+		t.Fatalf("got %d events want %d", got, want)
+	}
+	for _, e := range events {
+		if got, want := e.Status, EventStatusSucceeded; got != want {
+			t.Errorf("%s at %s: got status %q want %q", e.Entry.Name, e.Time, got, want)
+		}
+	}
+}
+
+// pausingStore lets another instance take over the lease while the check is reading the events
+type pausingStore struct {
+	*MemStore
+	pause func()
+}
+
+func (p *pausingStore) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]Event, error) {
+	if p.pause != nil {
+		p.pause()
+		p.pause = nil
+	}
+	return p.MemStore.GetEventsForEntries(ctx, names, from, to)
+}
+
+func TestScheduler_checkLeaseFencing(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &pausingStore{MemStore: &MemStore{}}
+	store.AddEntry(ctx, entry)
+
+	c1 := newTestLeaseCoordinator(t, store, "instance-1", &now)
+	c2 := newTestLeaseCoordinator(t, store, "instance-2", &now)
+	s1 := NewScheduler(func(ctx context.Context, e Entry) error {
+		t.Error("former leader triggered the entry")
+		return nil
+	}, store, WithCoordinator(c1))
+
+	// instance-1 is paused longer than the lease ttl right after it acquired the lease
+	store.pause = func() {
+		now = now.Add(2 * time.Minute)
+		if _, ok, err := c2.Acquire(ctx); err != nil || !ok {
+			t.Fatalf("instance-2 did not take over: %v", err)
+		}
+	}
+	if err := s1.check(ctx, now); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("got error %v want %v", err, ErrLeaseLost)
+	}
+	if got, want := len(store.events), 0; got != want {
+		t.Errorf("got %d events want %d", got, want)
+	}
+}
+
+func TestNewLeaseCoordinator(t *testing.T) {
+	store := &MemStore{}
+	if _, err := NewLeaseCoordinator(store, "", 90*time.Second); err == nil {
+		t.Error("expected error on empty instance")
+	}
+	if _, err := NewLeaseCoordinator(store, "instance-1", time.Minute); err == nil {
+		t.Error("expected error on ttl shorter than the check interval")
+	}
+}
@@ -0,0 +1,752 @@
+// Package cronbolt provides bbolt implementation of cron.Store, ex: for an edge device without network database
+//
+//	store, err := cronbolt.NewStore("/var/lib/myapp/cron.db", 0600)
+//	...
+//	defer store.Close()
+//	scheduler := cron.NewScheduler(handler, store)
+//
+// The file can only be opened by one process at a time so it does not allow running multiple instances like
+// cron.SqlStore.
+//
+// The entries are in the "entries" bucket keyed by name, the value is the JSON array of the entries with the name. The
+// events are in the "events" bucket keyed by the big-endian unix seconds followed by the name, expression and
+// location so that a range of time is a range of keys.
+//
+// Lock begins a writable transaction and Unlock commits it, the writes of a check are committed at once. If the
+// process crashes before Unlock none of them is recorded, the handlers of the check are not called yet since they run
+// after Unlock. bbolt syncs the file on commit so that the recorded events survive a crash of the device.
+package cronbolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yulrizka/cron"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store implements cron.Store, cron.EntryEventsGetter and cron.EventsAdder on a bbolt file
+type Store struct {
+	db *bolt.DB
+
+	mu sync.Mutex
+	tx *bolt.Tx // writable transaction while the store is locked
+}
+
+var (
+	boltEntriesBucket = []byte("entries")
+	boltEventsBucket  = []byte("events")
+)
+
+// boltOpenTimeout is the wait for the file lock when the file is opened by another process
+const boltOpenTimeout = time.Second
+
+// NewStore opens or creates the bbolt file of the store with the permissions. It fails when the file is opened by
+// another process.
+func NewStore(path string, mode os.FileMode) (*Store, error) {
+	db, err := bolt.Open(path, mode, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed opening %s: %v", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close the file, the transaction of the lock is rolled back
+func (s *Store) Close() error {
+	s.mu.Lock()
+	tx := s.tx
+	s.tx = nil
+	s.mu.Unlock()
+	if tx != nil {
+		tx.Rollback()
+	}
+
+	return s.db.Close()
+}
+
+// Initialize creates the buckets if not present
+func (s *Store) Initialize(ctx context.Context) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltEntriesBucket, boltEventsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("failed creating %s bucket: %v", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Health reads the buckets
+func (s *Store) Health(ctx context.Context) error {
+	return s.view(ctx, func(tx *bolt.Tx) error {
+		_, err := boltBuckets(tx)
+		return err
+	})
+}
+
+// Lock begins a writable transaction, it waits until the transaction of another Lock is committed
+func (s *Store) Lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin(true)
+	if err != nil {
+		return fmt.Errorf("failed beginning transaction: %v", err)
+	}
+	s.mu.Lock()
+	s.tx = tx
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Unlock commits the transaction of Lock
+func (s *Store) Unlock(ctx context.Context) error {
+	s.mu.Lock()
+	tx := s.tx
+	s.tx = nil
+	s.mu.Unlock()
+	if tx == nil {
+		return errors.New("not locked or transaction not exists")
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// view runs fn in the transaction of the lock, or in a read-only transaction when the store is not locked
+func (s *Store) view(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	tx := s.tx
+	s.mu.Unlock()
+	if tx != nil {
+		return fn(tx)
+	}
+
+	return s.db.View(fn)
+}
+
+// update runs fn in the transaction of the lock, or in its own writable transaction when the store is not locked
+func (s *Store) update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	tx := s.tx
+	s.mu.Unlock()
+	if tx != nil {
+		return fn(tx)
+	}
+
+	return s.db.Update(fn)
+}
+
+// boltBuckets returns the entries and events buckets
+func boltBuckets(tx *bolt.Tx) ([2]*bolt.Bucket, error) {
+	entries, events := tx.Bucket(boltEntriesBucket), tx.Bucket(boltEventsBucket)
+	if entries == nil || events == nil {
+		return [2]*bolt.Bucket{}, errors.New("buckets not exist, the store is not initialized")
+	}
+
+	return [2]*bolt.Bucket{entries, events}, nil
+}
+
+// boltEntry is the stored entry
+type boltEntry struct {
+	Expression     string        `json:"expression"`
+	Location       string        `json:"location"`
+	Meta           string        `json:"meta,omitempty"`
+	Paused         bool          `json:"paused,omitempty"`
+	IgnoreBlackout bool          `json:"ignore_blackout,omitempty"`
+	Timeout        time.Duration `json:"timeout,omitempty"`
+	DependsOn      []string      `json:"depends_on,omitempty"`
+}
+
+func newBoltEntry(e cron.Entry) boltEntry {
+	return boltEntry{
+		Expression:     e.Expression(),
+		Location:       e.Location.String(),
+		Meta:           e.Meta,
+		Paused:         e.Paused,
+		IgnoreBlackout: e.IgnoreBlackout,
+		Timeout:        e.Timeout,
+		DependsOn:      e.DependsOn,
+	}
+}
+
+// same reports whether v is the same entry of the name (expression and location)
+func (v boltEntry) same(o boltEntry) bool {
+	return v.Expression == o.Expression && v.Location == o.Location
+}
+
+func (v boltEntry) entry(name string) (cron.Entry, error) {
+	loc, err := time.LoadLocation(v.Location)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed to load location %q: %v", v.Location, err)
+	}
+	entry, err := cron.ParseStored(v.Expression, loc, name)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed to parse expression:%q loc:%q name:%q: %v", v.Expression, loc, name, err)
+	}
+	entry.Meta = v.Meta
+	entry.Paused = v.Paused
+	entry.IgnoreBlackout = v.IgnoreBlackout
+	entry.Timeout = v.Timeout
+	entry.DependsOn = v.DependsOn
+
+	return entry, nil
+}
+
+// validateEntry returns an error if the entry can not be read back
+func validateEntry(e cron.Entry) error {
+	if e.Name == "" {
+		return errors.New("got empty name")
+	}
+	if e.Expression() == "" {
+		return errors.New("got empty expression")
+	}
+	if _, err := time.LoadLocation(e.Location.String()); err != nil {
+		return fmt.Errorf("location %q of entry %q is not in the tz database, use time.LoadLocation: %v", e.Location,
+			e.Name, err)
+	}
+
+	return nil
+}
+
+// boltEntries decodes the entries with the name, nil if there is none
+func boltEntries(b *bolt.Bucket, name string) ([]boltEntry, error) {
+	value := b.Get([]byte(name))
+	if value == nil {
+		return nil, nil
+	}
+	var entries []boltEntry
+	if err := json.Unmarshal(value, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode entries %q: %v", name, err)
+	}
+
+	return entries, nil
+}
+
+// putBoltEntries stores the entries with the name, it deletes the key if there is none
+func putBoltEntries(b *bolt.Bucket, name string, entries []boltEntry) error {
+	if len(entries) == 0 {
+		return b.Delete([]byte(name))
+	}
+	value, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode entries %q: %v", name, err)
+	}
+
+	return b.Put([]byte(name), value)
+}
+
+func (s *Store) GetEntries(ctx context.Context, opts ...cron.EntriesOption) ([]cron.Entry, error) {
+	q := cron.NewEntriesQuery(opts...)
+	var entries []cron.Entry
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		// the keys are ordered by name
+		c := buckets[0].Cursor()
+		for k, _ := c.Seek([]byte(q.NamePrefix)); k != nil && bytes.HasPrefix(k, []byte(q.NamePrefix)); k, _ = c.Next() {
+			stored, err := boltEntries(buckets[0], string(k))
+			if err != nil {
+				return err
+			}
+			for _, v := range stored {
+				if v.Paused && !q.IncludeInactive {
+					continue
+				}
+				entry, err := v.entry(string(k))
+				if err != nil {
+					return err
+				}
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if q.OrderByName {
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			if a.Expression() != b.Expression() {
+				return a.Expression() < b.Expression()
+			}
+			return a.Location.String() < b.Location.String()
+		})
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(entries) {
+			return nil, nil
+		}
+		entries = entries[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(entries) {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (s *Store) GetEntry(ctx context.Context, name string) (cron.Entry, error) {
+	var entries []cron.Entry
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		stored, err := boltEntries(buckets[0], name)
+		if err != nil {
+			return err
+		}
+		for _, v := range stored {
+			entry, err := v.entry(name)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return cron.Entry{}, err
+	}
+
+	switch len(entries) {
+	case 0:
+		return cron.Entry{}, cron.ErrEntryNotFound
+	case 1:
+		return entries[0], nil
+	default:
+		return cron.Entry{}, cron.AmbiguousEntryError{Name: name, Entries: entries}
+	}
+}
+
+// AddEntry stores the entry, adding again the same entry keeps whether it is paused. The location is stored by name
+// so it must be loadable with time.LoadLocation (ex: not time.FixedZone).
+func (s *Store) AddEntry(ctx context.Context, entry cron.Entry) error {
+	if err := validateEntry(entry); err != nil {
+		return err
+	}
+
+	added := newBoltEntry(entry)
+	return s.updateEntries(ctx, entry.Name, func(entries []boltEntry) ([]boltEntry, error) {
+		for i, v := range entries {
+			if v.same(added) {
+				added.Paused = v.Paused
+				entries[i] = added
+				return entries, nil
+			}
+		}
+		return append(entries, added), nil
+	})
+}
+
+func (s *Store) DeleteEntry(ctx context.Context, entry cron.Entry) error {
+	deleted := newBoltEntry(entry)
+	return s.updateEntries(ctx, entry.Name, func(entries []boltEntry) ([]boltEntry, error) {
+		return removeBoltEntry(entries, deleted), nil
+	})
+}
+
+func removeBoltEntry(entries []boltEntry, removed boltEntry) []boltEntry {
+	var ret []boltEntry
+	for _, v := range entries {
+		if !v.same(removed) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+func (s *Store) UpdateEntry(ctx context.Context, name string, updated cron.Entry) error {
+	if err := validateEntry(updated); err != nil {
+		return err
+	}
+
+	record := newBoltEntry(updated)
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		stored, err := boltEntries(buckets[0], name)
+		if err != nil {
+			return err
+		}
+		if len(stored) == 0 {
+			return cron.ErrEntryNotFound
+		}
+		if err := buckets[0].Delete([]byte(name)); err != nil {
+			return err
+		}
+
+		// the updated entry also replaces the same entry of the new name
+		stored, err = boltEntries(buckets[0], updated.Name)
+		if err != nil {
+			return err
+		}
+		return putBoltEntries(buckets[0], updated.Name, append(removeBoltEntry(stored, record), record))
+	})
+}
+
+func (s *Store) SetEntryActive(ctx context.Context, name string, active bool) error {
+	return s.updateEntries(ctx, name, func(entries []boltEntry) ([]boltEntry, error) {
+		if len(entries) == 0 {
+			return nil, cron.ErrEntryNotFound
+		}
+		for i := range entries {
+			entries[i].Paused = !active
+		}
+		return entries, nil
+	})
+}
+
+// updateEntries replaces the entries with the name by the result of fn
+func (s *Store) updateEntries(ctx context.Context, name string, fn func(entries []boltEntry) ([]boltEntry, error)) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		stored, err := boltEntries(buckets[0], name)
+		if err != nil {
+			return err
+		}
+		if stored, err = fn(stored); err != nil {
+			return err
+		}
+		return putBoltEntries(buckets[0], name, stored)
+	})
+}
+
+// boltEvent is the stored event
+type boltEvent struct {
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Location   string    `json:"location"`
+	Meta       string    `json:"meta,omitempty"`
+	Time       time.Time `json:"time"`
+	Manual     bool      `json:"manual,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	FiredBy    string    `json:"fired_by,omitempty"`
+}
+
+func (v boltEvent) event() (cron.Event, error) {
+	entry, err := boltEntry{Expression: v.Expression, Location: v.Location, Meta: v.Meta}.entry(v.Name)
+	if err != nil {
+		return cron.Event{}, err
+	}
+
+	return cron.Event{Entry: entry, Time: v.Time.In(entry.Location), Manual: v.Manual, Status: v.Status, FiredBy: v.FiredBy}, nil
+}
+
+// boltSeconds is the key prefix of the events on the second of t, the times before 1970 are on the first key
+func boltSeconds(t time.Time) []byte {
+	key := make([]byte, 8)
+	if sec := t.Unix(); sec > 0 {
+		binary.BigEndian.PutUint64(key, uint64(sec))
+	}
+
+	return key
+}
+
+// boltEventKey identifies an event like the primary key of the cron.SqlStore events table
+func boltEventKey(e cron.Event) []byte {
+	id := strings.Join([]string{e.Entry.Name, e.Entry.Expression(), e.Entry.Location.String()}, "\x00")
+	return append(boltSeconds(e.Time), id...)
+}
+
+func (s *Store) AddEvent(ctx context.Context, e cron.Event) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		return putBoltEvent(buckets[1], e)
+	})
+}
+
+// AddEvents implements EventsAdder, the events are recorded in one transaction when the store is not locked
+func (s *Store) AddEvents(ctx context.Context, events []cron.Event) error {
+	var errs cron.EventErrors
+	err := s.update(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		for i, e := range events {
+			if err := putBoltEvent(buckets[1], e); err != nil {
+				if errs == nil {
+					errs = make(cron.EventErrors, len(events))
+				}
+				errs[i] = err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if errs == nil {
+		return nil
+	}
+	return errs
+}
+
+// putBoltEvent stores the event, it returns cron.ErrAlreadyTriggered if the key of the event exists
+func putBoltEvent(b *bolt.Bucket, e cron.Event) error {
+	if err := validateEntry(e.Entry); err != nil {
+		return err
+	}
+	key := boltEventKey(e)
+	if b.Get(key) != nil {
+		return cron.ErrAlreadyTriggered
+	}
+	value, err := json.Marshal(boltEvent{
+		Name:       e.Entry.Name,
+		Expression: e.Entry.Expression(),
+		Location:   e.Entry.Location.String(),
+		Meta:       e.Entry.Meta,
+		Time:       e.Time.UTC(),
+		Manual:     e.Manual,
+		Status:     e.Status,
+		FiredBy:    e.FiredBy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	return b.Put(key, value)
+}
+
+// scanEvents calls fn with the events of the seconds of [from, to] in order of the keys until fn returns false
+func (s *Store) scanEvents(ctx context.Context, from, to time.Time, fn func(e cron.Event) bool) error {
+	last := boltSeconds(to)
+	return s.view(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		c := buckets[1].Cursor()
+		for k, v := c.Seek(boltSeconds(from)); k != nil && bytes.Compare(k[:8], last) <= 0; k, v = c.Next() {
+			e, err := decodeBoltEvent(v)
+			if err != nil {
+				return err
+			}
+			if !fn(e) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func decodeBoltEvent(value []byte) (cron.Event, error) {
+	var v boltEvent
+	if err := json.Unmarshal(value, &v); err != nil {
+		return cron.Event{}, fmt.Errorf("failed to decode event: %v", err)
+	}
+
+	return v.event()
+}
+
+func (s *Store) GetEvents(ctx context.Context, from, to time.Time) ([]cron.Event, error) {
+	var events []cron.Event
+	err := s.scanEvents(ctx, from, to, func(e cron.Event) bool {
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			events = append(events, e)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Entry.Name != b.Entry.Name {
+			return a.Entry.Name < b.Entry.Name
+		}
+		if a.Entry.Expression() != b.Entry.Expression() {
+			return a.Entry.Expression() < b.Entry.Expression()
+		}
+		return a.Entry.Location.String() < b.Entry.Location.String()
+	})
+	return events, nil
+}
+
+func (s *Store) QueryEvents(ctx context.Context, from, to time.Time, opts ...cron.EventsOption) ([]cron.Event, error) {
+	events, err := s.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	q := cron.NewEventsQuery(opts...)
+	if len(q.Names) > 0 {
+		events = filterEvents(events, q.Names)
+	}
+	if q.Status != "" {
+		var ret []cron.Event
+		for _, e := range events {
+			if e.Status == q.Status {
+				ret = append(ret, e)
+			}
+		}
+		events = ret
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(events) {
+			return nil, nil
+		}
+		events = events[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(events) {
+		events = events[:q.Limit]
+	}
+	return events, nil
+}
+
+// filterEvents returns the events of the entries with the names
+func filterEvents(events []cron.Event, names []string) []cron.Event {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	var ret []cron.Event
+	for _, e := range events {
+		if set[e.Entry.Name] {
+			ret = append(ret, e)
+		}
+	}
+
+	return ret
+}
+
+func (s *Store) GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]cron.Event, error) {
+	events, err := s.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]cron.Event, 0)
+	for _, v := range events {
+		if v.Entry.Name == name {
+			ret = append(ret, v)
+		}
+	}
+	return ret, nil
+}
+
+// GetEventsForEntries implements EntryEventsGetter
+func (s *Store) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]cron.Event, error) {
+	events, err := s.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return filterEvents(events, names), nil
+}
+
+func (s *Store) CountEvents(ctx context.Context, from, to time.Time) (int64, error) {
+	var n int64
+	err := s.scanEvents(ctx, from, to, func(e cron.Event) bool {
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			n++
+		}
+		return true
+	})
+	return n, err
+}
+
+// LastEvent scans the events from the latest
+func (s *Store) LastEvent(ctx context.Context, name string, opts ...cron.EventsOption) (cron.Event, bool, error) {
+	status := cron.NewEventsQuery(opts...).Status
+	var (
+		last  cron.Event
+		found bool
+	)
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		c := buckets[1].Cursor()
+		var lastSeconds []byte
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			// the events of the same second are not ordered by time
+			if found && !bytes.Equal(k[:8], lastSeconds) {
+				return nil
+			}
+			e, err := decodeBoltEvent(v)
+			if err != nil {
+				return err
+			}
+			if e.Entry.Name == name && (status == "" || e.Status == status) && (!found || e.Time.After(last.Time)) {
+				last, found = e, true
+				lastSeconds = append(lastSeconds[:0], k[:8]...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return cron.Event{}, false, err
+	}
+
+	return last, found, nil
+}
+
+func (s *Store) DeleteEvents(ctx context.Context, until time.Time) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		buckets, err := boltBuckets(tx)
+		if err != nil {
+			return err
+		}
+		// the keys are deleted after the scan, the cursor skips keys when the bucket is modified
+		var deleted [][]byte
+		last := boltSeconds(until)
+		c := buckets[1].Cursor()
+		for k, v := c.First(); k != nil && bytes.Compare(k[:8], last) <= 0; k, v = c.Next() {
+			if bytes.Compare(k[:8], last) == 0 {
+				e, err := decodeBoltEvent(v)
+				if err != nil {
+					return err
+				}
+				if !e.Time.Before(until) {
+					continue
+				}
+			}
+			deleted = append(deleted, append([]byte(nil), k...))
+		}
+		for _, k := range deleted {
+			if err := buckets[1].Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,958 @@
+// Package crondynamo provides DynamoDB implementation of cron.Store with aws-sdk-go-v2
+//
+//	cfg, err := config.LoadDefaultConfig(ctx)
+//	...
+//	store := crondynamo.NewStore(dynamodb.NewFromConfig(cfg), "cron")
+//	scheduler := cron.NewScheduler(handler, store)
+//
+// Every item is in a single table with the string keys PK and SK, and the global secondary index "GSI1" on the string
+// keys GSI1PK and GSI1SK. Initialize creates the table on demand capacity if it does not exist.
+//
+//	PK                SK                                GSI1PK    GSI1SK
+//	ENTRY#<name>      ENTRY                             ENTRIES   <name>                                    JSON array of the entries with the name
+//	EVENT#<name>      <time>#<expression>#<location>    EVENTS    <time>#<name>#<expression>#<location>     JSON of the event
+//	LOCK              LOCK                                                                                  token and expiry of the lock
+//
+// The time of the events is RFC 3339 in UTC with a fixed number of fractional digits so that the keys sort
+// chronologically. The events of an entry are a range of SK, the events of every entry are a range of GSI1SK. An event
+// is recorded at most once with a conditional put on its key.
+//
+// GetEntries and the events of every entry (ex: GetEvents) are read from the index which is eventually consistent, a
+// change is seen after a moment. The entry with a name (GetEntry) and the events of entries (ex: LastEvent or
+// QueryEvents with cron.ForEntries) are read consistently.
+//
+// The events have the expiry attribute "ttl" so that DynamoDB deletes them after the event TTL (see WithEventTTL) when
+// Time to Live is enabled on the attribute, which Initialize does for the table it creates. DeleteEvents deletes them
+// explicitly.
+package crondynamo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yulrizka/cron"
+)
+
+// Client is the part of *dynamodb.Client used by the Store
+type Client interface {
+	dynamodb.DescribeTableAPIClient
+	CreateTable(ctx context.Context, in *dynamodb.CreateTableInput, opts ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	UpdateTimeToLive(ctx context.Context, in *dynamodb.UpdateTimeToLiveInput, opts ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, in *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	TransactWriteItems(ctx context.Context, in *dynamodb.TransactWriteItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+// Store implements cron.Store and cron.EntryEventsGetter on DynamoDB
+type Store struct {
+	client Client
+	table  string
+
+	lockTTL     time.Duration // expiry of the lock
+	lockTimeout time.Duration // wait for the lock
+	eventTTL    time.Duration // expiry of the events with DynamoDB Time to Live
+
+	mu        sync.Mutex
+	lockToken string // token of the lock item while the store is locked
+}
+
+// Option configures the Store
+type Option func(s *Store)
+
+// WithLock sets the expiry of the lock (default 1 minute) and how long Lock waits for it (default the expiry). The
+// expiry must be longer than a check, otherwise another instance acquires the lock while the check is running and
+// Unlock returns cron.ErrLockLost. Lock returns cron.ErrLockTimeout when the lock is not acquired within timeout.
+func WithLock(ttl, timeout time.Duration) Option {
+	return func(s *Store) {
+		s.lockTTL = ttl
+		s.lockTimeout = timeout
+	}
+}
+
+// WithEventTTL sets the expiry of the events (default cron.KeepEventDuration), zero or less keeps the default. It must
+// be longer than the retention of the scheduler (see cron.WithEventRetention) since DynamoDB may delete an expired
+// item days later, the events are deleted by DeleteEvents in the meantime.
+func WithEventTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		if ttl > 0 {
+			s.eventTTL = ttl
+		}
+	}
+}
+
+// NewStore creates store on the table
+func NewStore(client Client, table string, opts ...Option) *Store {
+	s := &Store{
+		client:   client,
+		table:    table,
+		lockTTL:  time.Minute,
+		eventTTL: cron.KeepEventDuration,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.lockTimeout <= 0 {
+		s.lockTimeout = s.lockTTL
+	}
+
+	return s
+}
+
+const (
+	indexName    = "GSI1"
+	ttlAttribute = "ttl"
+	entriesPK    = "ENTRIES" // GSI1PK of the entries
+	eventsPK     = "EVENTS"  // GSI1PK of the events
+	lockKey      = "LOCK"    // PK and SK of the lock
+)
+
+// tableActiveTimeout is the wait for the table created by Initialize to become active
+const tableActiveTimeout = 5 * time.Minute
+
+// Initialize creates the table with the index if it does not exist and enables Time to Live of the events
+func (s *Store) Initialize(ctx context.Context) error {
+	_, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	if !errors.As(err, new(*types.ResourceNotFoundException)) {
+		if err != nil {
+			return fmt.Errorf("failed describing table %s: %v", s.table, err)
+		}
+		return nil
+	}
+
+	key := func(pk, sk string) []types.KeySchemaElement {
+		return []types.KeySchemaElement{
+			{AttributeName: aws.String(pk), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(sk), KeyType: types.KeyTypeRange},
+		}
+	}
+	var attributes []types.AttributeDefinition
+	for _, name := range []string{"PK", "SK", "GSI1PK", "GSI1SK"} {
+		attributes = append(attributes, types.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: types.ScalarAttributeTypeS,
+		})
+	}
+	_, err = s.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String(s.table),
+		AttributeDefinitions: attributes,
+		KeySchema:            key("PK", "SK"),
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+			IndexName:  aws.String(indexName),
+			KeySchema:  key("GSI1PK", "GSI1SK"),
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	// another instance may create the table at the same time, it also enables time to live
+	created := err == nil
+	if err != nil && !errors.As(err, new(*types.ResourceInUseException)) {
+		return fmt.Errorf("failed creating table %s: %v", s.table, err)
+	}
+	waiter := dynamodb.NewTableExistsWaiter(s.client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}, tableActiveTimeout); err != nil {
+		return fmt.Errorf("failed waiting for table %s: %v", s.table, err)
+	}
+	if !created {
+		return nil
+	}
+	_, err = s.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(s.table),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(ttlAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed enabling time to live of table %s: %v", s.table, err)
+	}
+
+	return nil
+}
+
+// Health describes the table
+func (s *Store) Health(ctx context.Context) error {
+	if _, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}); err != nil {
+		return fmt.Errorf("failed describing table %s: %v", s.table, err)
+	}
+
+	return nil
+}
+
+// str is the string attribute value
+func str(v string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: v}
+}
+
+// num is the number attribute value
+func num(v int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(v, 10)}
+}
+
+// stringAttr returns the string attribute of the item, empty if it is not a string
+func stringAttr(item map[string]types.AttributeValue, name string) string {
+	v, _ := item[name].(*types.AttributeValueMemberS)
+	if v == nil {
+		return ""
+	}
+
+	return v.Value
+}
+
+// lockRetryInterval is the wait between the attempts of Lock while another instance holds the lock
+const lockRetryInterval = 100 * time.Millisecond
+
+// Lock puts the lock item if it does not exist or is expired, it waits until the lock is released by another instance
+// or expires
+func (s *Store) Lock(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lockToken != "" {
+		return errors.New("store is already locked")
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Errorf("failed creating lock token: %v", err)
+	}
+	token := hex.EncodeToString(b[:])
+	deadline := time.Now().Add(s.lockTimeout)
+	for {
+		now := time.Now()
+		expires := now.Add(s.lockTTL)
+		_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item: map[string]types.AttributeValue{
+				"PK":         str(lockKey),
+				"SK":         str(lockKey),
+				"token":      str(token),
+				"expires":    num(expires.UnixMilli()),
+				ttlAttribute: num(expires.Unix() + 1),
+			},
+			ConditionExpression:       aws.String("attribute_not_exists(PK) OR #expires < :now"),
+			ExpressionAttributeNames:  map[string]string{"#expires": "expires"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":now": num(now.UnixMilli())},
+		})
+		if err == nil {
+			s.lockToken = token
+			return nil
+		}
+		if !errors.As(err, new(*types.ConditionalCheckFailedException)) {
+			return fmt.Errorf("failed acquiring lock: %v", err)
+		}
+		if !time.Now().Before(deadline) {
+			return cron.ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Unlock deletes the lock item if it is still held with the token. It returns (wrapped) cron.ErrLockLost when the lock
+// expired and was acquired by another instance.
+func (s *Store) Unlock(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lockToken == "" {
+		return errors.New("store is not locked")
+	}
+	token := s.lockToken
+	s.lockToken = ""
+
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       map[string]types.AttributeValue{"PK": str(lockKey), "SK": str(lockKey)},
+		ConditionExpression:       aws.String("#token = :token"),
+		ExpressionAttributeNames:  map[string]string{"#token": "token"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":token": str(token)},
+	})
+	if errors.As(err, new(*types.ConditionalCheckFailedException)) {
+		return fmt.Errorf("%w: the lock expired after %s", cron.ErrLockLost, s.lockTTL)
+	}
+	if err != nil {
+		return fmt.Errorf("failed releasing lock: %v", err)
+	}
+
+	return nil
+}
+
+// entryJSON is the stored entry, it has the same fields as the JSON encoding of cron.Entry
+type entryJSON struct {
+	Name           string   `json:"name"`
+	Expression     string   `json:"expression"`
+	Location       string   `json:"location"`
+	Meta           string   `json:"meta,omitempty"`
+	Paused         bool     `json:"paused,omitempty"`
+	IgnoreBlackout bool     `json:"ignore_blackout,omitempty"`
+	Timeout        string   `json:"timeout,omitempty"`
+	DependsOn      []string `json:"depends_on,omitempty"`
+}
+
+func newEntryJSON(e cron.Entry) entryJSON {
+	loc := time.UTC
+	if e.Location != nil {
+		loc = e.Location
+	}
+	var timeout string
+	if e.Timeout > 0 {
+		timeout = e.Timeout.String()
+	}
+
+	return entryJSON{
+		Name:           e.Name,
+		Expression:     e.Expression(),
+		Location:       loc.String(),
+		Meta:           e.Meta,
+		Paused:         e.Paused,
+		IgnoreBlackout: e.IgnoreBlackout,
+		Timeout:        timeout,
+		DependsOn:      e.DependsOn,
+	}
+}
+
+// same reports whether v is the same entry (name, expression and location)
+func (v entryJSON) same(o entryJSON) bool {
+	return v.Name == o.Name && v.Expression == o.Expression && v.Location == o.Location
+}
+
+func (v entryJSON) entry() (cron.Entry, error) {
+	loc, err := time.LoadLocation(v.Location)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed loading location of entry %q: %v", v.Name, err)
+	}
+	e, err := cron.ParseStored(v.Expression, loc, v.Name)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed parsing entry %q: %v", v.Name, err)
+	}
+	if v.Timeout != "" {
+		if e.Timeout, err = time.ParseDuration(v.Timeout); err != nil {
+			return cron.Entry{}, fmt.Errorf("failed parsing timeout of entry %q: %v", v.Name, err)
+		}
+	}
+	e.Meta = v.Meta
+	e.Paused = v.Paused
+	e.IgnoreBlackout = v.IgnoreBlackout
+	e.DependsOn = v.DependsOn
+
+	return e, nil
+}
+
+// validateEntry returns an error if the entry can not be read back
+func validateEntry(e cron.Entry) error {
+	if e.Name == "" {
+		return errors.New("got empty name")
+	}
+	if e.Expression() == "" {
+		return errors.New("got empty expression")
+	}
+	if e.Location != nil {
+		if _, err := time.LoadLocation(e.Location.String()); err != nil {
+			return fmt.Errorf("location %q of entry %q is not in the tz database, use time.LoadLocation: %v", e.Location,
+				e.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// query calls fn with the items of every page of the query until fn returns false
+func (s *Store) query(ctx context.Context, in *dynamodb.QueryInput, fn func(item map[string]types.AttributeValue) (bool, error)) error {
+	in.TableName = aws.String(s.table)
+	for {
+		out, err := s.client.Query(ctx, in)
+		if err != nil {
+			return fmt.Errorf("failed querying table %s: %v", s.table, err)
+		}
+		for _, item := range out.Items {
+			if ok, err := fn(item); err != nil || !ok {
+				return err
+			}
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// GetEntries reads the entries from the index and applies the options, see cron.EntriesQuery
+func (s *Store) GetEntries(ctx context.Context, opts ...cron.EntriesOption) ([]cron.Entry, error) {
+	q := cron.NewEntriesQuery(opts...)
+	in := &dynamodb.QueryInput{
+		IndexName:              aws.String(indexName),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": str(entriesPK),
+		},
+	}
+	if q.NamePrefix != "" {
+		in.KeyConditionExpression = aws.String("GSI1PK = :pk AND begins_with(GSI1SK, :prefix)")
+		in.ExpressionAttributeValues[":prefix"] = str(q.NamePrefix)
+	}
+
+	var entries []cron.Entry
+	err := s.query(ctx, in, func(item map[string]types.AttributeValue) (bool, error) {
+		records, err := decodeEntries(stringAttr(item, "GSI1SK"), stringAttr(item, "entries"))
+		if err != nil {
+			return false, err
+		}
+		for _, v := range records {
+			if v.Paused && !q.IncludeInactive {
+				continue
+			}
+			e, err := v.entry()
+			if err != nil {
+				return false, err
+			}
+			entries = append(entries, e)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if q.OrderByName {
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			if a.Expression() != b.Expression() {
+				return a.Expression() < b.Expression()
+			}
+			return a.Location.String() < b.Location.String()
+		})
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(entries) {
+			return nil, nil
+		}
+		entries = entries[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(entries) {
+		entries = entries[:q.Limit]
+	}
+
+	return entries, nil
+}
+
+func decodeEntries(name, value string) ([]entryJSON, error) {
+	var records []entryJSON
+	if err := json.Unmarshal([]byte(value), &records); err != nil {
+		return nil, fmt.Errorf("failed decoding entries %q: %v", name, err)
+	}
+
+	return records, nil
+}
+
+// entryKey is the key of the item of the entries with the name
+func entryKey(name string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"PK": str("ENTRY#" + name), "SK": str("ENTRY")}
+}
+
+// getEntries reads the entries with the name consistently, with the version of the item (0 if it does not exist)
+func (s *Store) getEntries(ctx context.Context, name string) ([]entryJSON, int64, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            entryKey(name),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed reading entry %q: %v", name, err)
+	}
+	if out.Item == nil {
+		return nil, 0, nil
+	}
+	records, err := decodeEntries(name, stringAttr(out.Item, "entries"))
+	if err != nil {
+		return nil, 0, err
+	}
+	v, _ := out.Item["version"].(*types.AttributeValueMemberN)
+	if v == nil {
+		return nil, 0, fmt.Errorf("entry %q has no version", name)
+	}
+	version, err := strconv.ParseInt(v.Value, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed parsing version of entry %q: %v", name, err)
+	}
+
+	return records, version, nil
+}
+
+// GetEntry returns the entry with the name, see cron.Store
+func (s *Store) GetEntry(ctx context.Context, name string) (cron.Entry, error) {
+	records, _, err := s.getEntries(ctx, name)
+	if err != nil {
+		return cron.Entry{}, err
+	}
+
+	entries := make([]cron.Entry, 0, len(records))
+	for _, v := range records {
+		e, err := v.entry()
+		if err != nil {
+			return cron.Entry{}, err
+		}
+		entries = append(entries, e)
+	}
+	switch len(entries) {
+	case 0:
+		return cron.Entry{}, cron.ErrEntryNotFound
+	case 1:
+		return entries[0], nil
+	default:
+		return cron.Entry{}, cron.AmbiguousEntryError{Name: name, Entries: entries}
+	}
+}
+
+// AddEntry stores the entry, adding again the same entry keeps whether it is paused. The location must be loadable
+// with time.LoadLocation (ex: not time.FixedZone).
+func (s *Store) AddEntry(ctx context.Context, entry cron.Entry) error {
+	if err := validateEntry(entry); err != nil {
+		return err
+	}
+
+	added := newEntryJSON(entry)
+	return s.updateEntries(ctx, []string{entry.Name}, func(entries map[string][]entryJSON) error {
+		for i, v := range entries[entry.Name] {
+			if v.same(added) {
+				added.Paused = v.Paused
+				entries[entry.Name][i] = added
+				return nil
+			}
+		}
+		entries[entry.Name] = append(entries[entry.Name], added)
+		return nil
+	})
+}
+
+// DeleteEntry deletes the entry with the same name, expression and location
+func (s *Store) DeleteEntry(ctx context.Context, entry cron.Entry) error {
+	deleted := newEntryJSON(entry)
+	return s.updateEntries(ctx, []string{entry.Name}, func(entries map[string][]entryJSON) error {
+		entries[entry.Name] = removeEntry(entries[entry.Name], deleted)
+		return nil
+	})
+}
+
+func removeEntry(records []entryJSON, removed entryJSON) []entryJSON {
+	var ret []entryJSON
+	for _, v := range records {
+		if !v.same(removed) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// UpdateEntry replaces the entries with the name by the updated entry, see cron.Store
+func (s *Store) UpdateEntry(ctx context.Context, name string, updated cron.Entry) error {
+	if err := validateEntry(updated); err != nil {
+		return err
+	}
+
+	record := newEntryJSON(updated)
+	return s.updateEntries(ctx, []string{name, updated.Name}, func(entries map[string][]entryJSON) error {
+		if len(entries[name]) == 0 {
+			return cron.ErrEntryNotFound
+		}
+		entries[name] = nil
+		entries[updated.Name] = append(removeEntry(entries[updated.Name], record), record)
+		return nil
+	})
+}
+
+// SetEntryActive activates or pauses the entries with the name
+func (s *Store) SetEntryActive(ctx context.Context, name string, active bool) error {
+	return s.updateEntries(ctx, []string{name}, func(entries map[string][]entryJSON) error {
+		if len(entries[name]) == 0 {
+			return cron.ErrEntryNotFound
+		}
+		for i := range entries[name] {
+			entries[name][i].Paused = !active
+		}
+		return nil
+	})
+}
+
+// updateAttempts is the number of attempts of updateEntries when the entries are modified concurrently
+const updateAttempts = 10
+
+// updateEntries calls fn with the entries of the names and writes them back in a transaction conditioned on the
+// version of the items. It is retried when the entries are modified by another instance in between.
+func (s *Store) updateEntries(ctx context.Context, names []string, fn func(entries map[string][]entryJSON) error) error {
+	if len(names) > 1 && names[0] == names[1] {
+		names = names[:1]
+	}
+
+	for attempt := 1; ; attempt++ {
+		entries := make(map[string][]entryJSON, len(names))
+		versions := make(map[string]int64, len(names))
+		for _, name := range names {
+			var err error
+			if entries[name], versions[name], err = s.getEntries(ctx, name); err != nil {
+				return err
+			}
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+
+		var items []types.TransactWriteItem
+		for _, name := range names {
+			condition := aws.String("attribute_not_exists(PK)")
+			values := map[string]types.AttributeValue{}
+			if versions[name] > 0 {
+				condition = aws.String("version = :version")
+				values[":version"] = num(versions[name])
+			}
+			if len(entries[name]) == 0 {
+				if versions[name] > 0 {
+					items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+						TableName:                 aws.String(s.table),
+						Key:                       entryKey(name),
+						ConditionExpression:       condition,
+						ExpressionAttributeValues: values,
+					}})
+				}
+				continue
+			}
+			value, err := json.Marshal(entries[name])
+			if err != nil {
+				return err
+			}
+			item := entryKey(name)
+			item["GSI1PK"] = str(entriesPK)
+			item["GSI1SK"] = str(name)
+			item["entries"] = str(string(value))
+			item["version"] = num(versions[name] + 1)
+			if len(values) == 0 {
+				values = nil
+			}
+			items = append(items, types.TransactWriteItem{Put: &types.Put{
+				TableName:                 aws.String(s.table),
+				Item:                      item,
+				ConditionExpression:       condition,
+				ExpressionAttributeValues: values,
+			}})
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			return nil
+		}
+		if !errors.As(err, new(*types.TransactionCanceledException)) || attempt >= updateAttempts {
+			return fmt.Errorf("failed writing entries: %v", err)
+		}
+	}
+}
+
+// eventJSON is the stored event
+type eventJSON struct {
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Location   string    `json:"location"`
+	Meta       string    `json:"meta,omitempty"`
+	Time       time.Time `json:"time"`
+	Manual     bool      `json:"manual,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	FiredBy    string    `json:"fired_by,omitempty"`
+}
+
+func (v eventJSON) event() (cron.Event, error) {
+	e, err := entryJSON{Name: v.Name, Expression: v.Expression, Location: v.Location, Meta: v.Meta}.entry()
+	if err != nil {
+		return cron.Event{}, err
+	}
+
+	return cron.Event{Entry: e, Time: v.Time, Manual: v.Manual, Status: v.Status, FiredBy: v.FiredBy}, nil
+}
+
+// timeLayout is RFC 3339 with fixed fractional digits, the times in UTC sort chronologically
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// timeKey is the prefix of SK and GSI1SK of the events on t
+func timeKey(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// eventKey is the key of the item of the event
+func eventKey(name, expression, location string, t time.Time) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": str("EVENT#" + name),
+		"SK": str(timeKey(t) + "#" + expression + "#" + location),
+	}
+}
+
+// AddEvent records the event, it returns cron.ErrAlreadyTriggered if the entry already has an event on the same time
+func (s *Store) AddEvent(ctx context.Context, e cron.Event) error {
+	if err := validateEntry(e.Entry); err != nil {
+		return err
+	}
+	v := eventJSON{
+		Name:       e.Entry.Name,
+		Expression: e.Entry.Expression(),
+		Location:   newEntryJSON(e.Entry).Location,
+		Meta:       e.Entry.Meta,
+		Time:       e.Time.UTC(),
+		Manual:     e.Manual,
+		Status:     e.Status,
+		FiredBy:    e.FiredBy,
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	item := eventKey(v.Name, v.Expression, v.Location, v.Time)
+	item["GSI1PK"] = str(eventsPK)
+	item["GSI1SK"] = str(strings.Join([]string{timeKey(v.Time), v.Name, v.Expression, v.Location}, "#"))
+	item["event"] = str(string(value))
+	item[ttlAttribute] = num(v.Time.Add(s.eventTTL).Unix())
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if errors.As(err, new(*types.ConditionalCheckFailedException)) {
+		return cron.ErrAlreadyTriggered
+	}
+	if err != nil {
+		return fmt.Errorf("failed adding event: %v", err)
+	}
+
+	return nil
+}
+
+func decodeEvent(item map[string]types.AttributeValue) (cron.Event, error) {
+	value := stringAttr(item, "event")
+	var v eventJSON
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return cron.Event{}, fmt.Errorf("failed decoding event %q: %v", value, err)
+	}
+
+	return v.event()
+}
+
+// events reads the events on [from, to) ordered by time, then name, expression and location. The events of every
+// entry are read from the index when names is empty, otherwise the events of each name are read from the table.
+func (s *Store) events(ctx context.Context, from, to time.Time, names []string) ([]cron.Event, error) {
+	if !from.Before(to) {
+		return nil, nil
+	}
+	// the keys of the events on to are after timeKey(to) since they have a suffix
+	values := map[string]types.AttributeValue{":from": str(timeKey(from)), ":to": str(timeKey(to))}
+	var inputs []*dynamodb.QueryInput
+	if len(names) == 0 {
+		values[":pk"] = str(eventsPK)
+		inputs = append(inputs, &dynamodb.QueryInput{
+			IndexName:                 aws.String(indexName),
+			KeyConditionExpression:    aws.String("GSI1PK = :pk AND GSI1SK BETWEEN :from AND :to"),
+			ExpressionAttributeValues: values,
+		})
+	}
+	for _, name := range names {
+		nameValues := map[string]types.AttributeValue{":pk": str("EVENT#" + name)}
+		for k, v := range values {
+			nameValues[k] = v
+		}
+		inputs = append(inputs, &dynamodb.QueryInput{
+			KeyConditionExpression:    aws.String("PK = :pk AND SK BETWEEN :from AND :to"),
+			ExpressionAttributeValues: nameValues,
+			ConsistentRead:            aws.Bool(true),
+		})
+	}
+
+	var events []cron.Event
+	for _, in := range inputs {
+		err := s.query(ctx, in, func(item map[string]types.AttributeValue) (bool, error) {
+			e, err := decodeEvent(item)
+			if err != nil {
+				return false, err
+			}
+			if !e.Time.Before(from) && e.Time.Before(to) {
+				events = append(events, e)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed reading events: %v", err)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Entry.Name != b.Entry.Name {
+			return a.Entry.Name < b.Entry.Name
+		}
+		if a.Entry.Expression() != b.Entry.Expression() {
+			return a.Entry.Expression() < b.Entry.Expression()
+		}
+		return a.Entry.Location.String() < b.Entry.Location.String()
+	})
+
+	return events, nil
+}
+
+// GetEvents on [from, to) ordered by time, then name
+func (s *Store) GetEvents(ctx context.Context, from, to time.Time) ([]cron.Event, error) {
+	return s.events(ctx, from, to, nil)
+}
+
+// QueryEvents on [from, to) ordered like GetEvents, see cron.EventsQuery for the options
+func (s *Store) QueryEvents(ctx context.Context, from, to time.Time, opts ...cron.EventsOption) ([]cron.Event, error) {
+	q := cron.NewEventsQuery(opts...)
+	names := make(map[string]bool, len(q.Names))
+	for _, name := range q.Names {
+		names[name] = true
+	}
+	unique := make([]string, 0, len(names))
+	for name := range names {
+		unique = append(unique, name)
+	}
+	events, err := s.events(ctx, from, to, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []cron.Event
+	for _, e := range events {
+		if q.Status == "" || e.Status == q.Status {
+			ret = append(ret, e)
+		}
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(ret) {
+			return nil, nil
+		}
+		ret = ret[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(ret) {
+		ret = ret[:q.Limit]
+	}
+
+	return ret, nil
+}
+
+// GetEventsByName on [from, to) of the entries with the name, an empty slice if there is none
+func (s *Store) GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]cron.Event, error) {
+	events, err := s.QueryEvents(ctx, from, to, cron.ForEntries(name))
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = make([]cron.Event, 0)
+	}
+
+	return events, nil
+}
+
+// GetEventsForEntries implements cron.EntryEventsGetter
+func (s *Store) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]cron.Event, error) {
+	return s.QueryEvents(ctx, from, to, cron.ForEntries(names...))
+}
+
+// CountEvents on [from, to)
+func (s *Store) CountEvents(ctx context.Context, from, to time.Time) (int64, error) {
+	events, err := s.GetEvents(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(events)), nil
+}
+
+// LastEvent returns the latest event of the entry with the name, see cron.Store
+func (s *Store) LastEvent(ctx context.Context, name string, opts ...cron.EventsOption) (cron.Event, bool, error) {
+	status := cron.NewEventsQuery(opts...).Status
+	var (
+		last  cron.Event
+		found bool
+	)
+	// the keys are ordered by time, the first event with the status is the latest
+	err := s.query(ctx, &dynamodb.QueryInput{
+		KeyConditionExpression:    aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pk": str("EVENT#" + name)},
+		ScanIndexForward:          aws.Bool(false),
+		ConsistentRead:            aws.Bool(true),
+	}, func(item map[string]types.AttributeValue) (bool, error) {
+		e, err := decodeEvent(item)
+		if err != nil {
+			return false, err
+		}
+		if status == "" || e.Status == status {
+			last, found = e, true
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return cron.Event{}, false, fmt.Errorf("failed reading events: %v", err)
+	}
+
+	return last, found, nil
+}
+
+// batchWriteSize is the maximum number of requests of BatchWriteItem
+const batchWriteSize = 25
+
+// DeleteEvents deletes the events before until, the events are also deleted by DynamoDB after they expire (see
+// WithEventTTL)
+func (s *Store) DeleteEvents(ctx context.Context, until time.Time) error {
+	events, err := s.events(ctx, time.Time{}, until, nil)
+	if err != nil {
+		return err
+	}
+
+	requests := make([]types.WriteRequest, 0, len(events))
+	for _, e := range events {
+		entry := newEntryJSON(e.Entry)
+		requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{
+			Key: eventKey(entry.Name, entry.Expression, entry.Location, e.Time),
+		}})
+	}
+	for len(requests) > 0 {
+		n := min(len(requests), batchWriteSize)
+		out, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{s.table: requests[:n]},
+		})
+		if err != nil {
+			return fmt.Errorf("failed deleting events: %v", err)
+		}
+		// the requests that are throttled are sent again after a moment
+		unprocessed := out.UnprocessedItems[s.table]
+		requests = append(unprocessed, requests[n:]...)
+		if len(unprocessed) > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(lockRetryInterval):
+			}
+		}
+	}
+
+	return nil
+}
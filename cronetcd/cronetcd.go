@@ -0,0 +1,754 @@
+// Package cronetcd provides etcd implementation of cron.Store
+//
+//	client, err := clientv3.New(clientv3.Config{Endpoints: []string{"localhost:2379"}})
+//	...
+//	store := cronetcd.NewStore(client)
+//	defer store.Close()
+//	scheduler := cron.NewScheduler(handler, store, cron.WithEntryCache(time.Minute))
+//
+// The keys are under the prefix (default "/cron"):
+//
+//	/cron/entries/<name>                                     JSON array of the entries with the name
+//	/cron/events/<unix seconds>/<name>/<expression>/<location> JSON of the event
+//	/cron/lock                                               prefix of the keys of the lock, see concurrency.Mutex
+//
+// The seconds of the events are zero padded so that the keys sort chronologically and a range of time is a range of
+// keys. The name, expression and location are path escaped. An event is recorded at most once with a transaction that
+// puts the key only if it does not exist.
+//
+// The store implements cron.EntriesNotifier with Watch, the scheduler reloads the entry cache as soon as the entries
+// are changed (see cron.WithEntryCache).
+package cronetcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yulrizka/cron"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// Store implements cron.Store, cron.EntryEventsGetter and cron.EntriesNotifier on etcd
+type Store struct {
+	client *clientv3.Client
+	prefix string
+
+	lockTTL     int           // TTL in seconds of the lease of the lock session
+	lockTimeout time.Duration // wait for the lock
+
+	mu      sync.Mutex
+	session *concurrency.Session // session of the lock, created again when it expires
+	mutex   *concurrency.Mutex   // held lock, nil when the store is not locked
+}
+
+// Option configures the Store
+type Option func(s *Store)
+
+// WithPrefix sets the prefix of the keys (default "/cron"), stores with different prefixes do not see each other
+// entries and events.
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithLock sets the TTL of the lease of the lock session (default 1 minute, rounded up to seconds) and how long Lock
+// waits for the lock (default the TTL). The lease is kept alive while the instance is running, the lock of a crashed
+// instance is released when its lease expires. Lock returns cron.ErrLockTimeout when the lock is not acquired within
+// timeout.
+func WithLock(ttl, timeout time.Duration) Option {
+	return func(s *Store) {
+		s.lockTTL = int((ttl + time.Second - 1) / time.Second)
+		s.lockTimeout = timeout
+	}
+}
+
+// NewStore creates store on the client
+func NewStore(client *clientv3.Client, opts ...Option) *Store {
+	s := &Store{
+		client:  client,
+		prefix:  "/cron",
+		lockTTL: 60,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.lockTTL < 1 {
+		s.lockTTL = 1
+	}
+	if s.lockTimeout <= 0 {
+		s.lockTimeout = time.Duration(s.lockTTL) * time.Second
+	}
+
+	return s
+}
+
+func (s *Store) key(name string) string {
+	return s.prefix + "/" + name
+}
+
+// Initialize checks that the cluster is reachable, etcd does not need to create anything
+func (s *Store) Initialize(ctx context.Context) error {
+	return s.Health(ctx)
+}
+
+// Health reads a key, it fails when the cluster has no quorum
+func (s *Store) Health(ctx context.Context) error {
+	_, err := s.client.Get(ctx, s.key("health"))
+	return err
+}
+
+// Lock acquires the lock with a concurrency.Mutex, it waits until the lock is released by another instance or its
+// lease expires
+func (s *Store) Lock(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mutex != nil {
+		return errors.New("store is already locked")
+	}
+
+	if s.session != nil {
+		select {
+		case <-s.session.Done():
+			s.session = nil
+		default:
+		}
+	}
+	if s.session == nil {
+		session, err := concurrency.NewSession(s.client, concurrency.WithTTL(s.lockTTL))
+		if err != nil {
+			return fmt.Errorf("failed creating lock session: %v", err)
+		}
+		s.session = session
+	}
+
+	mutex := concurrency.NewMutex(s.session, s.key("lock"))
+	lockCtx, cancel := context.WithTimeout(ctx, s.lockTimeout)
+	defer cancel()
+	if err := mutex.Lock(lockCtx); err != nil {
+		if ctx.Err() == nil && errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+			return cron.ErrLockTimeout
+		}
+		return fmt.Errorf("failed acquiring lock: %v", err)
+	}
+	s.mutex = mutex
+
+	return nil
+}
+
+// Unlock releases the lock. It returns (wrapped) cron.ErrLockLost when the lease of the session expired before.
+func (s *Store) Unlock(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mutex == nil {
+		return errors.New("store is not locked")
+	}
+	mutex := s.mutex
+	s.mutex = nil
+
+	select {
+	case <-s.session.Done():
+		s.session = nil
+		return fmt.Errorf("%w: the lease of the lock session expired", cron.ErrLockLost)
+	default:
+	}
+	if err := mutex.Unlock(ctx); err != nil {
+		return fmt.Errorf("failed releasing lock: %v", err)
+	}
+
+	return nil
+}
+
+// Close revokes the lease of the lock session so that the lock is released right away, the client is not closed
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.session == nil {
+		return nil
+	}
+	err := s.session.Close()
+	s.session, s.mutex = nil, nil
+
+	return err
+}
+
+// entryJSON is the stored entry, it has the same fields as the JSON encoding of cron.Entry
+type entryJSON struct {
+	Name           string   `json:"name"`
+	Expression     string   `json:"expression"`
+	Location       string   `json:"location"`
+	Meta           string   `json:"meta,omitempty"`
+	Paused         bool     `json:"paused,omitempty"`
+	IgnoreBlackout bool     `json:"ignore_blackout,omitempty"`
+	Timeout        string   `json:"timeout,omitempty"`
+	DependsOn      []string `json:"depends_on,omitempty"`
+}
+
+func newEntryJSON(e cron.Entry) entryJSON {
+	loc := time.UTC
+	if e.Location != nil {
+		loc = e.Location
+	}
+	var timeout string
+	if e.Timeout > 0 {
+		timeout = e.Timeout.String()
+	}
+
+	return entryJSON{
+		Name:           e.Name,
+		Expression:     e.Expression(),
+		Location:       loc.String(),
+		Meta:           e.Meta,
+		Paused:         e.Paused,
+		IgnoreBlackout: e.IgnoreBlackout,
+		Timeout:        timeout,
+		DependsOn:      e.DependsOn,
+	}
+}
+
+// same reports whether v is the same entry (name, expression and location)
+func (v entryJSON) same(o entryJSON) bool {
+	return v.Name == o.Name && v.Expression == o.Expression && v.Location == o.Location
+}
+
+func (v entryJSON) entry() (cron.Entry, error) {
+	loc, err := time.LoadLocation(v.Location)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed loading location of entry %q: %v", v.Name, err)
+	}
+	e, err := cron.ParseStored(v.Expression, loc, v.Name)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed parsing entry %q: %v", v.Name, err)
+	}
+	if v.Timeout != "" {
+		if e.Timeout, err = time.ParseDuration(v.Timeout); err != nil {
+			return cron.Entry{}, fmt.Errorf("failed parsing timeout of entry %q: %v", v.Name, err)
+		}
+	}
+	e.Meta = v.Meta
+	e.Paused = v.Paused
+	e.IgnoreBlackout = v.IgnoreBlackout
+	e.DependsOn = v.DependsOn
+
+	return e, nil
+}
+
+// validateEntry returns an error if the entry can not be read back
+func validateEntry(e cron.Entry) error {
+	if e.Name == "" {
+		return errors.New("got empty name")
+	}
+	if e.Expression() == "" {
+		return errors.New("got empty expression")
+	}
+	if e.Location != nil {
+		if _, err := time.LoadLocation(e.Location.String()); err != nil {
+			return fmt.Errorf("location %q of entry %q is not in the tz database, use time.LoadLocation: %v", e.Location,
+				e.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeEntries(name string, value []byte) ([]entryJSON, error) {
+	var records []entryJSON
+	if err := json.Unmarshal(value, &records); err != nil {
+		return nil, fmt.Errorf("failed decoding entries %q: %v", name, err)
+	}
+
+	return records, nil
+}
+
+// GetEntries reads the entries and applies the options, see cron.EntriesQuery. The name prefix is a prefix of keys.
+func (s *Store) GetEntries(ctx context.Context, opts ...cron.EntriesOption) ([]cron.Entry, error) {
+	q := cron.NewEntriesQuery(opts...)
+	resp, err := s.client.Get(ctx, s.key("entries/"+q.NamePrefix), clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed reading entries: %v", err)
+	}
+
+	var entries []cron.Entry
+	for _, kv := range resp.Kvs {
+		records, err := decodeEntries(string(kv.Key), kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range records {
+			if v.Paused && !q.IncludeInactive {
+				continue
+			}
+			e, err := v.entry()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	}
+	if q.OrderByName {
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			if a.Expression() != b.Expression() {
+				return a.Expression() < b.Expression()
+			}
+			return a.Location.String() < b.Location.String()
+		})
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(entries) {
+			return nil, nil
+		}
+		entries = entries[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(entries) {
+		entries = entries[:q.Limit]
+	}
+
+	return entries, nil
+}
+
+// GetEntry returns the entry with the name, see cron.Store
+func (s *Store) GetEntry(ctx context.Context, name string) (cron.Entry, error) {
+	resp, err := s.client.Get(ctx, s.key("entries/"+name))
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed reading entry %q: %v", name, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return cron.Entry{}, cron.ErrEntryNotFound
+	}
+	records, err := decodeEntries(name, resp.Kvs[0].Value)
+	if err != nil {
+		return cron.Entry{}, err
+	}
+
+	entries := make([]cron.Entry, 0, len(records))
+	for _, v := range records {
+		e, err := v.entry()
+		if err != nil {
+			return cron.Entry{}, err
+		}
+		entries = append(entries, e)
+	}
+	switch len(entries) {
+	case 0:
+		return cron.Entry{}, cron.ErrEntryNotFound
+	case 1:
+		return entries[0], nil
+	default:
+		return cron.Entry{}, cron.AmbiguousEntryError{Name: name, Entries: entries}
+	}
+}
+
+// AddEntry stores the entry, adding again the same entry keeps whether it is paused. The location must be loadable
+// with time.LoadLocation (ex: not time.FixedZone).
+func (s *Store) AddEntry(ctx context.Context, entry cron.Entry) error {
+	if err := validateEntry(entry); err != nil {
+		return err
+	}
+
+	added := newEntryJSON(entry)
+	return s.updateEntries(ctx, []string{entry.Name}, func(entries map[string][]entryJSON) error {
+		for i, v := range entries[entry.Name] {
+			if v.same(added) {
+				added.Paused = v.Paused
+				entries[entry.Name][i] = added
+				return nil
+			}
+		}
+		entries[entry.Name] = append(entries[entry.Name], added)
+		return nil
+	})
+}
+
+// DeleteEntry deletes the entry with the same name, expression and location
+func (s *Store) DeleteEntry(ctx context.Context, entry cron.Entry) error {
+	deleted := newEntryJSON(entry)
+	return s.updateEntries(ctx, []string{entry.Name}, func(entries map[string][]entryJSON) error {
+		entries[entry.Name] = removeEntry(entries[entry.Name], deleted)
+		return nil
+	})
+}
+
+func removeEntry(records []entryJSON, removed entryJSON) []entryJSON {
+	var ret []entryJSON
+	for _, v := range records {
+		if !v.same(removed) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// UpdateEntry replaces the entries with the name by the updated entry, see cron.Store
+func (s *Store) UpdateEntry(ctx context.Context, name string, updated cron.Entry) error {
+	if err := validateEntry(updated); err != nil {
+		return err
+	}
+
+	record := newEntryJSON(updated)
+	return s.updateEntries(ctx, []string{name, updated.Name}, func(entries map[string][]entryJSON) error {
+		if len(entries[name]) == 0 {
+			return cron.ErrEntryNotFound
+		}
+		entries[name] = nil
+		entries[updated.Name] = append(removeEntry(entries[updated.Name], record), record)
+		return nil
+	})
+}
+
+// SetEntryActive activates or pauses the entries with the name
+func (s *Store) SetEntryActive(ctx context.Context, name string, active bool) error {
+	return s.updateEntries(ctx, []string{name}, func(entries map[string][]entryJSON) error {
+		if len(entries[name]) == 0 {
+			return cron.ErrEntryNotFound
+		}
+		for i := range entries[name] {
+			entries[name][i].Paused = !active
+		}
+		return nil
+	})
+}
+
+// updateAttempts is the number of attempts of updateEntries when the entries are modified concurrently
+const updateAttempts = 10
+
+// updateEntries calls fn with the entries of the names and writes them back in a transaction that succeeds only if
+// the keys are not modified in between, it is retried otherwise
+func (s *Store) updateEntries(ctx context.Context, names []string, fn func(entries map[string][]entryJSON) error) error {
+	if len(names) > 1 && names[0] == names[1] {
+		names = names[:1]
+	}
+	for attempt := 1; ; attempt++ {
+		entries := make(map[string][]entryJSON, len(names))
+		var cmps []clientv3.Cmp
+		for _, name := range names {
+			key := s.key("entries/" + name)
+			resp, err := s.client.Get(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed reading entry %q: %v", name, err)
+			}
+			var revision int64
+			if len(resp.Kvs) > 0 {
+				revision = resp.Kvs[0].ModRevision
+				if entries[name], err = decodeEntries(name, resp.Kvs[0].Value); err != nil {
+					return err
+				}
+			}
+			cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(key), "=", revision))
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+
+		var ops []clientv3.Op
+		for _, name := range names {
+			key := s.key("entries/" + name)
+			if len(entries[name]) == 0 {
+				ops = append(ops, clientv3.OpDelete(key))
+				continue
+			}
+			value, err := json.Marshal(entries[name])
+			if err != nil {
+				return err
+			}
+			ops = append(ops, clientv3.OpPut(key, string(value)))
+		}
+		resp, err := s.client.Txn(ctx).If(cmps...).Then(ops...).Commit()
+		if err != nil {
+			return fmt.Errorf("failed writing entries: %v", err)
+		}
+		if resp.Succeeded {
+			return nil
+		}
+		if attempt >= updateAttempts {
+			return fmt.Errorf("entries %q are modified concurrently", names)
+		}
+	}
+}
+
+// EntryChange is sent by Watch when the entries with the name are changed
+type EntryChange struct {
+	Name string
+	// Deleted is set when there is no entry with the name anymore
+	Deleted bool
+}
+
+// Watch sends the changes of the entries from now on until ctx is done. The channel is closed when ctx is done or
+// the watch fails (ex: the revision is compacted), the changes after that are not sent.
+func (s *Store) Watch(ctx context.Context) (<-chan EntryChange, error) {
+	prefix := s.key("entries/")
+	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return nil, fmt.Errorf("failed reading entries: %v", err)
+	}
+
+	watch := s.client.Watch(ctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(resp.Header.Revision+1))
+	changes := make(chan EntryChange)
+	go func() {
+		defer close(changes)
+		for resp := range watch {
+			if resp.Err() != nil {
+				return
+			}
+			for _, ev := range resp.Events {
+				change := EntryChange{
+					Name:    strings.TrimPrefix(string(ev.Kv.Key), prefix),
+					Deleted: ev.Type == clientv3.EventTypeDelete,
+				}
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// watchRetry is the wait before watching again after the watch of NotifyEntriesChanged failed
+const watchRetry = time.Second
+
+// NotifyEntriesChanged implements cron.EntriesNotifier, fn is called on every change of Watch until the client is
+// closed. fn is also called when the watch fails since changes may be missed until it is watched again.
+func (s *Store) NotifyEntriesChanged(fn func()) {
+	go func() {
+		ctx := s.client.Ctx()
+		for ctx.Err() == nil {
+			if changes, err := s.Watch(ctx); err == nil {
+				for range changes {
+					fn()
+				}
+				fn()
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(watchRetry):
+			}
+		}
+	}()
+}
+
+// eventJSON is the value of the event key
+type eventJSON struct {
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Location   string    `json:"location"`
+	Meta       string    `json:"meta,omitempty"`
+	Time       time.Time `json:"time"`
+	Manual     bool      `json:"manual,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	FiredBy    string    `json:"fired_by,omitempty"`
+}
+
+func (v eventJSON) event() (cron.Event, error) {
+	e, err := entryJSON{Name: v.Name, Expression: v.Expression, Location: v.Location, Meta: v.Meta}.entry()
+	if err != nil {
+		return cron.Event{}, err
+	}
+
+	return cron.Event{Entry: e, Time: v.Time, Manual: v.Manual, Status: v.Status, FiredBy: v.FiredBy}, nil
+}
+
+// eventsKey is the prefix of the keys of the events on the second of t
+func (s *Store) eventsKey(t time.Time) string {
+	return s.key(fmt.Sprintf("events/%012d/", t.Unix()))
+}
+
+func (s *Store) eventKey(v eventJSON) string {
+	return s.eventsKey(v.Time) + url.PathEscape(v.Name) + "/" + url.PathEscape(v.Expression) + "/" +
+		url.PathEscape(v.Location)
+}
+
+// AddEvent records the event, it returns cron.ErrAlreadyTriggered if the entry already has an event on the same time
+func (s *Store) AddEvent(ctx context.Context, e cron.Event) error {
+	if err := validateEntry(e.Entry); err != nil {
+		return err
+	}
+	v := eventJSON{
+		Name:       e.Entry.Name,
+		Expression: e.Entry.Expression(),
+		Location:   newEntryJSON(e.Entry).Location,
+		Meta:       e.Entry.Meta,
+		Time:       e.Time.UTC(),
+		Manual:     e.Manual,
+		Status:     e.Status,
+		FiredBy:    e.FiredBy,
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	key := s.eventKey(v)
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, string(value))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed adding event: %v", err)
+	}
+	if !resp.Succeeded {
+		return cron.ErrAlreadyTriggered
+	}
+
+	return nil
+}
+
+// GetEvents on [from, to) ordered by time, then name
+func (s *Store) GetEvents(ctx context.Context, from, to time.Time) ([]cron.Event, error) {
+	// the keys are by second, the events of the second of to are filtered by time
+	resp, err := s.client.Get(ctx, s.eventsKey(from), clientv3.WithRange(s.eventsKey(to.Add(time.Second))))
+	if err != nil {
+		return nil, fmt.Errorf("failed reading events: %v", err)
+	}
+
+	var events []cron.Event
+	for _, kv := range resp.Kvs {
+		e, err := decodeEvent(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		if e.Time.Before(from) || !e.Time.Before(to) {
+			continue
+		}
+		events = append(events, e)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Entry.Name != b.Entry.Name {
+			return a.Entry.Name < b.Entry.Name
+		}
+		if a.Entry.Expression() != b.Entry.Expression() {
+			return a.Entry.Expression() < b.Entry.Expression()
+		}
+		return a.Entry.Location.String() < b.Entry.Location.String()
+	})
+
+	return events, nil
+}
+
+func decodeEvent(value []byte) (cron.Event, error) {
+	var v eventJSON
+	if err := json.Unmarshal(value, &v); err != nil {
+		return cron.Event{}, fmt.Errorf("failed decoding event %q: %v", value, err)
+	}
+
+	return v.event()
+}
+
+// QueryEvents on [from, to) ordered like GetEvents, see cron.EventsQuery for the options
+func (s *Store) QueryEvents(ctx context.Context, from, to time.Time, opts ...cron.EventsOption) ([]cron.Event, error) {
+	events, err := s.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	q := cron.NewEventsQuery(opts...)
+	names := make(map[string]bool, len(q.Names))
+	for _, name := range q.Names {
+		names[name] = true
+	}
+	var ret []cron.Event
+	for _, e := range events {
+		if (len(names) == 0 || names[e.Entry.Name]) && (q.Status == "" || e.Status == q.Status) {
+			ret = append(ret, e)
+		}
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(ret) {
+			return nil, nil
+		}
+		ret = ret[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(ret) {
+		ret = ret[:q.Limit]
+	}
+
+	return ret, nil
+}
+
+// GetEventsByName on [from, to) of the entries with the name, an empty slice if there is none
+func (s *Store) GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]cron.Event, error) {
+	events, err := s.QueryEvents(ctx, from, to, cron.ForEntries(name))
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = make([]cron.Event, 0)
+	}
+
+	return events, nil
+}
+
+// GetEventsForEntries implements cron.EntryEventsGetter
+func (s *Store) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]cron.Event, error) {
+	return s.QueryEvents(ctx, from, to, cron.ForEntries(names...))
+}
+
+// CountEvents on [from, to)
+func (s *Store) CountEvents(ctx context.Context, from, to time.Time) (int64, error) {
+	events, err := s.GetEvents(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(events)), nil
+}
+
+// lastEventBatch is the number of events read at once by LastEvent from the latest
+const lastEventBatch = 100
+
+// LastEvent returns the latest event of the entry with the name, see cron.Store
+func (s *Store) LastEvent(ctx context.Context, name string, opts ...cron.EventsOption) (cron.Event, bool, error) {
+	status := cron.NewEventsQuery(opts...).Status
+	prefix := s.key("events/")
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	for {
+		resp, err := s.client.Get(ctx, prefix, clientv3.WithRange(end),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend), clientv3.WithLimit(lastEventBatch))
+		if err != nil {
+			return cron.Event{}, false, fmt.Errorf("failed reading events: %v", err)
+		}
+		// the keys are ordered by time, the first event of the entry is the latest
+		for _, kv := range resp.Kvs {
+			e, err := decodeEvent(kv.Value)
+			if err != nil {
+				return cron.Event{}, false, err
+			}
+			if e.Entry.Name == name && (status == "" || e.Status == status) {
+				return e, true, nil
+			}
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return cron.Event{}, false, nil
+		}
+		end = string(resp.Kvs[len(resp.Kvs)-1].Key)
+	}
+}
+
+// DeleteEvents deletes the events of the seconds before until
+func (s *Store) DeleteEvents(ctx context.Context, until time.Time) error {
+	if _, err := s.client.Delete(ctx, s.key("events/"), clientv3.WithRange(s.eventsKey(until))); err != nil {
+		return fmt.Errorf("failed deleting events: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,331 @@
+package cronetcd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/yulrizka/cron"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
+)
+
+var (
+	_ cron.Store             = (*Store)(nil)
+	_ cron.EntryEventsGetter = (*Store)(nil)
+	_ cron.EntriesNotifier   = (*Store)(nil)
+)
+
+// newTestClient starts an embedded etcd server for the test and returns a client connected to it in process
+func newTestClient(t *testing.T) *clientv3.Client {
+	t.Helper()
+	freeURL := func() url.URL {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		return url.URL{Scheme: "http", Host: l.Addr().String()}
+	}
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientURL, peerURL := freeURL(), freeURL()
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd server is not ready")
+	}
+
+	client := v3client.New(e.Server)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func parse(t *testing.T, expression, name string) cron.Entry {
+	t.Helper()
+	e, err := cron.Parse(expression, time.UTC, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestStore_Entries(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(newTestClient(t))
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	entry1 := parse(t, "* * * * *", "ENTRY_1")
+	entry1.Meta = "meta"
+	entry1.Timeout = time.Minute
+	entry2 := parse(t, "0 * * * *", "ENTRY_2")
+	entry2.DependsOn = []string{"ENTRY_1"}
+	for _, e := range []cron.Entry{entry1, entry2} {
+		if err := store.AddEntry(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddEntry(ctx, parse(t, "* * * * *", "")); err == nil {
+		t.Error("expected error on empty name")
+	}
+
+	got, err := store.GetEntry(ctx, entry1.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Expression() != entry1.Expression() || got.Meta != entry1.Meta || got.Timeout != entry1.Timeout ||
+		got.Location.String() != "UTC" {
+		t.Errorf("got entry %+v want %+v", got, entry1)
+	}
+	if _, err := store.GetEntry(ctx, "UNKNOWN"); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+
+	// paused entry is only returned with IncludeInactive and stays paused when it is added again
+	if err := store.SetEntryActive(ctx, entry2.Name, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, entry2); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := store.GetEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 1; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.OrderByName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	if got := entries[1]; got.Name != entry2.Name || !got.Paused || len(got.DependsOn) != 1 {
+		t.Errorf("got entry %+v want paused %+v", got, entry2)
+	}
+
+	// the same name with another expression
+	hourly := parse(t, "0 * * * *", entry1.Name)
+	if err := store.AddEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+	var ambiguous cron.AmbiguousEntryError
+	if _, err := store.GetEntry(ctx, entry1.Name); !errors.As(err, &ambiguous) || len(ambiguous.Entries) != 2 {
+		t.Errorf("got error %v want AmbiguousEntryError of 2 entries", err)
+	}
+	if err := store.DeleteEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+
+	// update renames the entry
+	renamed := parse(t, "*/5 * * * *", "ENTRY_4")
+	if err := store.UpdateEntry(ctx, entry1.Name, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateEntry(ctx, entry1.Name, renamed); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.NamePrefix("ENTRY_4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Expression() != renamed.Expression() {
+		t.Errorf("got entries %v want %v", entries, renamed)
+	}
+}
+
+func TestStore_Events(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(newTestClient(t))
+
+	entry1 := parse(t, "* * * * *", "ENTRY_1")
+	entry2 := parse(t, "* * * * *", "ENTRY_2")
+	on := time.Date(2018, 12, 15, 10, 0, 0, 0, time.UTC)
+	events := []cron.Event{
+		{Entry: entry2, Time: on},
+		{Entry: entry1, Time: on, Status: cron.EventStatusSucceeded},
+		{Entry: entry1, Time: on.Add(time.Minute)},
+		{Entry: entry1, Time: on.Add(90*time.Second + time.Millisecond), Manual: true},
+	}
+	for _, e := range events {
+		if err := store.AddEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddEvent(ctx, events[0]); err != cron.ErrAlreadyTriggered {
+		t.Errorf("got error %v want %v", err, cron.ErrAlreadyTriggered)
+	}
+
+	got, err := store.GetEvents(ctx, on, on.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Entry.Name != entry1.Name || got[1].Entry.Name != entry2.Name || !got[0].Time.Equal(on) {
+		t.Fatalf("got events %v want the events of ENTRY_1 and ENTRY_2 on %s", got, on)
+	}
+	// the manual event is after to on the same second
+	if n, err := store.CountEvents(ctx, on, on.Add(90*time.Second)); err != nil || n != 3 {
+		t.Errorf("got count %d, %v want 3", n, err)
+	}
+	got, err = store.QueryEvents(ctx, on, on.Add(time.Hour), cron.ForEntries(entry1.Name), cron.EventsPage(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].Time.Equal(on.Add(time.Minute)) {
+		t.Errorf("got events %v want the second event of ENTRY_1", got)
+	}
+	got, err = store.GetEventsByName(ctx, "UNKNOWN", on, on.Add(time.Hour))
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("got events %v, %v want empty slice", got, err)
+	}
+
+	last, ok, err := store.LastEvent(ctx, entry1.Name)
+	if err != nil || !ok || !last.Manual {
+		t.Errorf("got last event %v, %t, %v want the manual event", last, ok, err)
+	}
+	last, ok, err = store.LastEvent(ctx, entry1.Name, cron.EventsWithStatus(cron.EventStatusSucceeded))
+	if err != nil || !ok || !last.Time.Equal(on) {
+		t.Errorf("got last succeeded event %v, %t, %v want the event on %s", last, ok, err, on)
+	}
+	if _, ok, err := store.LastEvent(ctx, "UNKNOWN"); err != nil || ok {
+		t.Errorf("got last event %t, %v want none", ok, err)
+	}
+
+	// deleted event can be recorded again
+	if err := store.DeleteEvents(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.CountEvents(ctx, on, on.Add(2*time.Minute)); err != nil || n != 2 {
+		t.Errorf("got count %d, %v after delete want 2", n, err)
+	}
+	if err := store.AddEvent(ctx, events[0]); err != nil {
+		t.Errorf("failed adding deleted event again: %v", err)
+	}
+}
+
+func TestStore_Lock(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	store1 := NewStore(client, WithLock(time.Second, 100*time.Millisecond))
+	store2 := NewStore(client, WithLock(time.Second, 100*time.Millisecond))
+	defer store1.Close()
+	defer store2.Close()
+
+	if err := store1.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store1.Lock(ctx); err == nil {
+		t.Error("expected error locking twice")
+	}
+	if got, want := store2.Lock(ctx), cron.ErrLockTimeout; got != want {
+		t.Fatalf("got error %v want %v", got, want)
+	}
+	if err := store1.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Unlock(ctx); err == nil {
+		t.Error("expected error unlocking twice")
+	}
+
+	// the lock of a crashed instance is released with its lease
+	if err := store1.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	store1.session.Orphan()
+	if _, err := client.Revoke(ctx, store1.session.Lease()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Lock(ctx); err != nil {
+		t.Fatalf("lock is not released after the lease expired: %v", err)
+	}
+	if err := store1.Unlock(ctx); !errors.Is(err, cron.ErrLockLost) {
+		t.Errorf("got error %v want %v", err, cron.ErrLockLost)
+	}
+	if err := store2.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewStore(newTestClient(t))
+
+	changes, err := store.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := parse(t, "* * * * *", "ENTRY_1")
+	if err := store.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []EntryChange{{Name: "ENTRY_1"}, {Name: "ENTRY_1", Deleted: true}} {
+		select {
+		case got := <-changes:
+			if got != want {
+				t.Errorf("got change %+v want %+v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change, want %+v", want)
+		}
+	}
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestStore_NotifyEntriesChanged(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(newTestClient(t))
+	changed := make(chan struct{}, 1)
+	store.NotifyEntriesChanged(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	// the watch is started in background, the entry is added until the change is notified
+	entry := parse(t, "* * * * *", "ENTRY_1")
+	timeout := time.After(5 * time.Second)
+	for {
+		if err := store.AddEntry(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-changed:
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("change is not notified")
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package cronkafka provides Kafka implementation of cron.Publisher with franz-go
+//
+//	client, err := kgo.NewClient(kgo.SeedBrokers("localhost:9092"))
+//	...
+//	handler := cron.NewPublisherHandler(cronkafka.NewPublisher(client), nil)
+//	scheduler := cron.NewScheduler(handler, store)
+package cronkafka
+
+import (
+	"context"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/yulrizka/cron"
+)
+
+// producer is the part of kgo.Client used by Publisher
+type producer interface {
+	ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults
+}
+
+// Publisher implements cron.Publisher with a Kafka client
+type Publisher struct {
+	client producer
+}
+
+// NewPublisher creates publisher on the client
+func NewPublisher(client *kgo.Client) *Publisher {
+	return &Publisher{client: client}
+}
+
+// Publish produces the payload to the topic and waits until it is acknowledged according to the acks of the client.
+// The key of the record is the entry name of the event in ctx (see cron.EventFromContext) so that the events of an
+// entry go to the same partition in order, the record has no key without an event.
+func (p *Publisher) Publish(ctx context.Context, topic string, payload []byte) error {
+	record := &kgo.Record{Topic: topic, Value: payload}
+	if ev, ok := cron.EventFromContext(ctx); ok {
+		record.Key = []byte(ev.Entry.Name)
+	}
+
+	return p.client.ProduceSync(ctx, record).FirstErr()
+}
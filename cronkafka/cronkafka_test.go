@@ -0,0 +1,81 @@
+package cronkafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/yulrizka/cron"
+)
+
+var _ cron.Publisher = (*Publisher)(nil)
+
+// memProducer keeps the produced records
+type memProducer struct {
+	records []*kgo.Record
+	err     error
+}
+
+func (m *memProducer) ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	var results kgo.ProduceResults
+	for _, r := range rs {
+		if m.err == nil {
+			m.records = append(m.records, r)
+		}
+		results = append(results, kgo.ProduceResult{Record: r, Err: m.err})
+	}
+	return results
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	entry, err := cron.Parse("*/5 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &memProducer{}
+	handler := cron.NewPublisherHandler(&Publisher{client: client}, func(e cron.Entry) string { return "jobs." + e.Name })
+	if err := handler(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(client.records), 1; got != want {
+		t.Fatalf("got records %d want %d", got, want)
+	}
+	record := client.records[0]
+	if got, want := record.Topic, "jobs.ENTRY_1"; got != want {
+		t.Errorf("got topic %q want %q", got, want)
+	}
+	if got, want := string(record.Key), "ENTRY_1"; got != want {
+		t.Errorf("got key %q want %q", got, want)
+	}
+	var ev struct {
+		Entry struct {
+			Name string `json:"name"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(record.Value, &ev); err != nil {
+		t.Fatalf("got value %s: %v", record.Value, err)
+	}
+	if got, want := ev.Entry.Name, "ENTRY_1"; got != want {
+		t.Errorf("got entry %q want %q", got, want)
+	}
+
+	// the record has no key without an event
+	p := &Publisher{client: client}
+	if err := p.Publish(context.Background(), "jobs", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.records[1]; got.Key != nil || string(got.Value) != "{}" {
+		t.Errorf("got key %q value %q want no key and value %q", got.Key, got.Value, "{}")
+	}
+
+	client.err = errors.New("broker is down")
+	if err := p.Publish(context.Background(), "jobs", []byte("{}")); !errors.Is(err, client.err) {
+		t.Errorf("got error %v want %v", err, client.err)
+	}
+	if err := handler(context.Background(), entry); err == nil {
+		t.Error("expected publish error")
+	}
+}
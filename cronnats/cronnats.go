@@ -0,0 +1,43 @@
+// Package cronnats provides NATS implementation of cron.Publisher
+//
+//	nc, err := nats.Connect(nats.DefaultURL)
+//	...
+//	handler := cron.NewPublisherHandler(cronnats.NewPublisher(nc), nil)
+//	scheduler := cron.NewScheduler(handler, store)
+package cronnats
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// conn is the part of nats.Conn used by Publisher
+type conn interface {
+	Publish(subj string, data []byte) error
+	Flush() error
+	FlushWithContext(ctx context.Context) error
+}
+
+// Publisher implements cron.Publisher with a NATS connection, the topic is the subject
+type Publisher struct {
+	conn conn
+}
+
+// NewPublisher creates publisher on the connection
+func NewPublisher(conn *nats.Conn) *Publisher {
+	return &Publisher{conn: conn}
+}
+
+// Publish publishes the payload and flushes the connection so that an error is returned when the server did not
+// receive it. The flush waits until ctx is done if it has a deadline, else the default timeout of nats.Conn.Flush.
+func (p *Publisher) Publish(ctx context.Context, topic string, payload []byte) error {
+	if err := p.conn.Publish(topic, payload); err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return p.conn.Flush()
+	}
+
+	return p.conn.FlushWithContext(ctx)
+}
@@ -0,0 +1,109 @@
+package cronnats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yulrizka/cron"
+)
+
+var _ cron.Publisher = (*Publisher)(nil)
+
+// memConn keeps the published messages by subject
+type memConn struct {
+	subjects []string
+	data     [][]byte
+	pending  int // published and not flushed
+	flushed  int
+	flushCtx int // flushes with a context
+	err      error
+	flushErr error
+}
+
+func (m *memConn) Publish(subj string, data []byte) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.subjects = append(m.subjects, subj)
+	m.data = append(m.data, data)
+	m.pending++
+	return nil
+}
+
+func (m *memConn) Flush() error {
+	if m.flushErr != nil {
+		return m.flushErr
+	}
+	m.flushed += m.pending
+	m.pending = 0
+	return nil
+}
+
+func (m *memConn) FlushWithContext(ctx context.Context) error {
+	m.flushCtx++
+	return m.Flush()
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	entry, err := cron.Parse("*/5 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &memConn{}
+	handler := cron.NewPublisherHandler(&Publisher{conn: conn}, func(e cron.Entry) string { return "jobs." + e.Name })
+	if err := handler(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(conn.subjects), 1; got != want {
+		t.Fatalf("got messages %d want %d", got, want)
+	}
+	if got, want := conn.subjects[0], "jobs.ENTRY_1"; got != want {
+		t.Errorf("got subject %q want %q", got, want)
+	}
+	var ev struct {
+		Entry struct {
+			Name string `json:"name"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(conn.data[0], &ev); err != nil {
+		t.Fatalf("got data %s: %v", conn.data[0], err)
+	}
+	if got, want := ev.Entry.Name, "ENTRY_1"; got != want {
+		t.Errorf("got entry %q want %q", got, want)
+	}
+	if conn.flushed != 1 || conn.flushCtx != 0 {
+		t.Errorf("got flushed %d with context %d want 1 without context", conn.flushed, conn.flushCtx)
+	}
+
+	// the flush waits until the deadline of ctx
+	p := &Publisher{conn: conn}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Publish(ctx, "jobs", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(conn.data[1]), "{}"; got != want {
+		t.Errorf("got data %q want %q", got, want)
+	}
+	if conn.flushed != 2 || conn.flushCtx != 1 {
+		t.Errorf("got flushed %d with context %d want 2 with context 1", conn.flushed, conn.flushCtx)
+	}
+
+	// the message that is not flushed is an error
+	conn.flushErr = errors.New("connection closed")
+	if err := p.Publish(context.Background(), "jobs", []byte("{}")); !errors.Is(err, conn.flushErr) {
+		t.Errorf("got error %v want %v", err, conn.flushErr)
+	}
+	conn.flushErr = nil
+
+	conn.err = errors.New("connection closed")
+	if err := p.Publish(context.Background(), "jobs", []byte("{}")); !errors.Is(err, conn.err) {
+		t.Errorf("got error %v want %v", err, conn.err)
+	}
+	if err := handler(context.Background(), entry); err == nil {
+		t.Error("expected publish error")
+	}
+}
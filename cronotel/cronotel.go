@@ -0,0 +1,82 @@
+// Package cronotel provides OpenTelemetry tracing of the scheduler. It creates a span for every check cycle and a
+// child span for every triggered handler. The context given to the handler carries the handler span so that traces
+// continue to downstream services.
+//
+//	scheduler := cron.NewScheduler(handler, store, cronotel.WithTracerProvider(otel.GetTracerProvider()))
+package cronotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/yulrizka/cron"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/yulrizka/cron"
+
+// Span attributes
+const (
+	AttrEntry       = attribute.Key("cron.entry")
+	AttrExpression  = attribute.Key("cron.expression")
+	AttrScheduledAt = attribute.Key("cron.scheduled_at")
+	AttrOutcome     = attribute.Key("cron.outcome")
+	AttrDuration    = attribute.Key("cron.duration_ms")
+)
+
+// Outcome of the handler
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// WithTracerProvider traces the scheduler with spans created from tp
+func WithTracerProvider(tp trace.TracerProvider) cron.Option {
+	return cron.WithTracer(Tracer{tracer: tp.Tracer(tracerName)})
+}
+
+// Tracer implements cron.Tracer with OpenTelemetry
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// StartCheck implements cron.Tracer
+func (t Tracer) StartCheck(ctx context.Context, on time.Time) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, "cron.check", trace.WithAttributes(
+		AttrScheduledAt.String(on.Format(time.RFC3339)),
+	))
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// StartHandler implements cron.Tracer
+func (t Tracer) StartHandler(ctx context.Context, ev cron.Event) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := t.tracer.Start(ctx, "cron.handler "+ev.Entry.Name, trace.WithAttributes(
+		AttrEntry.String(ev.Entry.Name),
+		AttrExpression.String(ev.Entry.Expression()),
+		AttrScheduledAt.String(ev.Time.Format(time.RFC3339)),
+	))
+
+	return ctx, func(err error) {
+		outcome := OutcomeSuccess
+		if err != nil {
+			outcome = OutcomeError
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(
+			AttrOutcome.String(outcome),
+			AttrDuration.Int64(time.Since(start).Milliseconds()),
+		)
+		span.End()
+	}
+}
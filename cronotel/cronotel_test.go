@@ -0,0 +1,77 @@
+package cronotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yulrizka/cron"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := Tracer{tracer: tp.Tracer(tracerName)}
+
+	entry, err := cron.Parse("*/5 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	// simulate what the scheduler does: a check span and a handler span with context derived from it
+	ctx, endCheck := tracer.StartCheck(context.Background(), on)
+	handlerCtx, endHandler := tracer.StartHandler(ctx, cron.Event{Entry: entry, Time: on})
+	handlerSpan := trace.SpanContextFromContext(handlerCtx)
+	endCheck(nil)
+	endHandler(errors.New("failed"))
+
+	spans := exporter.GetSpans()
+	if got, want := len(spans), 2; got != want {
+		t.Fatalf("got spans %d want %d", got, want)
+	}
+	check, h := spans[0], spans[1]
+	if got, want := check.Name, "cron.check"; got != want {
+		t.Errorf("got check span name %q want %q", got, want)
+	}
+	if got, want := h.Name, "cron.handler ENTRY_1"; got != want {
+		t.Errorf("got handler span name %q want %q", got, want)
+	}
+
+	if got, want := h.Parent.SpanID(), check.SpanContext.SpanID(); got != want {
+		t.Errorf("got handler parent span %s want %s", got, want)
+	}
+	if got, want := handlerSpan.SpanID(), h.SpanContext.SpanID(); got != want {
+		t.Errorf("got handler context span %s want %s", got, want)
+	}
+	if got, want := h.Status.Code, codes.Error; got != want {
+		t.Errorf("got handler status %v want %v", got, want)
+	}
+	if got, want := check.Status.Code, codes.Unset; got != want {
+		t.Errorf("got check status %v want %v", got, want)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range h.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	for key, want := range map[attribute.Key]string{
+		AttrEntry:       "ENTRY_1",
+		AttrExpression:  "*/5 * * * *",
+		AttrScheduledAt: "2018-12-15T00:00:00Z",
+		AttrOutcome:     OutcomeError,
+	} {
+		if got := attrs[key].AsString(); got != want {
+			t.Errorf("got attribute %s %q want %q", key, got, want)
+		}
+	}
+	if _, ok := attrs[AttrDuration]; !ok {
+		t.Errorf("missing attribute %s", AttrDuration)
+	}
+}
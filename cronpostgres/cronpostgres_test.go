@@ -0,0 +1,286 @@
+package cronpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/yulrizka/cron"
+)
+
+var (
+	_ cron.Store             = (*Store)(nil)
+	_ cron.EntryEventsGetter = (*Store)(nil)
+	_ cron.EventStatusStore  = (*Store)(nil)
+	_ cron.ClaimStore        = (*Store)(nil)
+)
+
+// newTestStore creates a store on the PostgreSQL test database configured by the PG_TEST_* environment variables,
+// the tables are dropped first so that the test starts with an empty store
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	if testing.Short() {
+		t.Skip()
+	}
+	env := func(key, defaultValue string) string {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+		return defaultValue
+	}
+	user := env("PG_TEST_USER", "gotest")
+	pass := env("PG_TEST_PASS", "secret")
+	addr := env("PG_TEST_ADDR", "localhost:5432")
+	dbname := env("PG_TEST_DBNAME", "gotest")
+	dsn := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable&connect_timeout=30", user, pass, addr, dbname)
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, table := range []string{cron.EntriesTable, cron.EventsTable} {
+		if _, err := db.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewStore(db)
+}
+
+func parse(t *testing.T, expression, name string) cron.Entry {
+	t.Helper()
+	e, err := cron.Parse(expression, time.UTC, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestStore_Entries(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	entry1 := parse(t, "* * * * *", "ENTRY_1")
+	entry1.Meta = "meta"
+	entry1.Timeout = time.Minute
+	entry2 := parse(t, "0 * * * *", "ENTRY_2")
+	entry2.DependsOn = []string{"ENTRY_1"}
+	for _, e := range []cron.Entry{entry1, entry2} {
+		if err := store.AddEntry(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddEntry(ctx, parse(t, "* * * * *", "")); err == nil {
+		t.Error("expected error on empty name")
+	}
+	custom, err := cron.Parse("* * * * *", time.FixedZone("custom", 3600), "ENTRY_3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, custom); err == nil {
+		t.Error("expected error on location not in the tz database")
+	}
+
+	got, err := store.GetEntry(ctx, entry1.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Expression() != entry1.Expression() || got.Meta != entry1.Meta || got.Timeout != entry1.Timeout ||
+		got.Location.String() != "UTC" {
+		t.Errorf("got entry %+v want %+v", got, entry1)
+	}
+	if _, err := store.GetEntry(ctx, "UNKNOWN"); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+
+	// paused entry is only returned with IncludeInactive and stays paused when it is added again
+	if err := store.SetEntryActive(ctx, entry2.Name, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, entry2); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := store.GetEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 1; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.OrderByName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	if got := entries[1]; got.Name != entry2.Name || !got.Paused || len(got.DependsOn) != 1 {
+		t.Errorf("got entry %+v want paused %+v", got, entry2)
+	}
+	if err := store.SetEntryActive(ctx, "UNKNOWN", false); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+
+	// the same name with another expression
+	hourly := parse(t, "0 * * * *", entry1.Name)
+	if err := store.AddEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+	var ambiguous cron.AmbiguousEntryError
+	if _, err := store.GetEntry(ctx, entry1.Name); !errors.As(err, &ambiguous) || len(ambiguous.Entries) != 2 {
+		t.Errorf("got error %v want AmbiguousEntryError of 2 entries", err)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.OrderByName(), cron.Page(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != entry1.Name || entries[0].Expression() != "0 * * * *" {
+		t.Errorf("got page %v want the hourly %s", entries, entry1.Name)
+	}
+	if err := store.DeleteEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+
+	// update renames the entry
+	renamed := parse(t, "*/5 * * * *", "ENTRY_4")
+	if err := store.UpdateEntry(ctx, entry1.Name, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateEntry(ctx, entry1.Name, renamed); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.NamePrefix("ENTRY_4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Expression() != renamed.Expression() {
+		t.Errorf("got entries %v want %v", entries, renamed)
+	}
+}
+
+func TestStore_Events(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	entry1 := parse(t, "* * * * *", "ENTRY_1")
+	entry2 := parse(t, "* * * * *", "ENTRY_2")
+	on := time.Date(2018, 12, 15, 10, 0, 0, 0, time.UTC)
+	events := []cron.Event{
+		{Entry: entry2, Time: on},
+		{Entry: entry1, Time: on, Status: cron.EventStatusSucceeded},
+		{Entry: entry1, Time: on.Add(time.Minute)},
+		{Entry: entry1, Time: on.Add(90 * time.Second), Manual: true},
+	}
+	for _, e := range events {
+		if err := store.AddEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddEvent(ctx, events[0]); err != cron.ErrAlreadyTriggered {
+		t.Errorf("got error %v want %v", err, cron.ErrAlreadyTriggered)
+	}
+
+	got, err := store.GetEvents(ctx, on, on.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Entry.Name != entry1.Name || got[1].Entry.Name != entry2.Name || !got[0].Time.Equal(on) {
+		t.Fatalf("got events %v want the events of ENTRY_1 and ENTRY_2 on %s", got, on)
+	}
+	if n, err := store.CountEvents(ctx, on, on.Add(2*time.Minute)); err != nil || n != 4 {
+		t.Errorf("got count %d, %v want 4", n, err)
+	}
+	got, err = store.QueryEvents(ctx, on, on.Add(time.Hour), cron.ForEntries(entry1.Name), cron.EventsPage(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].Time.Equal(on.Add(time.Minute)) {
+		t.Errorf("got events %v want the second event of ENTRY_1", got)
+	}
+	got, err = store.GetEventsByName(ctx, "UNKNOWN", on, on.Add(time.Hour))
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("got events %v, %v want empty slice", got, err)
+	}
+
+	last, ok, err := store.LastEvent(ctx, entry1.Name)
+	if err != nil || !ok || !last.Manual {
+		t.Errorf("got last event %v, %t, %v want the manual event", last, ok, err)
+	}
+	last, ok, err = store.LastEvent(ctx, entry1.Name, cron.EventsWithStatus(cron.EventStatusSucceeded))
+	if err != nil || !ok || !last.Time.Equal(on) {
+		t.Errorf("got last succeeded event %v, %t, %v want the event on %s", last, ok, err, on)
+	}
+	if _, ok, err := store.LastEvent(ctx, "UNKNOWN"); err != nil || ok {
+		t.Errorf("got last event %t, %v want none", ok, err)
+	}
+
+	// deleted event can be recorded again
+	if err := store.DeleteEvents(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.CountEvents(ctx, on, on.Add(2*time.Minute)); err != nil || n != 2 {
+		t.Errorf("got count %d, %v after delete want 2", n, err)
+	}
+	if err := store.AddEvent(ctx, events[0]); err != nil {
+		t.Errorf("failed adding deleted event again: %v", err)
+	}
+}
+
+func TestStore_EventStatus(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := parse(t, "* * * * *", "ENTRY_1")
+	on := time.Date(2018, 12, 15, 10, 0, 0, 0, time.UTC)
+	ev := cron.Event{Entry: entry, Time: on, Status: cron.EventStatusClaimed, FiredBy: "instance-1"}
+	if err := store.AddEvent(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+
+	// the claim of a stopped instance is taken over by another instance
+	stale, err := store.StaleClaims(ctx, on.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || !stale[0].Time.Equal(on) {
+		t.Fatalf("got stale claims %v want the event on %s", stale, on)
+	}
+	reclaimed := stale[0]
+	reclaimed.FiredBy = "instance-2"
+	if ok, err := store.ReclaimEvent(ctx, reclaimed, on.Add(time.Minute), on.Add(2*time.Minute)); err != nil || !ok {
+		t.Fatalf("got reclaimed %t, %v want true", ok, err)
+	}
+	if ok, err := store.ReclaimEvent(ctx, reclaimed, on.Add(time.Minute), on.Add(2*time.Minute)); err != nil || ok {
+		t.Errorf("got reclaimed again %t, %v want false", ok, err)
+	}
+
+	if err := store.SetEventStatus(ctx, ev, cron.EventStatusSucceeded); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := store.GetEventStatuses(ctx, []string{entry.Name}, on, on.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := statuses[entry.Name], cron.EventStatusSucceeded; got != want {
+		t.Errorf("got status %q want %q", got, want)
+	}
+	last, ok, err := store.LastEvent(ctx, entry.Name)
+	if err != nil || !ok || last.FiredBy != "instance-2" {
+		t.Errorf("got last event %+v, %t, %v want fired by instance-2", last, ok, err)
+	}
+}
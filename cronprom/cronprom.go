@@ -0,0 +1,92 @@
+// Package cronprom provides Prometheus implementation of cron.Metrics
+//
+//	collector := cronprom.NewCollector()
+//	prometheus.MustRegister(collector)
+//	scheduler := cron.NewScheduler(handler, store, cron.WithMetrics(collector))
+package cronprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements cron.Metrics and prometheus.Collector
+type Collector struct {
+	triggered       *prometheus.CounterVec
+	handlerDuration *prometheus.HistogramVec
+	errors          *prometheus.CounterVec
+	checkDuration   prometheus.Histogram
+	skipped         *prometheus.CounterVec
+}
+
+// NewCollector creates metrics collector
+func NewCollector() *Collector {
+	return &Collector{
+		triggered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cron_triggered_total",
+			Help: "Number of triggered entries.",
+		}, []string{"entry"}),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cron_handler_duration_seconds",
+			Help:    "Duration of handler execution.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"entry"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cron_errors_total",
+			Help: "Number of errors by kind.",
+		}, []string{"kind"}),
+		checkDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cron_check_duration_seconds",
+			Help:    "Duration of a check tick.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		skipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cron_skipped_total",
+			Help: "Number of matched entries that are not triggered.",
+		}, []string{"entry", "reason"}),
+	}
+}
+
+// IncTriggered implements cron.Metrics
+func (c *Collector) IncTriggered(entry string) {
+	c.triggered.WithLabelValues(entry).Inc()
+}
+
+// ObserveHandlerDuration implements cron.Metrics
+func (c *Collector) ObserveHandlerDuration(entry string, d time.Duration) {
+	c.handlerDuration.WithLabelValues(entry).Observe(d.Seconds())
+}
+
+// IncError implements cron.Metrics
+func (c *Collector) IncError(kind string) {
+	c.errors.WithLabelValues(kind).Inc()
+}
+
+// ObserveCheckDuration implements cron.Metrics
+func (c *Collector) ObserveCheckDuration(d time.Duration) {
+	c.checkDuration.Observe(d.Seconds())
+}
+
+// IncSkipped implements cron.Metrics
+func (c *Collector) IncSkipped(entry, reason string) {
+	c.skipped.WithLabelValues(entry, reason).Inc()
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.triggered.Describe(ch)
+	c.handlerDuration.Describe(ch)
+	c.errors.Describe(ch)
+	c.checkDuration.Describe(ch)
+	c.skipped.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.triggered.Collect(ch)
+	c.handlerDuration.Collect(ch)
+	c.errors.Collect(ch)
+	c.checkDuration.Collect(ch)
+	c.skipped.Collect(ch)
+}
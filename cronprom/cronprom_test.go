@@ -0,0 +1,40 @@
+package cronprom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yulrizka/cron"
+)
+
+var _ cron.Metrics = (*Collector)(nil)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	c.IncTriggered("ENTRY_1")
+	c.IncTriggered("ENTRY_1")
+	c.ObserveHandlerDuration("ENTRY_1", time.Second)
+	c.IncError(cron.ErrorKindHandler)
+	c.ObserveCheckDuration(time.Millisecond)
+	c.IncSkipped("ENTRY_2", cron.SkipAlreadyTriggered)
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "triggered", got: testutil.ToFloat64(c.triggered.WithLabelValues("ENTRY_1")), want: 2},
+		{name: "errors", got: testutil.ToFloat64(c.errors.WithLabelValues(cron.ErrorKindHandler)), want: 1},
+		{name: "skipped", got: testutil.ToFloat64(c.skipped.WithLabelValues("ENTRY_2", cron.SkipAlreadyTriggered)), want: 1},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if got, want := testutil.CollectAndCount(c), 5; got != want {
+		t.Errorf("got metrics %d want %d", got, want)
+	}
+}
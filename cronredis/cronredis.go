@@ -0,0 +1,755 @@
+// Package cronredis provides Redis implementation of cron.Store with go-redis
+//
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	store := cronredis.NewStore(client, cronredis.WithPrefix("myapp"))
+//	scheduler := cron.NewScheduler(handler, store)
+//
+// The entries are stored in a hash keyed by name, the value is the JSON array of the entries with the name. The events
+// are stored in a sorted set scored by unix minute. An event is recorded at most once with a key per entry and time
+// created with SET NX, the key expires after the event TTL (see WithEventTTL).
+//
+// Lock sets a key with expiry so that the lock of a crashed instance is released after the lock TTL, see WithLock.
+// Every key of the store shares the hash tag of the prefix so that the store works on Redis Cluster.
+package cronredis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yulrizka/cron"
+)
+
+// Store implements cron.Store and cron.EntryEventsGetter on Redis
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+
+	lockTTL     time.Duration // expiry of the lock
+	lockTimeout time.Duration // wait for the lock
+	eventTTL    time.Duration // expiry of the keys that prevent an event from being recorded twice
+
+	mu        sync.Mutex
+	lockToken string // value of the lock key while the store is locked
+}
+
+// Option configures the Store
+type Option func(s *Store)
+
+// WithPrefix sets the prefix of the keys (default "cron"), stores with different prefixes do not see each other entries
+// and events.
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithLock sets the expiry of the lock (default 1 minute) and how long Lock waits for it (default the expiry). The
+// expiry must be longer than a check, otherwise another instance acquires the lock while the check is running and
+// Unlock returns cron.ErrLockLost. Lock returns cron.ErrLockTimeout when the lock is not acquired within timeout.
+func WithLock(ttl, timeout time.Duration) Option {
+	return func(s *Store) {
+		s.lockTTL = ttl
+		s.lockTimeout = timeout
+	}
+}
+
+// WithEventTTL sets the expiry of the keys that prevent an event from being recorded twice (default
+// cron.KeepEventDuration), zero or less keeps the default. The events themselves are deleted by DeleteEvents, see
+// cron.WithEventRetention.
+func WithEventTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		if ttl > 0 {
+			s.eventTTL = ttl
+		}
+	}
+}
+
+// NewStore creates store on the client
+func NewStore(client redis.UniversalClient, opts ...Option) *Store {
+	s := &Store{
+		client:   client,
+		prefix:   "cron",
+		lockTTL:  time.Minute,
+		eventTTL: cron.KeepEventDuration,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.lockTimeout <= 0 {
+		s.lockTimeout = s.lockTTL
+	}
+
+	return s
+}
+
+// key of the store, the prefix is the hash tag so that every key is on the same slot of a cluster
+func (s *Store) key(name string) string {
+	return "{" + s.prefix + "}:" + name
+}
+
+// Initialize checks that the server is reachable, Redis does not need to create anything
+func (s *Store) Initialize(ctx context.Context) error {
+	return s.Health(ctx)
+}
+
+// Health pings the server
+func (s *Store) Health(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// lockRetryInterval is the wait between the attempts of Lock while another instance holds the lock
+const lockRetryInterval = 50 * time.Millisecond
+
+// Lock sets the lock key if it does not exist, it waits until the lock is released by another instance or expires
+func (s *Store) Lock(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lockToken != "" {
+		return errors.New("store is already locked")
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Errorf("failed creating lock token: %v", err)
+	}
+	token := hex.EncodeToString(b[:])
+	deadline := time.Now().Add(s.lockTimeout)
+	for {
+		ok, err := s.client.SetNX(ctx, s.key("lock"), token, s.lockTTL).Result()
+		if err != nil {
+			return fmt.Errorf("failed acquiring lock: %v", err)
+		}
+		if ok {
+			s.lockToken = token
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return cron.ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// unlockScript deletes the lock only if it is still held with the token, it may have expired and been acquired by
+// another instance
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Unlock releases the lock. It returns (wrapped) cron.ErrLockLost when the lock expired before.
+func (s *Store) Unlock(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lockToken == "" {
+		return errors.New("store is not locked")
+	}
+	token := s.lockToken
+	s.lockToken = ""
+
+	released, err := unlockScript.Run(ctx, s.client, []string{s.key("lock")}, token).Int()
+	if err != nil {
+		return fmt.Errorf("failed releasing lock: %v", err)
+	}
+	if released == 0 {
+		return fmt.Errorf("%w: the lock expired after %s", cron.ErrLockLost, s.lockTTL)
+	}
+
+	return nil
+}
+
+// entryJSON is the stored entry, it has the same fields as the JSON encoding of cron.Entry
+type entryJSON struct {
+	Name           string   `json:"name"`
+	Expression     string   `json:"expression"`
+	Location       string   `json:"location"`
+	Meta           string   `json:"meta,omitempty"`
+	Paused         bool     `json:"paused,omitempty"`
+	IgnoreBlackout bool     `json:"ignore_blackout,omitempty"`
+	Timeout        string   `json:"timeout,omitempty"`
+	DependsOn      []string `json:"depends_on,omitempty"`
+}
+
+func newEntryJSON(e cron.Entry) entryJSON {
+	loc := time.UTC
+	if e.Location != nil {
+		loc = e.Location
+	}
+	var timeout string
+	if e.Timeout > 0 {
+		timeout = e.Timeout.String()
+	}
+
+	return entryJSON{
+		Name:           e.Name,
+		Expression:     e.Expression(),
+		Location:       loc.String(),
+		Meta:           e.Meta,
+		Paused:         e.Paused,
+		IgnoreBlackout: e.IgnoreBlackout,
+		Timeout:        timeout,
+		DependsOn:      e.DependsOn,
+	}
+}
+
+// same reports whether v is the same entry (name, expression and location)
+func (v entryJSON) same(o entryJSON) bool {
+	return v.Name == o.Name && v.Expression == o.Expression && v.Location == o.Location
+}
+
+func (v entryJSON) entry() (cron.Entry, error) {
+	loc, err := time.LoadLocation(v.Location)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed loading location of entry %q: %v", v.Name, err)
+	}
+	e, err := cron.ParseStored(v.Expression, loc, v.Name)
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed parsing entry %q: %v", v.Name, err)
+	}
+	if v.Timeout != "" {
+		if e.Timeout, err = time.ParseDuration(v.Timeout); err != nil {
+			return cron.Entry{}, fmt.Errorf("failed parsing timeout of entry %q: %v", v.Name, err)
+		}
+	}
+	e.Meta = v.Meta
+	e.Paused = v.Paused
+	e.IgnoreBlackout = v.IgnoreBlackout
+	e.DependsOn = v.DependsOn
+
+	return e, nil
+}
+
+// validateEntry returns an error if the entry can not be read back
+func validateEntry(e cron.Entry) error {
+	if e.Name == "" {
+		return errors.New("got empty name")
+	}
+	if e.Expression() == "" {
+		return errors.New("got empty expression")
+	}
+	if e.Location != nil {
+		if _, err := time.LoadLocation(e.Location.String()); err != nil {
+			return fmt.Errorf("location %q of entry %q is not in the tz database, use time.LoadLocation: %v", e.Location,
+				e.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetEntries reads every entry and applies the options, see cron.EntriesQuery
+func (s *Store) GetEntries(ctx context.Context, opts ...cron.EntriesOption) ([]cron.Entry, error) {
+	values, err := s.client.HGetAll(ctx, s.key("entries")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading entries: %v", err)
+	}
+
+	q := cron.NewEntriesQuery(opts...)
+	var entries []cron.Entry
+	for name, value := range values {
+		if !strings.HasPrefix(name, q.NamePrefix) {
+			continue
+		}
+		records, err := decodeEntries(name, value)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range records {
+			if v.Paused && !q.IncludeInactive {
+				continue
+			}
+			e, err := v.entry()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	}
+	if q.OrderByName {
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			if a.Expression() != b.Expression() {
+				return a.Expression() < b.Expression()
+			}
+			return a.Location.String() < b.Location.String()
+		})
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(entries) {
+			return nil, nil
+		}
+		entries = entries[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(entries) {
+		entries = entries[:q.Limit]
+	}
+
+	return entries, nil
+}
+
+func decodeEntries(name, value string) ([]entryJSON, error) {
+	var records []entryJSON
+	if err := json.Unmarshal([]byte(value), &records); err != nil {
+		return nil, fmt.Errorf("failed decoding entries %q: %v", name, err)
+	}
+
+	return records, nil
+}
+
+// GetEntry returns the entry with the name, see cron.Store
+func (s *Store) GetEntry(ctx context.Context, name string) (cron.Entry, error) {
+	value, err := s.client.HGet(ctx, s.key("entries"), name).Result()
+	if errors.Is(err, redis.Nil) {
+		return cron.Entry{}, cron.ErrEntryNotFound
+	}
+	if err != nil {
+		return cron.Entry{}, fmt.Errorf("failed reading entry %q: %v", name, err)
+	}
+	records, err := decodeEntries(name, value)
+	if err != nil {
+		return cron.Entry{}, err
+	}
+
+	entries := make([]cron.Entry, 0, len(records))
+	for _, v := range records {
+		e, err := v.entry()
+		if err != nil {
+			return cron.Entry{}, err
+		}
+		entries = append(entries, e)
+	}
+	switch len(entries) {
+	case 0:
+		return cron.Entry{}, cron.ErrEntryNotFound
+	case 1:
+		return entries[0], nil
+	default:
+		return cron.Entry{}, cron.AmbiguousEntryError{Name: name, Entries: entries}
+	}
+}
+
+// AddEntry stores the entry, adding again the same entry keeps whether it is paused. The location must be loadable
+// with time.LoadLocation (ex: not time.FixedZone).
+func (s *Store) AddEntry(ctx context.Context, entry cron.Entry) error {
+	if err := validateEntry(entry); err != nil {
+		return err
+	}
+
+	added := newEntryJSON(entry)
+	return s.updateEntries(ctx, []string{entry.Name}, func(entries map[string][]entryJSON) error {
+		for i, v := range entries[entry.Name] {
+			if v.same(added) {
+				added.Paused = v.Paused
+				entries[entry.Name][i] = added
+				return nil
+			}
+		}
+		entries[entry.Name] = append(entries[entry.Name], added)
+		return nil
+	})
+}
+
+// DeleteEntry deletes the entry with the same name, expression and location
+func (s *Store) DeleteEntry(ctx context.Context, entry cron.Entry) error {
+	deleted := newEntryJSON(entry)
+	return s.updateEntries(ctx, []string{entry.Name}, func(entries map[string][]entryJSON) error {
+		entries[entry.Name] = removeEntry(entries[entry.Name], deleted)
+		return nil
+	})
+}
+
+func removeEntry(records []entryJSON, removed entryJSON) []entryJSON {
+	var ret []entryJSON
+	for _, v := range records {
+		if !v.same(removed) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// UpdateEntry replaces the entries with the name by the updated entry, see cron.Store
+func (s *Store) UpdateEntry(ctx context.Context, name string, updated cron.Entry) error {
+	if err := validateEntry(updated); err != nil {
+		return err
+	}
+
+	record := newEntryJSON(updated)
+	return s.updateEntries(ctx, []string{name, updated.Name}, func(entries map[string][]entryJSON) error {
+		if len(entries[name]) == 0 {
+			return cron.ErrEntryNotFound
+		}
+		entries[name] = nil
+		entries[updated.Name] = append(removeEntry(entries[updated.Name], record), record)
+		return nil
+	})
+}
+
+// SetEntryActive activates or pauses the entries with the name
+func (s *Store) SetEntryActive(ctx context.Context, name string, active bool) error {
+	return s.updateEntries(ctx, []string{name}, func(entries map[string][]entryJSON) error {
+		if len(entries[name]) == 0 {
+			return cron.ErrEntryNotFound
+		}
+		for i := range entries[name] {
+			entries[name][i].Paused = !active
+		}
+		return nil
+	})
+}
+
+// updateAttempts is the number of attempts of updateEntries when the entries are modified concurrently
+const updateAttempts = 10
+
+// updateEntries calls fn with the entries of the names and writes them back in a transaction. It is retried when the
+// entries are modified by another client in between.
+func (s *Store) updateEntries(ctx context.Context, names []string, fn func(entries map[string][]entryJSON) error) error {
+	if len(names) > 1 && names[0] == names[1] {
+		names = names[:1]
+	}
+	key := s.key("entries")
+	update := func(tx *redis.Tx) error {
+		values, err := tx.HMGet(ctx, key, names...).Result()
+		if err != nil {
+			return fmt.Errorf("failed reading entries: %v", err)
+		}
+		entries := make(map[string][]entryJSON, len(names))
+		for i, v := range values {
+			value, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if entries[names[i]], err = decodeEntries(names[i], value); err != nil {
+				return err
+			}
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, name := range names {
+				if len(entries[name]) == 0 {
+					pipe.HDel(ctx, key, name)
+					continue
+				}
+				value, err := json.Marshal(entries[name])
+				if err != nil {
+					return err
+				}
+				pipe.HSet(ctx, key, name, value)
+			}
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.client.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) || attempt >= updateAttempts {
+			return err
+		}
+	}
+}
+
+// eventJSON is the member of the events sorted set
+type eventJSON struct {
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Location   string    `json:"location"`
+	Meta       string    `json:"meta,omitempty"`
+	Time       time.Time `json:"time"`
+	Manual     bool      `json:"manual,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	FiredBy    string    `json:"fired_by,omitempty"`
+}
+
+func (v eventJSON) event() (cron.Event, error) {
+	e, err := entryJSON{Name: v.Name, Expression: v.Expression, Location: v.Location, Meta: v.Meta}.entry()
+	if err != nil {
+		return cron.Event{}, err
+	}
+
+	return cron.Event{Entry: e, Time: v.Time, Manual: v.Manual, Status: v.Status, FiredBy: v.FiredBy}, nil
+}
+
+// minute is the score of the events on t
+func minute(t time.Time) int64 {
+	m := t.Unix() / 60
+	if t.Unix()%60 < 0 {
+		m--
+	}
+
+	return m
+}
+
+// triggeredKey is the key that prevents the event of the entry on the time from being recorded twice
+func (s *Store) triggeredKey(v eventJSON) string {
+	entry, _ := json.Marshal([]string{v.Name, v.Expression, v.Location})
+	return s.key("triggered:" + strconv.FormatInt(v.Time.Unix(), 10) + ":" + string(entry))
+}
+
+// addEventScript records the event in the sorted set only if the triggered key is created
+var addEventScript = redis.NewScript(`
+if not redis.call('SET', KEYS[1], '1', 'NX', 'PX', ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[3])
+return 1
+`)
+
+// AddEvent records the event, it returns cron.ErrAlreadyTriggered if the entry already has an event on the same time
+func (s *Store) AddEvent(ctx context.Context, e cron.Event) error {
+	if err := validateEntry(e.Entry); err != nil {
+		return err
+	}
+	v := eventJSON{
+		Name:       e.Entry.Name,
+		Expression: e.Entry.Expression(),
+		Location:   newEntryJSON(e.Entry).Location,
+		Meta:       e.Entry.Meta,
+		Time:       e.Time.UTC(),
+		Manual:     e.Manual,
+		Status:     e.Status,
+		FiredBy:    e.FiredBy,
+	}
+	member, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{s.triggeredKey(v), s.key("events")}
+	added, err := addEventScript.Run(ctx, s.client, keys, s.eventTTL.Milliseconds(), minute(v.Time), member).Int()
+	if err != nil {
+		return fmt.Errorf("failed adding event: %v", err)
+	}
+	if added == 0 {
+		return cron.ErrAlreadyTriggered
+	}
+
+	return nil
+}
+
+// GetEvents on [from, to) ordered by time, then name
+func (s *Store) GetEvents(ctx context.Context, from, to time.Time) ([]cron.Event, error) {
+	events, _, err := s.events(ctx, from, to)
+	return events, err
+}
+
+// events reads the events on [from, to) ordered by time, then name, expression and location. It also returns the
+// members of the events.
+func (s *Store) events(ctx context.Context, from, to time.Time) ([]cron.Event, []string, error) {
+	opt := &redis.ZRangeBy{
+		Min: strconv.FormatInt(minute(from), 10),
+		Max: strconv.FormatInt(minute(to), 10),
+	}
+	if from.IsZero() {
+		opt.Min = "-inf"
+	}
+	values, err := s.client.ZRangeByScore(ctx, s.key("events"), opt).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading events: %v", err)
+	}
+
+	var (
+		events  []cron.Event
+		members []string
+	)
+	for _, member := range values {
+		e, err := decodeEvent(member)
+		if err != nil {
+			return nil, nil, err
+		}
+		if e.Time.Before(from) || !e.Time.Before(to) {
+			continue
+		}
+		events = append(events, e)
+		members = append(members, member)
+	}
+	sort.Sort(byTime{events, members})
+
+	return events, members, nil
+}
+
+func decodeEvent(member string) (cron.Event, error) {
+	var v eventJSON
+	if err := json.Unmarshal([]byte(member), &v); err != nil {
+		return cron.Event{}, fmt.Errorf("failed decoding event %q: %v", member, err)
+	}
+
+	return v.event()
+}
+
+// byTime sorts the events and their members by time, then name, expression and location
+type byTime struct {
+	events  []cron.Event
+	members []string
+}
+
+func (b byTime) Len() int { return len(b.events) }
+
+func (b byTime) Swap(i, j int) {
+	b.events[i], b.events[j] = b.events[j], b.events[i]
+	b.members[i], b.members[j] = b.members[j], b.members[i]
+}
+
+func (b byTime) Less(i, j int) bool {
+	x, y := b.events[i], b.events[j]
+	if !x.Time.Equal(y.Time) {
+		return x.Time.Before(y.Time)
+	}
+	if x.Entry.Name != y.Entry.Name {
+		return x.Entry.Name < y.Entry.Name
+	}
+	if x.Entry.Expression() != y.Entry.Expression() {
+		return x.Entry.Expression() < y.Entry.Expression()
+	}
+	return x.Entry.Location.String() < y.Entry.Location.String()
+}
+
+// QueryEvents on [from, to) ordered like GetEvents, see cron.EventsQuery for the options
+func (s *Store) QueryEvents(ctx context.Context, from, to time.Time, opts ...cron.EventsOption) ([]cron.Event, error) {
+	events, _, err := s.events(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	q := cron.NewEventsQuery(opts...)
+	names := make(map[string]bool, len(q.Names))
+	for _, name := range q.Names {
+		names[name] = true
+	}
+	var ret []cron.Event
+	for _, e := range events {
+		if (len(names) == 0 || names[e.Entry.Name]) && (q.Status == "" || e.Status == q.Status) {
+			ret = append(ret, e)
+		}
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(ret) {
+			return nil, nil
+		}
+		ret = ret[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(ret) {
+		ret = ret[:q.Limit]
+	}
+
+	return ret, nil
+}
+
+// GetEventsByName on [from, to) of the entries with the name, an empty slice if there is none
+func (s *Store) GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]cron.Event, error) {
+	events, err := s.QueryEvents(ctx, from, to, cron.ForEntries(name))
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = make([]cron.Event, 0)
+	}
+
+	return events, nil
+}
+
+// GetEventsForEntries implements cron.EntryEventsGetter
+func (s *Store) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]cron.Event, error) {
+	return s.QueryEvents(ctx, from, to, cron.ForEntries(names...))
+}
+
+// CountEvents on [from, to)
+func (s *Store) CountEvents(ctx context.Context, from, to time.Time) (int64, error) {
+	events, _, err := s.events(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(events)), nil
+}
+
+// lastEventBatch is the number of events read at once by LastEvent from the latest
+const lastEventBatch = 100
+
+// LastEvent returns the latest event of the entry with the name, see cron.Store
+func (s *Store) LastEvent(ctx context.Context, name string, opts ...cron.EventsOption) (cron.Event, bool, error) {
+	status := cron.NewEventsQuery(opts...).Status
+	var (
+		last      cron.Event
+		lastScore float64
+		found     bool
+	)
+	for offset := int64(0); ; offset += lastEventBatch {
+		opt := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Offset: offset, Count: lastEventBatch}
+		values, err := s.client.ZRevRangeByScoreWithScores(ctx, s.key("events"), opt).Result()
+		if err != nil {
+			return cron.Event{}, false, fmt.Errorf("failed reading events: %v", err)
+		}
+		for _, z := range values {
+			// the events of the same minute are not ordered by time
+			if found && z.Score < lastScore {
+				return last, true, nil
+			}
+			member, _ := z.Member.(string)
+			e, err := decodeEvent(member)
+			if err != nil {
+				return cron.Event{}, false, err
+			}
+			if e.Entry.Name == name && (status == "" || e.Status == status) && (!found || e.Time.After(last.Time)) {
+				last, lastScore, found = e, z.Score, true
+			}
+		}
+		if len(values) < lastEventBatch {
+			return last, found, nil
+		}
+	}
+}
+
+// DeleteEvents deletes the events before until with their keys that prevent recording them twice
+func (s *Store) DeleteEvents(ctx context.Context, until time.Time) error {
+	events, members, err := s.events(ctx, time.Time{}, until)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		keys := make([]string, 0, len(events))
+		values := make([]interface{}, 0, len(members))
+		for i, e := range events {
+			entry := newEntryJSON(e.Entry)
+			keys = append(keys, s.triggeredKey(eventJSON{Name: entry.Name, Expression: entry.Expression,
+				Location: entry.Location, Time: e.Time}))
+			values = append(values, members[i])
+		}
+		pipe.ZRem(ctx, s.key("events"), values...)
+		pipe.Del(ctx, keys...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed deleting events: %v", err)
+	}
+
+	return nil
+}
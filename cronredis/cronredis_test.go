@@ -0,0 +1,301 @@
+package cronredis
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/yulrizka/cron"
+)
+
+var (
+	_ cron.Store             = (*Store)(nil)
+	_ cron.EntryEventsGetter = (*Store)(nil)
+)
+
+// newTestStore creates a store on miniredis, or on the Redis server of REDIS_TEST_ADDR (ex: localhost:6379) in which
+// case the returned miniredis is nil. The keys of the store are deleted at the end of the test.
+func newTestStore(t *testing.T, opts ...Option) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	var (
+		mr   *miniredis.Miniredis
+		addr = os.Getenv("REDIS_TEST_ADDR")
+	)
+	if addr == "" {
+		mr = miniredis.RunT(t)
+		addr = mr.Addr()
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+
+	prefix := "crontest_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		keys, err := client.Keys(ctx, "{"+prefix+"}:*").Result()
+		if err == nil && len(keys) > 0 {
+			client.Del(ctx, keys...)
+		}
+	})
+
+	return NewStore(client, append([]Option{WithPrefix(prefix)}, opts...)...), mr
+}
+
+func parse(t *testing.T, expression, name string) cron.Entry {
+	t.Helper()
+	e, err := cron.Parse(expression, time.UTC, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestStore_Entries(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	entry1 := parse(t, "* * * * *", "ENTRY_1")
+	entry1.Meta = "meta"
+	entry1.Timeout = time.Minute
+	entry2 := parse(t, "0 * * * *", "ENTRY_2")
+	entry2.DependsOn = []string{"ENTRY_1"}
+	for _, e := range []cron.Entry{entry1, entry2} {
+		if err := store.AddEntry(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddEntry(ctx, parse(t, "* * * * *", "")); err == nil {
+		t.Error("expected error on empty name")
+	}
+	custom, err := cron.Parse("* * * * *", time.FixedZone("custom", 3600), "ENTRY_3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, custom); err == nil {
+		t.Error("expected error on location not in the tz database")
+	}
+
+	got, err := store.GetEntry(ctx, entry1.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Expression() != entry1.Expression() || got.Meta != entry1.Meta || got.Timeout != entry1.Timeout ||
+		got.Location.String() != "UTC" {
+		t.Errorf("got entry %+v want %+v", got, entry1)
+	}
+	if _, err := store.GetEntry(ctx, "UNKNOWN"); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+
+	// paused entry is only returned with IncludeInactive and stays paused when it is added again
+	if err := store.SetEntryActive(ctx, entry2.Name, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, entry2); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := store.GetEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 1; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.OrderByName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	if got := entries[1]; got.Name != entry2.Name || !got.Paused || len(got.DependsOn) != 1 {
+		t.Errorf("got entry %+v want paused %+v", got, entry2)
+	}
+	if err := store.SetEntryActive(ctx, "UNKNOWN", false); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+
+	// the same name with another expression
+	hourly := parse(t, "0 * * * *", entry1.Name)
+	if err := store.AddEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+	var ambiguous cron.AmbiguousEntryError
+	if _, err := store.GetEntry(ctx, entry1.Name); !errors.As(err, &ambiguous) || len(ambiguous.Entries) != 2 {
+		t.Errorf("got error %v want AmbiguousEntryError of 2 entries", err)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.Page(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != entry1.Name || entries[0].Expression() != "0 * * * *" {
+		t.Errorf("got page %v want the hourly %s", entries, entry1.Name)
+	}
+	if err := store.DeleteEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+
+	// update renames the entry
+	renamed := parse(t, "*/5 * * * *", "ENTRY_4")
+	if err := store.UpdateEntry(ctx, entry1.Name, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateEntry(ctx, entry1.Name, renamed); err != cron.ErrEntryNotFound {
+		t.Errorf("got error %v want %v", err, cron.ErrEntryNotFound)
+	}
+	entries, err = store.GetEntries(ctx, cron.IncludeInactive(), cron.NamePrefix("ENTRY_4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Expression() != renamed.Expression() {
+		t.Errorf("got entries %v want %v", entries, renamed)
+	}
+}
+
+func TestStore_Events(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+
+	entry1 := parse(t, "* * * * *", "ENTRY_1")
+	entry2 := parse(t, "* * * * *", "ENTRY_2")
+	on := time.Date(2018, 12, 15, 10, 0, 0, 0, time.UTC)
+	events := []cron.Event{
+		{Entry: entry2, Time: on},
+		{Entry: entry1, Time: on, Status: cron.EventStatusSucceeded},
+		{Entry: entry1, Time: on.Add(time.Minute)},
+		{Entry: entry1, Time: on.Add(90 * time.Second), Manual: true},
+	}
+	for _, e := range events {
+		if err := store.AddEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddEvent(ctx, events[0]); err != cron.ErrAlreadyTriggered {
+		t.Errorf("got error %v want %v", err, cron.ErrAlreadyTriggered)
+	}
+
+	got, err := store.GetEvents(ctx, on, on.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Entry.Name != entry1.Name || got[1].Entry.Name != entry2.Name || !got[0].Time.Equal(on) {
+		t.Fatalf("got events %v want the events of ENTRY_1 and ENTRY_2 on %s", got, on)
+	}
+	if n, err := store.CountEvents(ctx, on, on.Add(2*time.Minute)); err != nil || n != 4 {
+		t.Errorf("got count %d, %v want 4", n, err)
+	}
+	got, err = store.QueryEvents(ctx, on, on.Add(time.Hour), cron.ForEntries(entry1.Name), cron.EventsPage(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].Time.Equal(on.Add(time.Minute)) {
+		t.Errorf("got events %v want the second event of ENTRY_1", got)
+	}
+	got, err = store.GetEventsByName(ctx, "UNKNOWN", on, on.Add(time.Hour))
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("got events %v, %v want empty slice", got, err)
+	}
+
+	last, ok, err := store.LastEvent(ctx, entry1.Name)
+	if err != nil || !ok || !last.Manual {
+		t.Errorf("got last event %v, %t, %v want the manual event", last, ok, err)
+	}
+	last, ok, err = store.LastEvent(ctx, entry1.Name, cron.EventsWithStatus(cron.EventStatusSucceeded))
+	if err != nil || !ok || !last.Time.Equal(on) {
+		t.Errorf("got last succeeded event %v, %t, %v want the event on %s", last, ok, err, on)
+	}
+	if _, ok, err := store.LastEvent(ctx, "UNKNOWN"); err != nil || ok {
+		t.Errorf("got last event %t, %v want none", ok, err)
+	}
+
+	// deleted event can be recorded again
+	if err := store.DeleteEvents(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.CountEvents(ctx, on, on.Add(2*time.Minute)); err != nil || n != 2 {
+		t.Errorf("got count %d, %v after delete want 2", n, err)
+	}
+	if err := store.AddEvent(ctx, events[0]); err != nil {
+		t.Errorf("failed adding deleted event again: %v", err)
+	}
+}
+
+func TestStore_Lock(t *testing.T) {
+	ctx := context.Background()
+	store1, mr := newTestStore(t, WithLock(time.Minute, 100*time.Millisecond))
+	store2 := NewStore(store1.client, WithPrefix(store1.prefix), WithLock(time.Minute, 100*time.Millisecond))
+
+	if err := store1.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store1.Lock(ctx); err == nil {
+		t.Error("expected error locking twice")
+	}
+	if got, want := store2.Lock(ctx), cron.ErrLockTimeout; got != want {
+		t.Fatalf("got error %v want %v", got, want)
+	}
+	if err := store1.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Unlock(ctx); err == nil {
+		t.Error("expected error unlocking twice")
+	}
+
+	if mr == nil {
+		return
+	}
+	// the lock of a crashed instance expires
+	if err := store1.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(time.Minute)
+	if err := store2.Lock(ctx); err != nil {
+		t.Fatalf("lock is not released after it expired: %v", err)
+	}
+	if err := store1.Unlock(ctx); !errors.Is(err, cron.ErrLockLost) {
+		t.Errorf("got error %v want %v", err, cron.ErrLockLost)
+	}
+	if err := store2.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_Scheduler(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+	if err := store.AddEntry(ctx, parse(t, "* * * * *", "ENTRY_1")); err != nil {
+		t.Fatal(err)
+	}
+
+	triggered := make(chan cron.Entry, 1)
+	handler := func(ctx context.Context, e cron.Entry) error {
+		triggered <- e
+		return nil
+	}
+	s := cron.NewScheduler(handler, store, cron.WithImmediateFirstCheck())
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.Run(ctx)
+
+	select {
+	case e := <-triggered:
+		if e.Name != "ENTRY_1" {
+			t.Errorf("got entry %q want ENTRY_1", e.Name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("entry is not triggered")
+	}
+}
@@ -0,0 +1,140 @@
+package cron
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ParseFile parses crontab formatted text from r. Each line contains a 5 fields expression followed by the name
+// of the entry (ex: `*/5 * * * * ENTRY_1`), or `@reboot` followed by the name (see Entry.IsReboot). Empty lines and
+// lines started with '#' are ignored.
+//
+// Lines in form of `KEY=value` set an environment variable for the entries that follow it. The environment of an
+// entry is attached to Entry.Meta as JSON object (ex: `{"KEY":"value"}`). Entries without environment have empty Meta.
+// The special `CRON_TZ=<zone>` assignment is not part of the environment, instead it sets the location of the entries
+// that follow it. Before any `CRON_TZ` assignment loc is used, if loc is nil it uses UTC.
+func ParseFile(r io.Reader, loc *time.Location) ([]Entry, error) {
+	var entries []Entry
+	env := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if key, value, ok := parseEnv(line); ok {
+			if key == "CRON_TZ" {
+				l, err := time.LoadLocation(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: failed to load location %q: %v", lineNo, value, err)
+				}
+				loc = l
+				continue
+			}
+			env[key] = value
+			continue
+		}
+
+		fields := strings.Fields(line)
+		n := 5 // fields of the expression
+		if fields[0] == rebootExpression {
+			n = 1
+		}
+		if len(fields) <= n {
+			return nil, fmt.Errorf("line %d: got %d want at least %d fields (expression and name)", lineNo, len(fields), n+1)
+		}
+		expression, name := strings.Join(fields[:n], " "), strings.Join(fields[n:], " ")
+		entry, err := Parse(expression, loc, name)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+
+		if len(env) > 0 {
+			meta, err := json.Marshal(env)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: failed encoding environment: %v", lineNo, err)
+			}
+			entry.Meta = string(meta)
+		}
+
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading crontab: %v", err)
+	}
+
+	return entries, nil
+}
+
+// parseEnv parses environment assignment line `KEY=value`. Value can be optionally quoted with single or double quote.
+func parseEnv(line string) (key, value string, ok bool) {
+	i := strings.IndexByte(line, '=')
+	if i <= 0 {
+		return "", "", false
+	}
+
+	key = strings.TrimSpace(line[:i])
+	for j, c := range key {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && (j == 0 || !isDigit) {
+			return "", "", false
+		}
+	}
+
+	value = strings.TrimSpace(line[i+1:])
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	return key, value, true
+}
+
+// WriteCrontab writes entries to w as crontab formatted text that can be read back with ParseFile. Each entry is
+// written as `<expression> <name>`. When the location of an entry is different from the previous one (starting from
+// UTC), a `CRON_TZ=<zone>` line is written before it. Meta of the entries is not written.
+//
+// It returns an error without writing anything if an entry can not be read back, ex: its name is empty or it has
+// leading, trailing or repeated spaces, or its expression never matches (see Entry.IsSatisfiable).
+func WriteCrontab(w io.Writer, entries []Entry) error {
+	for _, e := range entries {
+		if e.Name == "" || strings.Join(strings.Fields(e.Name), " ") != e.Name {
+			return fmt.Errorf("name %q of entry can not be read back from crontab", e.Name)
+		}
+		if !e.IsReboot() && !e.IsSatisfiable() {
+			return fmt.Errorf("entry %q can not be read back from crontab: expression %q never matches", e.Name, e.expression)
+		}
+	}
+
+	current := time.UTC.String()
+	for _, e := range entries {
+		loc := time.UTC.String()
+		if e.Location != nil {
+			loc = e.Location.String()
+		}
+		if loc != current {
+			if _, err := fmt.Fprintf(w, "CRON_TZ=%s\n", loc); err != nil {
+				return fmt.Errorf("failed writing location of entry %q: %v", e.Name, err)
+			}
+			current = loc
+		}
+
+		expression := e.expression
+		if expression == "" {
+			expression = e.schedule()
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", expression, e.Name); err != nil {
+			return fmt.Errorf("failed writing entry %q: %v", e.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFile(t *testing.T) {
+	crontab := `
+# comment line is ignored
+* * * * * ENTRY_1
+
+SHELL=/bin/sh
+MAILTO="ops@example.com"
+*/5 * * * * ENTRY_2
+PATH=/usr/bin
+0 1 * * * ENTRY 3
+`
+	entries, err := ParseFile(strings.NewReader(crontab), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 3; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		meta       string
+	}{
+		{name: "ENTRY_1", expression: "* * * * *", meta: ""},
+		{name: "ENTRY_2", expression: "*/5 * * * *", meta: `{"MAILTO":"ops@example.com","SHELL":"/bin/sh"}`},
+		{name: "ENTRY 3", expression: "0 1 * * *", meta: `{"MAILTO":"ops@example.com","PATH":"/usr/bin","SHELL":"/bin/sh"}`},
+	}
+	for i, tt := range tests {
+		e := entries[i]
+		if got, want := e.Name, tt.name; got != want {
+			t.Errorf("[%d] got name %q want %q", i, got, want)
+		}
+		if got, want := e.Expression(), tt.expression; got != want {
+			t.Errorf("[%d] got expression %q want %q", i, got, want)
+		}
+		if got, want := e.Meta, tt.meta; got != want {
+			t.Errorf("[%d] got meta %q want %q", i, got, want)
+		}
+	}
+}
+
+func TestParseFile_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		crontab string
+		wantErr string
+	}{
+		{name: "missing name", crontab: "* * * * *", wantErr: "line 1: got 5 want at least 6 fields (expression and name)"},
+		{name: "reboot missing name", crontab: "@reboot", wantErr: "line 1: got 1 want at least 2 fields (expression and name)"},
+		{name: "invalid expression", crontab: "\n60 * * * * ENTRY_1", wantErr: `line 2: failed parsing 'minute' field "60": value out of range (0 - 59): 60`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFile(strings.NewReader(tt.crontab), time.UTC)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseFile() error = %q, wantErr %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteCrontab(t *testing.T) {
+	jkt, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []Entry
+	for _, v := range []struct {
+		expression string
+		loc        *time.Location
+		name       string
+	}{
+		{expression: "* * * * *", loc: time.UTC, name: "ENTRY_1"},
+		{expression: "*/5 1-3 * * 1", loc: jkt, name: "ENTRY_2"},
+		{expression: "0 0 1 1 *", loc: jkt, name: "ENTRY 3"},
+		{expression: "30 2 * * *", loc: time.UTC, name: "ENTRY_4"},
+		{expression: "@reboot", loc: time.UTC, name: "ENTRY_5"},
+	} {
+		e, err := Parse(v.expression, v.loc, v.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	var buf strings.Builder
+	if err := WriteCrontab(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `* * * * * ENTRY_1
+CRON_TZ=Asia/Jakarta
+*/5 1-3 * * 1 ENTRY_2
+0 0 1 1 * ENTRY 3
+CRON_TZ=UTC
+30 2 * * * ENTRY_4
+@reboot ENTRY_5
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got crontab %q want %q", got, want)
+	}
+
+	parsed, err := ParseFile(strings.NewReader(buf.String()), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(parsed), len(entries); got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	for i := range entries {
+		if got, want := parsed[i].String(), entries[i].String(); got != want {
+			t.Errorf("[%d] got entry %s want %s", i, got, want)
+		}
+		if got, want := parsed[i].Expression(), entries[i].Expression(); got != want {
+			t.Errorf("[%d] got expression %q want %q", i, got, want)
+		}
+	}
+}
+
+func TestWriteCrontab_error(t *testing.T) {
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withName := func(name string) Entry {
+		e := entry
+		e.Name = name
+		return e
+	}
+	never, err := ParseStored("0 0 30 2 *", time.UTC, "ENTRY_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		entry   Entry
+		wantErr string
+	}{
+		{name: "empty name", entry: withName(""), wantErr: `name "" of entry can not be read back from crontab`},
+		{name: "repeated spaces", entry: withName("ENTRY  1"), wantErr: `name "ENTRY  1" of entry can not be read back from crontab`},
+		{name: "trailing space", entry: withName("ENTRY_1 "), wantErr: `name "ENTRY_1 " of entry can not be read back from crontab`},
+		{
+			name: "never matches", entry: never,
+			wantErr: `entry "ENTRY_2" can not be read back from crontab: expression "0 0 30 2 *" never matches`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			err := WriteCrontab(&buf, []Entry{entry, tt.entry})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("WriteCrontab() error = %v, wantErr %q", err, tt.wantErr)
+			}
+			if buf.Len() > 0 {
+				t.Errorf("got crontab %q want nothing written", buf.String())
+			}
+		})
+	}
+}
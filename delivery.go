@@ -0,0 +1,149 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventStatusClaimed is Event.Status of an event whose handler has not returned yet with AtLeastOnce delivery, see
+// WithDeliveryMode
+const EventStatusClaimed = "claimed"
+
+// DeliveryMode is the guarantee of running the handler of a triggered entry when an instance dies while the handler
+// is running, see WithDeliveryMode
+type DeliveryMode int
+
+const (
+	// AtMostOnce records the event before the handler runs. The run is lost if the instance dies before the handler
+	// returns.
+	AtMostOnce DeliveryMode = iota
+	// AtLeastOnce records the event as claimed (EventStatusClaimed) by the instance and completes it with the result
+	// of the handler (EventStatusSucceeded or EventStatusFailed). The claims that are not completed are run again by
+	// the recovery on start, so a handler may run more than once.
+	AtLeastOnce
+)
+
+// ClaimStore is an optional interface of a Store that finds and takes over the claims of AtLeastOnce delivery. The
+// methods are called while the store is locked, the claims are completed with EventStatusStore.SetEventStatus.
+type ClaimStore interface {
+	EventStatusStore
+	// StaleClaims returns the events with EventStatusClaimed that are claimed before the given time, the time of a
+	// claim is the event time until it is reclaimed
+	StaleClaims(ctx context.Context, before time.Time) ([]Event, error)
+	// ReclaimEvent claims the event again for e.FiredBy on now if it is still claimed before staleBefore. ok is false
+	// if it is completed or reclaimed by another instance in the mean time.
+	ReclaimEvent(ctx context.Context, e Event, staleBefore, now time.Time) (ok bool, err error)
+}
+
+// defaultStaleClaim is how long a claim is kept before it is run again
+const defaultStaleClaim = time.Hour
+
+// WithDeliveryMode sets the delivery mode of the scheduled events (default AtMostOnce). With AtLeastOnce the store
+// must implement ClaimStore, Run recovers the claims older than staleAfter (default an hour when zero) on start.
+// staleAfter should be longer than the longest handler since a claim is not renewed while the handler runs. The
+// events of TriggerNow are always AtMostOnce.
+func WithDeliveryMode(mode DeliveryMode, staleAfter time.Duration) Option {
+	return func(s *Scheduler) {
+		if staleAfter <= 0 {
+			staleAfter = defaultStaleClaim
+		}
+		s.delivery = mode
+		s.staleClaim = staleAfter
+	}
+}
+
+// WithRecordAfterRun records the event of a scheduled entry only when its handler returns nil, instead of before the
+// handler runs. An instance that dies while the handler runs does not leave an event of a run that never completed, a
+// failed run is not recorded either. The trade-off is that the entry is not marked as triggered while the handler
+// runs: another instance checking the same minute triggers it again, and a restart during the handler runs it again
+// if the minute is checked again (ex: WithImmediateFirstCheck). It should be used with a single instance or handlers
+// that tolerate running twice (see IdempotencyKey). WithDeliveryMode is ignored and TriggerNow still records the
+// event before the handler runs.
+func WithRecordAfterRun() Option {
+	return func(s *Scheduler) {
+		s.recordAfterRun = true
+	}
+}
+
+// recordsAfterRun reports whether the event is recorded after its handler succeeded, see WithRecordAfterRun
+func (s *Scheduler) recordsAfterRun(ev Event) bool {
+	return s.recordAfterRun && !ev.Manual
+}
+
+// recordSucceeded records the event of a succeeded handler while the store is locked, errors are logged
+func (s *Scheduler) recordSucceeded(ctx context.Context, ev Event) {
+	ctx = context.WithoutCancel(ctx)
+	ev.Status = EventStatusSucceeded
+	err := s.withLock(ctx, func() error {
+		return s.addEvent(ctx, ev)
+	})
+	if errors.Is(err, ErrAlreadyTriggered) {
+		s.logger.Info("entry also run by another instance", s.entryKV(ev.Entry, "at", ev.Time)...)
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to store event", s.entryKV(ev.Entry, "at", ev.Time, "error", err)...)
+		s.log(fmt.Errorf("failed to store event of entry %q: %v", ev.Entry.Name, err))
+	}
+}
+
+// recoverClaims runs the handlers of the stale claims again, the claims are taken over by this instance while the
+// store is locked so that only one instance runs them. Errors are logged.
+func (s *Scheduler) recoverClaims(ctx context.Context) {
+	if s.delivery != AtLeastOnce || s.recordAfterRun {
+		return
+	}
+	store, ok := s.store.(ClaimStore)
+	if !ok {
+		s.logger.Error("store does not support at least once delivery, stale claims are not recovered")
+		s.log(fmt.Errorf("store %T does not implement ClaimStore", s.store))
+		return
+	}
+
+	var reclaimed []Event
+	now := s.now()
+	err := s.withLock(ctx, func() error {
+		stale, err := store.StaleClaims(ctx, now.Add(-s.staleClaim))
+		if err != nil {
+			s.metrics.IncError(ErrorKindStore)
+			return fmt.Errorf("failed to get stale claims: %v", err)
+		}
+		for _, ev := range stale {
+			previous := ev.FiredBy
+			ev.FiredBy = s.instance.ID
+			ev.IdempotencyKey = IdempotencyKey(ev.Entry.Name, ev.Time)
+			ok, err := store.ReclaimEvent(ctx, ev, now.Add(-s.staleClaim), now)
+			if err != nil {
+				s.metrics.IncError(ErrorKindStore)
+				return fmt.Errorf("failed to reclaim event of entry %q: %v", ev.Entry.Name, err)
+			}
+			if ok {
+				s.logger.Info("stale claim recovered", s.entryKV(ev.Entry, "at", ev.Time, "claimed_by", previous)...)
+				reclaimed = append(reclaimed, ev)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("recovering stale claims failed", "error", err)
+		s.log(err)
+	}
+
+	// like check, the handlers are dispatched after the store is unlocked
+	for _, ev := range reclaimed {
+		fn, err := s.handlerFor(ev.Entry)
+		if err != nil {
+			s.metrics.IncError(ErrorKindNoHandler)
+			s.incStats(&s.stats.Errored)
+			s.logger.Error("no handler", s.entryKV(ev.Entry, "at", ev.Time)...)
+			s.log(err)
+			continue
+		}
+		s.metrics.IncTriggered(ev.Entry.Name)
+		s.incStats(&s.stats.Triggered)
+		s.onTrigger(ev)
+		s.goRun(ctx, fn, ev)
+	}
+}
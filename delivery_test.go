@@ -0,0 +1,197 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// crashStore simulates an instance that dies while the handler is running: the claim is recorded but it is never
+// completed
+type crashStore struct {
+	*MemStore
+}
+
+func (c crashStore) SetEventStatus(ctx context.Context, e Event, status string) error {
+	return nil
+}
+
+// countHandler counts the runs by entry name
+type countHandler struct {
+	mu   sync.Mutex
+	runs map[string]int
+}
+
+func (c *countHandler) handle(ctx context.Context, e Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.runs == nil {
+		c.runs = make(map[string]int)
+	}
+	c.runs[e.Name]++
+	return nil
+}
+
+func (c *countHandler) count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs[name]
+}
+
+func TestScheduler_atLeastOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	// the first instance dies after the claim
+	var first countHandler
+	s1 := NewScheduler(first.handle, crashStore{store}, WithDeliveryMode(AtLeastOnce, time.Minute), WithInstance("i1", ""))
+	if err := s1.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	s1.inFlight.Wait()
+	if got, want := first.count("ENTRY_1"), 1; got != want {
+		t.Fatalf("got runs %d want %d", got, want)
+	}
+	if got, want := eventStatus(store, "ENTRY_1"), EventStatusClaimed; got != want {
+		t.Fatalf("got status %q want %q", got, want)
+	}
+
+	// the claim is not stale yet
+	var second countHandler
+	s2 := NewScheduler(second.handle, crashStore{store}, WithDeliveryMode(AtLeastOnce, time.Minute), WithInstance("i2", ""))
+	s2.now = func() time.Time { return now.Add(30 * time.Second) }
+	s2.recoverClaims(ctx)
+	s2.inFlight.Wait()
+	if got, want := second.count("ENTRY_1"), 0; got != want {
+		t.Fatalf("got runs %d want %d", got, want)
+	}
+
+	// the stale claim is run again once, the second instance also dies
+	s2.now = func() time.Time { return now.Add(2 * time.Minute) }
+	s2.recoverClaims(ctx)
+	s2.inFlight.Wait()
+	if got, want := second.count("ENTRY_1"), 1; got != want {
+		t.Fatalf("got runs %d want %d", got, want)
+	}
+	store.mu.Lock()
+	firedBy := store.events[0].FiredBy
+	store.mu.Unlock()
+	if got, want := firedBy, "i2"; got != want {
+		t.Errorf("got fired by %q want %q", got, want)
+	}
+
+	// the reclaimed event is stale after the duration since it is reclaimed
+	var third countHandler
+	s3 := NewScheduler(third.handle, store, WithDeliveryMode(AtLeastOnce, time.Minute), WithInstance("i3", ""))
+	s3.now = func() time.Time { return now.Add(2*time.Minute + 30*time.Second) }
+	s3.recoverClaims(ctx)
+	s3.inFlight.Wait()
+	if got, want := third.count("ENTRY_1"), 0; got != want {
+		t.Fatalf("got runs %d want %d", got, want)
+	}
+	s3.now = func() time.Time { return now.Add(4 * time.Minute) }
+	s3.recoverClaims(ctx)
+	s3.inFlight.Wait()
+	if got, want := third.count("ENTRY_1"), 1; got != want {
+		t.Fatalf("got runs %d want %d", got, want)
+	}
+	if got, want := eventStatus(store, "ENTRY_1"), EventStatusSucceeded; got != want {
+		t.Fatalf("got status %q want %q", got, want)
+	}
+
+	// a completed event is not run again
+	s3.now = func() time.Time { return now.Add(time.Hour) }
+	s3.recoverClaims(ctx)
+	s3.inFlight.Wait()
+	if got, want := third.count("ENTRY_1"), 1; got != want {
+		t.Errorf("got runs %d want %d", got, want)
+	}
+}
+
+func TestScheduler_atMostOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	var first countHandler
+	s1 := NewScheduler(first.handle, crashStore{store})
+	if err := s1.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	s1.inFlight.Wait()
+	if got, want := eventStatus(store, "ENTRY_1"), ""; got != want {
+		t.Fatalf("got status %q want %q", got, want)
+	}
+
+	// the run is lost
+	var second countHandler
+	s2 := NewScheduler(second.handle, store, WithDeliveryMode(AtLeastOnce, time.Minute))
+	s2.now = func() time.Time { return now.Add(time.Hour) }
+	s2.recoverClaims(ctx)
+	s2.inFlight.Wait()
+	if got, want := second.count("ENTRY_1"), 0; got != want {
+		t.Errorf("got runs %d want %d", got, want)
+	}
+}
+
+func TestScheduler_recordAfterRun(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	failing, err := Parse("* * * * *", time.UTC, "FAILING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	succeeding, err := Parse("* * * * *", time.UTC, "SUCCEEDING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, failing)
+	store.AddEntry(ctx, succeeding)
+
+	// the event is not recorded while the handler runs
+	release := make(chan struct{})
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		<-release
+		if e.Name == "FAILING" {
+			return errors.New("failed")
+		}
+		return nil
+	}, store, WithRecordAfterRun())
+	if err := s.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(store.events), 0; got != want {
+		t.Errorf("got %d events while the handlers run want %d", got, want)
+	}
+
+	// the running handlers are not triggered again by this scheduler
+	if err := s.check(ctx, now.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Stats().Skipped, uint64(2); got != want {
+		t.Errorf("got skipped %d want %d", got, want)
+	}
+	close(release)
+	s.inFlight.Wait()
+
+	if got, want := len(store.events), 1; got != want {
+		t.Fatalf("got %d events want %d", got, want)
+	}
+	if got := store.events[0]; got.Entry.Name != "SUCCEEDING" || got.Status != EventStatusSucceeded {
+		t.Errorf("got event of %s with status %q want SUCCEEDING with %q", got.Entry.Name, got.Status, EventStatusSucceeded)
+	}
+}
@@ -0,0 +1,242 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event.Status of a triggered event after its handler returned, it is recorded when the store implements
+// EventStatusStore
+const (
+	EventStatusSucceeded = "succeeded"
+	EventStatusFailed    = "failed"
+	// EventStatusSkippedDependency is a dependent entry that is not run because its dependencies did not succeed, see
+	// Entry.DependsOn
+	EventStatusSkippedDependency = "skipped-dependency"
+)
+
+// EventStatusStore is an optional interface of a Store that records the status of the events. The scheduler records
+// the result of the handlers with it so that the dependent entries (see Entry.DependsOn) can wait for them from any
+// instance. Unlike the other methods of a Store, they are called without locking the store and must be safe for
+// concurrent use. MemStore and SqlStore implement it.
+type EventStatusStore interface {
+	// SetEventStatus sets the status of the event of the entry on the event time, it is a no-op if there is no such
+	// event
+	SetEventStatus(ctx context.Context, e Event, status string) error
+	// GetEventStatuses returns the status of the events (not manual) on [from, to) of the entries with the names by
+	// entry name
+	GetEventStatuses(ctx context.Context, names []string, from, to time.Time) (map[string]string, error)
+}
+
+// DependencyPolicy is what the scheduler does with a dependent entry whose dependencies did not succeed within the
+// wait timeout or failed, see WithDependencyWait
+type DependencyPolicy int
+
+const (
+	// DependencySkip does not run the dependent entry, its event status is EventStatusSkippedDependency
+	DependencySkip DependencyPolicy = iota
+	// DependencyRun runs the dependent entry anyway
+	DependencyRun
+)
+
+// default wait for the dependencies and the interval of reading their status from the store
+const (
+	defaultDependencyTimeout = 10 * time.Minute
+	defaultDependencyPoll    = 5 * time.Second
+)
+
+// WithDependencyWait sets how long a dependent entry waits for its dependencies (default 10 minutes) and what is done
+// when they do not succeed within timeout or one of them failed (default DependencySkip).
+func WithDependencyWait(timeout time.Duration, policy DependencyPolicy) Option {
+	return func(s *Scheduler) {
+		s.dependsTimeout = timeout
+		s.dependsPolicy = policy
+	}
+}
+
+// DependencyCycleError is returned by ValidateDependencies when entries depend on each other
+type DependencyCycleError struct {
+	// Cycle is the names of the entries in the cycle, the first name is repeated at the end
+	Cycle []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// ValidateDependencies returns DependencyCycleError if an entry depends on itself directly or through other entries
+// (see Entry.DependsOn). Entries with the same name share their dependencies, dependencies that are not in entries
+// are ignored.
+func ValidateDependencies(entries []Entry) error {
+	deps := make(map[string][]string)
+	var names []string
+	for _, e := range entries {
+		if _, ok := deps[e.Name]; !ok {
+			names = append(names, e.Name)
+		}
+		deps[e.Name] = append(deps[e.Name], e.DependsOn...)
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return &DependencyCycleError{Cycle: append(append([]string(nil), path[i:]...), name)}
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, d := range deps[name] {
+			if _, ok := deps[d]; !ok {
+				continue
+			}
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, n := range names {
+		if err := visit(n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportDependencies logs the dependency cycle of the loaded entries. The entries in a cycle wait for each other
+// until the timeout of WithDependencyWait.
+func (s *Scheduler) reportDependencies(entries []Entry) {
+	if err := ValidateDependencies(entries); err != nil {
+		s.logger.Error("invalid entry dependencies", "error", err)
+		s.log(err)
+	}
+}
+
+// dependencies returns the dependencies of e that are expected to be triggered on the same minute, the names of the
+// entries matching the minute are in matching. The other dependencies are not waited for.
+func dependencies(e Entry, matching map[string]bool) []string {
+	var deps []string
+	for _, d := range e.DependsOn {
+		if matching[d] && d != e.Name {
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+// goRunAfter runs the handler in its own go routine once the events of deps on the event time succeeded
+func (s *Scheduler) goRunAfter(ctx context.Context, fn HandlerFunc, ev Event, deps []string) {
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		if s.waitDependencies(ctx, ev, deps) {
+			s.run(ctx, fn, ev)
+		}
+	}()
+}
+
+// waitDependencies reads the status of the events of deps from the store until they all succeeded, one of them failed
+// or the timeout. It reports whether the handler of ev should run according to the policy. It gives up without
+// running the handler when ctx is done or the scheduler is stopped.
+func (s *Scheduler) waitDependencies(ctx context.Context, ev Event, deps []string) bool {
+	s.runMu.Lock()
+	stop := s.stop
+	s.runMu.Unlock()
+	timeout := time.NewTimer(s.dependsTimeout)
+	defer timeout.Stop()
+
+	reason := "timeout"
+wait:
+	for {
+		succeeded, failed, err := s.dependenciesStatus(ctx, ev, deps)
+		switch {
+		case err != nil:
+			s.logger.Error("failed to read dependencies", s.entryKV(ev.Entry, "at", ev.Time, "error", err)...)
+			s.log(fmt.Errorf("failed to read dependencies of entry %q: %v", ev.Entry.Name, err))
+		case succeeded:
+			return true
+		case failed:
+			reason = "failed"
+			break wait
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-stop:
+			return false
+		case <-timeout.C:
+			break wait
+		case <-time.After(s.dependsPoll):
+		}
+	}
+
+	if s.dependsPolicy == DependencyRun {
+		s.logger.Info("dependencies did not succeed, running anyway", s.entryKV(ev.Entry, "at", ev.Time, "reason", reason)...)
+		return true
+	}
+	s.metrics.IncSkipped(ev.Entry.Name, SkipDependency)
+	s.incStats(&s.stats.Skipped)
+	s.logger.Info("entry skipped, dependencies did not succeed", s.entryKV(ev.Entry, "at", ev.Time, "reason", reason)...)
+	ev.Status = EventStatusSkippedDependency
+	s.recordStatus(ctx, ev, EventStatusSkippedDependency)
+	s.onSkip(ev)
+	return false
+}
+
+// dependenciesStatus reads the status of the events of deps in the dedupe bucket of the event time. succeeded is true
+// when all of them succeeded, failed is true when one of them did not succeed and will not (ex: its handler failed).
+func (s *Scheduler) dependenciesStatus(ctx context.Context, ev Event, deps []string) (succeeded, failed bool, err error) {
+	store, ok := s.store.(EventStatusStore)
+	if !ok {
+		return false, false, nil
+	}
+	status, err := store.GetEventStatuses(ctx, deps, ev.Time.Truncate(s.dedupe), ev.Time.Add(time.Minute))
+	if err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		return false, false, err
+	}
+
+	succeeded = true
+	for _, d := range deps {
+		switch status[d] {
+		case EventStatusSucceeded:
+		case EventStatusFailed, EventStatusSkippedDependency, EventStatusSuppressedBlackout:
+			return false, true, nil
+		default:
+			succeeded = false
+		}
+	}
+	return succeeded, false, nil
+}
+
+// recordStatus records the status of the event when the store implements EventStatusStore, the error is logged. It
+// is recorded even if the handler context is canceled.
+func (s *Scheduler) recordStatus(ctx context.Context, ev Event, status string) {
+	store, ok := s.store.(EventStatusStore)
+	if !ok {
+		return
+	}
+	if err := store.SetEventStatus(context.WithoutCancel(ctx), ev, status); err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		s.logger.Error("failed to record event status", s.entryKV(ev.Entry, "at", ev.Time, "status", status, "error", err)...)
+		s.log(fmt.Errorf("failed to record status of entry %q: %v", ev.Entry.Name, err))
+	}
+}
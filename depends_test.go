@@ -0,0 +1,231 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	entry := func(name string, deps ...string) Entry {
+		e, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.DependsOn = deps
+		return e
+	}
+
+	tests := []struct {
+		name      string
+		entries   []Entry
+		wantCycle []string
+	}{
+		{name: "no dependencies", entries: []Entry{entry("A"), entry("B")}},
+		{name: "chain", entries: []Entry{entry("C", "B"), entry("B", "A"), entry("A")}},
+		{name: "diamond", entries: []Entry{entry("D", "B", "C"), entry("B", "A"), entry("C", "A"), entry("A")}},
+		{name: "unknown dependency", entries: []Entry{entry("A", "X")}},
+		{name: "self", entries: []Entry{entry("A", "A")}, wantCycle: []string{"A", "A"}},
+		{
+			name:      "cycle",
+			entries:   []Entry{entry("A"), entry("B", "C"), entry("C", "D"), entry("D", "B")},
+			wantCycle: []string{"B", "C", "D", "B"},
+		},
+		{
+			name:      "same name",
+			entries:   []Entry{entry("A", "B"), entry("B"), entry("B", "A")},
+			wantCycle: []string{"A", "B", "A"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDependencies(tt.entries)
+			var cycleErr *DependencyCycleError
+			if !errors.As(err, &cycleErr) {
+				if tt.wantCycle != nil || err != nil {
+					t.Fatalf("got error %v want cycle %v", err, tt.wantCycle)
+				}
+				return
+			}
+			if got, want := cycleErr.Cycle, tt.wantCycle; !reflect.DeepEqual(got, want) {
+				t.Errorf("got cycle %v want %v", got, want)
+			}
+		})
+	}
+}
+
+// newDependsScheduler creates a scheduler with entry A and B depending on A that both match every minute. The handler
+// of A is fnA, the names of the entries that ran are returned by the ran func.
+func newDependsScheduler(t *testing.T, fnA HandlerFunc, opts ...Option) (s *Scheduler, store *MemStore, ran func() []string) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, name)
+	}
+
+	ctx := context.Background()
+	store = &MemStore{}
+	entryA, err := Parse("* * * * *", time.UTC, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryB, err := Parse("* * * * *", time.UTC, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryB.DependsOn = []string{"A", "NOT_MATCHING"}
+	store.AddEntry(ctx, entryB)
+	store.AddEntry(ctx, entryA)
+
+	s = NewScheduler(nil, store, opts...)
+	s.dependsPoll = time.Millisecond
+	s.Handle("A", func(ctx context.Context, e Entry) error {
+		err := fnA(ctx, e)
+		record(e.Name)
+		return err
+	})
+	s.Handle("B", func(ctx context.Context, e Entry) error {
+		record(e.Name)
+		return nil
+	})
+
+	return s, store, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), names...)
+	}
+}
+
+// eventStatus returns the status of the event of the entry with the name
+func eventStatus(store *MemStore, name string) string {
+	e, _, _ := store.LastEvent(context.Background(), name)
+	return e.Status
+}
+
+func TestScheduler_checkDependsOn(t *testing.T) {
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	s, store, ran := newDependsScheduler(t, func(ctx context.Context, e Entry) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if err := s.check(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	s.inFlight.Wait()
+	if got, want := ran(), []string{"A", "B"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ran %v want %v", got, want)
+	}
+	for _, name := range []string{"A", "B"} {
+		if got, want := eventStatus(store, name), EventStatusSucceeded; got != want {
+			t.Errorf("got status of %s %q want %q", name, got, want)
+		}
+	}
+}
+
+func TestScheduler_checkDependsOnFailed(t *testing.T) {
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	fail := func(ctx context.Context, e Entry) error { return errors.New("boom") }
+
+	tests := []struct {
+		name       string
+		policy     DependencyPolicy
+		wantRan    []string
+		wantStatus string
+	}{
+		{name: "skip", policy: DependencySkip, wantRan: []string{"A"}, wantStatus: EventStatusSkippedDependency},
+		{name: "run", policy: DependencyRun, wantRan: []string{"A", "B"}, wantStatus: EventStatusSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var skipped []Event
+			s, store, ran := newDependsScheduler(t, fail, WithDependencyWait(time.Minute, tt.policy),
+				WithHooks(Hooks{OnSkip: func(ev Event) { skipped = append(skipped, ev) }}))
+
+			if err := s.check(context.Background(), now); err != nil {
+				t.Fatal(err)
+			}
+			s.inFlight.Wait()
+			if got, want := ran(), tt.wantRan; !reflect.DeepEqual(got, want) {
+				t.Errorf("got ran %v want %v", got, want)
+			}
+			if got, want := eventStatus(store, "A"), EventStatusFailed; got != want {
+				t.Errorf("got status of A %q want %q", got, want)
+			}
+			if got, want := eventStatus(store, "B"), tt.wantStatus; got != want {
+				t.Errorf("got status of B %q want %q", got, want)
+			}
+			if tt.policy == DependencySkip {
+				if len(skipped) != 1 || skipped[0].Status != EventStatusSkippedDependency {
+					t.Errorf("got skipped %v", skipped)
+				}
+				if got, want := s.Stats().Skipped, uint64(1); got != want {
+					t.Errorf("got skipped %d want %d", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduler_checkDependsOnTimeout(t *testing.T) {
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	release := make(chan struct{})
+	s, store, ran := newDependsScheduler(t, func(ctx context.Context, e Entry) error {
+		<-release
+		return nil
+	}, WithDependencyWait(20*time.Millisecond, DependencySkip))
+
+	if err := s.check(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, want := eventStatus(store, "B"), EventStatusSkippedDependency; got != want {
+		t.Errorf("got status of B %q want %q", got, want)
+	}
+	close(release)
+	s.inFlight.Wait()
+	if got, want := ran(), []string{"A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ran %v want %v", got, want)
+	}
+}
+
+func TestScheduler_AddEntryDependencyCycle(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	s := NewScheduler(nil, store)
+
+	entryA, err := Parse("* * * * *", time.UTC, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryB, err := Parse("* * * * *", time.UTC, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryA.DependsOn, entryB.DependsOn = []string{"B"}, []string{"A"}
+	if err := s.AddEntry(ctx, entryA); err != nil {
+		t.Fatal(err)
+	}
+
+	var cycleErr *DependencyCycleError
+	if err := s.AddEntry(ctx, entryB); !errors.As(err, &cycleErr) {
+		t.Fatalf("got error %v want DependencyCycleError", err)
+	}
+
+	// replacing A without the dependency breaks the cycle
+	entryA.DependsOn = nil
+	if err := s.AddEntry(ctx, entryA); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddEntry(ctx, entryB); err != nil {
+		t.Errorf("got error %v", err)
+	}
+}
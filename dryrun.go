@@ -0,0 +1,48 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WithDryRun makes the scheduler match and dedup the entries as usual without recording events to the store or
+// calling the handlers. The events it would trigger are passed to Hooks.OnTrigger with Event.DryRun set, so that it
+// can run against a production store without suppressing the real scheduler.
+func WithDryRun() Option {
+	return func(s *Scheduler) {
+		s.dryRun = true
+	}
+}
+
+// Simulate returns the events that the entries in the store would trigger on [from, to) ordered by time. Paused
+// entries are not included. It does not consider events already recorded in the store, nor record any.
+func (s *Scheduler) Simulate(ctx context.Context, from, to time.Time) ([]Event, error) {
+	var entries []Entry
+	err := s.withLock(ctx, func() (err error) {
+		entries, err = s.store.GetEntries(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entries: %v", err)
+	}
+
+	// first minute on or after from
+	start := from.Truncate(time.Minute)
+	if start.Before(from) {
+		start = start.Add(time.Minute)
+	}
+
+	var events []Event
+	for _, e := range entries {
+		for t := e.Next(start.Add(-time.Minute)); !t.IsZero() && t.Before(to); t = e.Next(t) {
+			events = append(events, Event{Entry: e, Time: t.In(from.Location()), DryRun: true})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events, nil
+}
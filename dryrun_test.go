@@ -0,0 +1,104 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func dryRunFixture(t *testing.T) *MemStore {
+	t.Helper()
+	store := &MemStore{}
+	for _, v := range []struct{ expression, name string }{
+		{"*/15 * * * *", "ENTRY_1"},
+		{"0 1 * * *", "ENTRY_2"},
+		{"30 0-2 * * *", "ENTRY_3"},
+	} {
+		e, err := Parse(v.expression, time.UTC, v.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(context.Background(), e)
+	}
+	return store
+}
+
+func TestScheduler_dryRun(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	to := from.Add(3 * time.Hour)
+
+	// actual firing behavior
+	var mu sync.Mutex
+	var actual []Event
+	store := dryRunFixture(t)
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store, WithHooks(Hooks{
+		OnTrigger: func(ev Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			actual = append(actual, ev)
+		},
+	}))
+	for on := from; on.Before(to); on = on.Add(time.Minute) {
+		if err := s.check(ctx, on); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// dry run against a fresh store
+	var dry []Event
+	dryStore := dryRunFixture(t)
+	handled := false
+	ds := NewScheduler(func(ctx context.Context, e Entry) error {
+		handled = true
+		return nil
+	}, dryStore, WithDryRun(), WithHooks(Hooks{
+		OnTrigger: func(ev Event) { dry = append(dry, ev) },
+	}))
+	for on := from; on.Before(to); on = on.Add(time.Minute) {
+		if err := ds.check(ctx, on); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if handled {
+		t.Errorf("handler is called on dry run")
+	}
+	if got, want := len(dryStore.events), 0; got != want {
+		t.Errorf("got %d recorded events want %d", got, want)
+	}
+
+	simulated, err := ds.Simulate(ctx, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := len(actual), 12+1+3; got != want {
+		t.Fatalf("got %d actual events want %d", got, want)
+	}
+	for name, events := range map[string][]Event{"dry run": dry, "simulate": simulated} {
+		if got, want := len(events), len(actual); got != want {
+			t.Errorf("%s: got %d events want %d", name, got, want)
+			continue
+		}
+		for i := range actual {
+			if events[i].Entry.Name != actual[i].Entry.Name || !events[i].Time.Equal(actual[i].Time) || !events[i].DryRun {
+				t.Errorf("%s: got event %s at %s want %s at %s", name, events[i].Entry.Name, events[i].Time, actual[i].Entry.Name, actual[i].Time)
+			}
+		}
+	}
+
+	// events recorded by the real scheduler are deduped
+	dry = nil
+	ds = NewScheduler(nil, store, WithDryRun(), WithHooks(Hooks{
+		OnTrigger: func(ev Event) { dry = append(dry, ev) },
+	}))
+	if err := ds.check(ctx, from); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(dry), 0; got != want {
+		t.Errorf("got %d dry run events want %d", got, want)
+	}
+}
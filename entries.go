@@ -0,0 +1,154 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AddEntry adds the entry to the store while the store is locked. The scheduler reads the entries from the store on
+// every check so the entry is triggered as soon as the next minute that matches. It returns DependencyCycleError if
+// the dependencies of the entry make a cycle with the entries in the store.
+func (s *Scheduler) AddEntry(ctx context.Context, e Entry) error {
+	return s.addEntry(ctx, e, true)
+}
+
+// errEntryExists is returned by addEntry when an entry with the name is in the store and it must not be replaced
+var errEntryExists = errors.New("entry already exists")
+
+// addEntry adds the entry like AddEntry, it returns errEntryExists if replace is false and the store has an entry with
+// the name
+func (s *Scheduler) addEntry(ctx context.Context, e Entry, replace bool) error {
+	if e.Name == "" {
+		return errors.New("empty name")
+	}
+	if e.expression == "" {
+		return fmt.Errorf("entry %q is not parsed, use Parse to create an entry", e.Name)
+	}
+
+	err := s.withLock(ctx, func() error {
+		if !replace {
+			_, err := s.store.GetEntry(ctx, e.Name)
+			if err == nil || errors.As(err, &AmbiguousEntryError{}) {
+				return errEntryExists
+			}
+			if !errors.Is(err, ErrEntryNotFound) {
+				return err
+			}
+		}
+		if len(e.DependsOn) > 0 {
+			if err := s.validateDependencies(ctx, e, ""); err != nil {
+				return err
+			}
+		}
+		return s.store.AddEntry(ctx, e)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add entry %q: %w", e.Name, err)
+	}
+	s.ForceReload()
+	s.logger.Info("entry added", s.entryKV(e)...)
+
+	return nil
+}
+
+// UpdateEntry replaces the entries with the name by e while the store is locked, e.Name can differ to rename the entry.
+// Every field is taken from e so Paused and Meta of the stored entry must be copied to keep them. It returns
+// ErrEntryNotFound if there is no entry with the name and DependencyCycleError like AddEntry.
+func (s *Scheduler) UpdateEntry(ctx context.Context, name string, e Entry) error {
+	if e.Name == "" {
+		return errors.New("empty name")
+	}
+	if e.expression == "" {
+		return fmt.Errorf("entry %q is not parsed, use Parse to create an entry", e.Name)
+	}
+
+	err := s.withLock(ctx, func() error {
+		if len(e.DependsOn) > 0 {
+			if err := s.validateDependencies(ctx, e, name); err != nil {
+				return err
+			}
+		}
+		return s.store.UpdateEntry(ctx, name, e)
+	})
+	if errors.Is(err, ErrEntryNotFound) {
+		return ErrEntryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update entry %q: %w", name, err)
+	}
+	s.ForceReload()
+	s.logger.Info("entry updated", s.entryKV(e, "previous_name", name)...)
+
+	return nil
+}
+
+// RemoveEntry deletes entries with the name from the store while the store is locked. If cancelRunning is true, the
+// context of the handlers of the entry that are still running in this instance is canceled. It returns
+// ErrEntryNotFound if there is no entry with the name.
+func (s *Scheduler) RemoveEntry(ctx context.Context, name string, cancelRunning bool) error {
+	deleted := 0
+	err := s.withLock(ctx, func() error {
+		entries, err := s.store.GetEntries(ctx, IncludeInactive())
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.Name != name {
+				continue
+			}
+			if err := s.store.DeleteEntry(ctx, e); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove entry %q: %v", name, err)
+	}
+	if deleted == 0 {
+		return ErrEntryNotFound
+	}
+	s.ForceReload()
+	s.logger.Info("entry removed", "name", name)
+
+	if cancelRunning {
+		s.cancelRunning(name)
+	}
+
+	return nil
+}
+
+// validateDependencies checks the dependencies of e with the entries in the store, the entry replaced by e and the
+// entries with the name replaced (empty for none) are left out. It must be called while the store is locked.
+func (s *Scheduler) validateDependencies(ctx context.Context, e Entry, replaced string) error {
+	stored, err := s.store.GetEntries(ctx, IncludeInactive())
+	if err != nil {
+		return err
+	}
+	entries := []Entry{e}
+	for _, v := range stored {
+		if v.Name == e.Name && v.expression == e.expression && v.Location.String() == e.Location.String() ||
+			replaced != "" && v.Name == replaced {
+			continue
+		}
+		entries = append(entries, v)
+	}
+
+	return ValidateDependencies(entries)
+}
+
+// withLock runs fn while the store is locked
+func (s *Scheduler) withLock(ctx context.Context, fn func() error) error {
+	if s.store == nil {
+		return errors.New("empty store")
+	}
+	if err := s.store.Lock(ctx); err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		return fmt.Errorf("locking store failed: %v", err)
+	}
+	defer s.store.Unlock(ctx)
+
+	return fn()
+}
@@ -0,0 +1,206 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_AddEntry(t *testing.T) {
+	ctx := context.Background()
+	store := MemStore{}
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	triggered := make(chan string, 5)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		if ev, _ := EventFromContext(ctx); ev.Time.Equal(on.Add(time.Minute)) {
+			triggered <- e.Name
+		}
+		return nil
+	}, &store)
+
+	if err := s.AddEntry(ctx, Entry{Name: "ENTRY_1"}); err == nil {
+		t.Errorf("expected error for entry without expression")
+	}
+
+	// add entries while checks are running
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := s.check(ctx, on); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			entry, err := Parse("* * * * *", time.UTC, "ENTRY_"+string(rune('A'+i)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := s.AddEntry(ctx, entry); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// every added entry is triggered on the next minute
+	if err := s.check(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		select {
+		case name := <-triggered:
+			got[name] = true
+		case <-time.After(time.Second):
+			t.Fatalf("got %d triggered entries want %d", len(got), 5)
+		}
+	}
+	if got, want := len(got), 5; got != want {
+		t.Errorf("got %d distinct triggered entries want %d", got, want)
+	}
+}
+
+func TestScheduler_RemoveEntry(t *testing.T) {
+	ctx := context.Background()
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := MemStore{}
+	store.AddEntry(ctx, entry)
+
+	started := make(chan struct{})
+	done := make(chan error)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		close(started)
+		<-ctx.Done()
+		done <- ctx.Err()
+		return nil
+	}, &store)
+
+	if got, want := s.RemoveEntry(ctx, "UNKNOWN", true), ErrEntryNotFound; got != want {
+		t.Errorf("got error %v want %v", got, want)
+	}
+
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	// remove while the handler is running
+	if err := s.RemoveEntry(ctx, "ENTRY_1", true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("got error %v want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler context is not canceled")
+	}
+
+	if err := s.check(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(store.events), 1; got != want {
+		t.Errorf("got %d events want %d", got, want)
+	}
+}
+
+func TestScheduler_UpdateEntry(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	entry, err := Parse("0 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = "META"
+	store := MemStore{}
+	store.AddEntry(ctx, entry)
+	triggered := make(chan string, 1)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		triggered <- e.Name
+		return nil
+	}, &store)
+
+	if got, want := s.UpdateEntry(ctx, "UNKNOWN", entry), ErrEntryNotFound; got != want {
+		t.Errorf("got error %v want %v", got, want)
+	}
+
+	// rename and reschedule, the updated entry is triggered on the next check
+	updated, err := Parse("* * * * *", time.UTC, "ENTRY_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated.Meta = entry.Meta
+	if err := s.UpdateEntry(ctx, entry.Name, updated); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.check(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	s.inFlight.Wait()
+	select {
+	case name := <-triggered:
+		if name != "ENTRY_2" {
+			t.Errorf("got triggered %q want ENTRY_2", name)
+		}
+	default:
+		t.Error("updated entry is not triggered")
+	}
+	if got, want := len(store.entries), 1; got != want {
+		t.Fatalf("got %d entries want %d", got, want)
+	}
+	if got, want := store.entries[0].Meta, "META"; got != want {
+		t.Errorf("got meta %q want %q", got, want)
+	}
+}
+
+func TestScheduler_UpdateEntryRace(t *testing.T) {
+	ctx := context.Background()
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := Parse("*/5 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		store := MemStore{}
+		store.AddEntry(ctx, entry)
+		s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, &store)
+
+		var wg sync.WaitGroup
+		var updateErr, removeErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			updateErr = s.UpdateEntry(ctx, entry.Name, updated)
+		}()
+		go func() {
+			defer wg.Done()
+			removeErr = s.RemoveEntry(ctx, entry.Name, false)
+		}()
+		wg.Wait()
+
+		// in either order the entry is removed, an update after the removal finds nothing to update
+		if removeErr != nil {
+			t.Fatalf("[%d] got remove error %v", i, removeErr)
+		}
+		if updateErr != nil && updateErr != ErrEntryNotFound {
+			t.Fatalf("[%d] got update error %v", i, updateErr)
+		}
+		if got := len(store.entries); got != 0 {
+			t.Fatalf("[%d] got %d entries after remove want 0", i, got)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// ExecError is returned by the handler of NewExecHandler when the command fails
+type ExecError struct {
+	// ExitCode of the command, -1 if it did not exit (ex: it is killed on timeout or it could not be started)
+	ExitCode int
+	// Output is the combined stdout and stderr, truncated to the output limit (see WithExecOutputLimit)
+	Output string
+	Err    error
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("command failed with exit code %d: %v, output: %q", e.ExitCode, e.Err, e.Output)
+}
+
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// ExecOption configures NewExecHandler
+type ExecOption func(c *execConfig)
+
+type execConfig struct {
+	allowlist   map[string]bool
+	outputLimit int
+}
+
+// WithExecAllowlist only allows the commands whose executable (first element of "command") is one of executables,
+// compared as written in the meta. By default any executable is allowed.
+func WithExecAllowlist(executables ...string) ExecOption {
+	return func(c *execConfig) {
+		c.allowlist = make(map[string]bool, len(executables))
+		for _, e := range executables {
+			c.allowlist[e] = true
+		}
+	}
+}
+
+// WithExecOutputLimit sets the maximum bytes of the output kept in ExecError (default 4096)
+func WithExecOutputLimit(n int) ExecOption {
+	return func(c *execConfig) {
+		c.outputLimit = n
+	}
+}
+
+// execMeta is the Entry.Meta of NewExecHandler
+type execMeta struct {
+	Command []string          `json:"command"`
+	Dir     string            `json:"dir"`
+	Env     map[string]string `json:"env"`
+	Timeout string            `json:"timeout"`
+}
+
+// NewExecHandler creates handler that runs the command in the entry meta. The meta is a JSON object with "command" as
+// the executable and its arguments (it is not run by a shell), optional working directory "dir", "env" added to the
+// environment of the process and "timeout" (ex: `{"command": ["backup", "--full"], "dir": "/srv", "timeout": "1h"}`).
+// The command is killed when the handler context is done. Non-zero exit code is returned as ExecError with the output.
+func NewExecHandler(opts ...ExecOption) HandlerFunc {
+	c := execConfig{outputLimit: 4096}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return func(ctx context.Context, e Entry) error {
+		var meta execMeta
+		if err := json.Unmarshal([]byte(e.Meta), &meta); err != nil {
+			return fmt.Errorf("invalid exec meta of entry %q: %v", e.Name, err)
+		}
+		if len(meta.Command) == 0 || meta.Command[0] == "" {
+			return fmt.Errorf("missing command in exec meta of entry %q", e.Name)
+		}
+		if c.allowlist != nil && !c.allowlist[meta.Command[0]] {
+			return fmt.Errorf("command %q of entry %q is not allowed", meta.Command[0], e.Name)
+		}
+		if meta.Timeout != "" {
+			timeout, err := time.ParseDuration(meta.Timeout)
+			if err != nil {
+				return fmt.Errorf("invalid exec timeout of entry %q: %v", e.Name, err)
+			}
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		cmd := exec.CommandContext(ctx, meta.Command[0], meta.Command[1:]...)
+		cmd.Dir = meta.Dir
+		if len(meta.Env) > 0 {
+			cmd.Env = os.Environ()
+			for k, v := range meta.Env {
+				cmd.Env = append(cmd.Env, k+"="+v)
+			}
+		}
+		output := &limitedBuffer{limit: c.outputLimit}
+		cmd.Stdout, cmd.Stderr = output, output
+
+		if err := cmd.Run(); err != nil {
+			code := -1
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				code = exitErr.ExitCode()
+			}
+			return &ExecError{ExitCode: code, Output: output.String(), Err: err}
+		}
+
+		return nil
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written to it. The buffer is not embedded so that io.Copy does not bypass
+// Write with bytes.Buffer.ReadFrom.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.limit - b.buf.Len(); n > 0 {
+		if len(p) > n {
+			b.buf.Write(p[:n])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
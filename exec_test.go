@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewExecHandler(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "marker"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		meta     string
+		opts     []ExecOption
+		wantErr  bool
+		wantCode int
+		wantOut  string
+	}{
+		{name: "success", meta: `{"command": ["sh", "-c", "echo hello"]}`},
+		{
+			name: "dir and env",
+			meta: fmt.Sprintf(`{"command": ["sh", "-c", "test -f marker && test \"$FOO\" = bar"], "dir": %q, "env": {"FOO": "bar"}}`, dir),
+		},
+		{
+			name: "exit code", meta: `{"command": ["sh", "-c", "echo boom; exit 3"]}`,
+			wantErr: true, wantCode: 3, wantOut: "boom\n",
+		},
+		{
+			name: "output limit", meta: `{"command": ["sh", "-c", "echo 0123456789; exit 1"]}`, opts: []ExecOption{WithExecOutputLimit(4)},
+			wantErr: true, wantCode: 1, wantOut: "0123",
+		},
+		{name: "timeout", meta: `{"command": ["sleep", "5"], "timeout": "50ms"}`, wantErr: true, wantCode: -1},
+		{name: "not found", meta: `{"command": ["/no/such/command"]}`, wantErr: true, wantCode: -1},
+		{name: "missing command", meta: `{"dir": "/"}`, wantErr: true},
+		{name: "invalid meta", meta: `backup`, wantErr: true},
+		{name: "not allowed", meta: `{"command": ["rm", "-rf", "/tmp/x"]}`, opts: []ExecOption{WithExecAllowlist("sh")}, wantErr: true},
+		{name: "allowed", meta: `{"command": ["sh", "-c", "true"]}`, opts: []ExecOption{WithExecAllowlist("sh")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry.Meta = tt.meta
+
+			start := time.Now()
+			err = NewExecHandler(tt.opts...)(context.Background(), entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v wantErr %t", err, tt.wantErr)
+			}
+			if d := time.Since(start); d > 2*time.Second {
+				t.Errorf("handler returned after %s", d)
+			}
+			var execErr *ExecError
+			if !errors.As(err, &execErr) {
+				if tt.wantCode != 0 {
+					t.Fatalf("got error %v want ExecError", err)
+				}
+				return
+			}
+			if got, want := execErr.ExitCode, tt.wantCode; got != want {
+				t.Errorf("got exit code %d want %d", got, want)
+			}
+			if got, want := execErr.Output, tt.wantOut; got != want {
+				t.Errorf("got output %q want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store on a JSON file, ex: for a CLI tool run by systemd. The entries and events are kept in memory
+// like MemStore, they are loaded from the file by Initialize and every change is written to a temporary file that
+// replaces the file so that a crash never leaves a partially written file.
+//
+// Lock is only a mutex of the process. Running more than one process on the same file is not supported, the last
+// write of a process overwrites the changes of the others. Use SqlStore to run multiple instances.
+type FileStore struct {
+	path      string
+	retention time.Duration
+	reset     bool
+	now       func() time.Time
+
+	mu  sync.Mutex // serializes the changes and writes of the file
+	mem MemStore
+}
+
+// FileStoreOption configures the FileStore
+type FileStoreOption func(s *FileStore)
+
+// WithFileRetention deletes the events older than d when the file is written so that the file does not grow forever
+func WithFileRetention(d time.Duration) FileStoreOption {
+	return func(s *FileStore) {
+		s.retention = d
+	}
+}
+
+// WithFileResetOnCorruption makes Initialize start with an empty store when the file can not be decoded instead of
+// failing. The corrupted file is kept as the path with ".corrupted" suffix.
+func WithFileResetOnCorruption() FileStoreOption {
+	return func(s *FileStore) {
+		s.reset = true
+	}
+}
+
+// NewFileStore creates the store of the file on path, the file is created by Initialize if not exists
+func NewFileStore(path string, opts ...FileStoreOption) *FileStore {
+	s := &FileStore{path: path, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// fileDocument is the content of the file
+type fileDocument struct {
+	Entries []fileEntry `json:"entries"`
+	Events  []fileEvent `json:"events"`
+}
+
+// fileEntry is the stored entry
+type fileEntry struct {
+	Name           string        `json:"name"`
+	Expression     string        `json:"expression"`
+	Location       string        `json:"location"`
+	Meta           string        `json:"meta,omitempty"`
+	Paused         bool          `json:"paused,omitempty"`
+	IgnoreBlackout bool          `json:"ignore_blackout,omitempty"`
+	Timeout        time.Duration `json:"timeout,omitempty"`
+	DependsOn      []string      `json:"depends_on,omitempty"`
+}
+
+func newFileEntry(e Entry) fileEntry {
+	return fileEntry{
+		Name:           e.Name,
+		Expression:     e.expression,
+		Location:       e.Location.String(),
+		Meta:           e.Meta,
+		Paused:         e.Paused,
+		IgnoreBlackout: e.IgnoreBlackout,
+		Timeout:        e.Timeout,
+		DependsOn:      e.DependsOn,
+	}
+}
+
+func (v fileEntry) entry() (Entry, error) {
+	loc, err := time.LoadLocation(v.Location)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to load location %q: %v", v.Location, err)
+	}
+	entry, err := ParseStored(v.Expression, loc, v.Name)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to parse expression:%q loc:%q name:%q: %v", v.Expression, loc, v.Name, err)
+	}
+	entry.Meta = v.Meta
+	entry.Paused = v.Paused
+	entry.IgnoreBlackout = v.IgnoreBlackout
+	entry.Timeout = v.Timeout
+	entry.DependsOn = v.DependsOn
+
+	return entry, nil
+}
+
+// fileEvent is the stored event
+type fileEvent struct {
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Location   string    `json:"location"`
+	Meta       string    `json:"meta,omitempty"`
+	Time       time.Time `json:"time"`
+	Manual     bool      `json:"manual,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	FiredBy    string    `json:"fired_by,omitempty"`
+}
+
+func newFileEvent(e Event) fileEvent {
+	return fileEvent{
+		Name:       e.Entry.Name,
+		Expression: e.Entry.expression,
+		Location:   e.Entry.Location.String(),
+		Meta:       e.Entry.Meta,
+		Time:       e.Time.UTC(),
+		Manual:     e.Manual,
+		Status:     e.Status,
+		FiredBy:    e.FiredBy,
+	}
+}
+
+func (v fileEvent) event() (Event, error) {
+	entry, err := fileEntry{Name: v.Name, Expression: v.Expression, Location: v.Location, Meta: v.Meta}.entry()
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{Entry: entry, Time: v.Time.In(entry.Location), Manual: v.Manual, Status: v.Status, FiredBy: v.FiredBy}, nil
+}
+
+// Initialize loads the file, it writes an empty store when the file does not exist
+func (s *FileStore) Initialize(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, events, err := s.load()
+	if errors.Is(err, fs.ErrNotExist) {
+		s.set(nil, nil)
+		return s.save()
+	}
+	if err != nil && s.reset {
+		if err := os.Rename(s.path, s.path+".corrupted"); err != nil {
+			return fmt.Errorf("failed moving corrupted file %s: %v", s.path, err)
+		}
+		s.set(nil, nil)
+		return s.save()
+	}
+	if err != nil {
+		return err
+	}
+	s.set(entries, events)
+
+	return nil
+}
+
+// set replaces the entries and events in memory
+func (s *FileStore) set(entries []Entry, events []Event) {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	s.mem.setEntries(entries)
+	s.mem.events = events
+}
+
+// snapshot returns a copy of the entries and events in memory
+func (s *FileStore) snapshot() ([]Entry, []Event) {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	return append([]Entry(nil), s.mem.entries...), append([]Event(nil), s.mem.events...)
+}
+
+// load reads the entries and events of the file
+func (s *FileStore) load() ([]Entry, []Event, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc fileDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("file %s is corrupted, fix or remove it (see WithFileResetOnCorruption): %v", s.path, err)
+	}
+
+	entries := make([]Entry, 0, len(doc.Entries))
+	for _, v := range doc.Entries {
+		entry, err := v.entry()
+		if err != nil {
+			return nil, nil, fmt.Errorf("file %s is corrupted, invalid entry: %v", s.path, err)
+		}
+		entries = append(entries, entry)
+	}
+	events := make([]Event, 0, len(doc.Events))
+	for _, v := range doc.Events {
+		e, err := v.event()
+		if err != nil {
+			return nil, nil, fmt.Errorf("file %s is corrupted, invalid event: %v", s.path, err)
+		}
+		events = append(events, e)
+	}
+
+	return entries, events, nil
+}
+
+// save writes the entries and events to a temporary file in the same directory and renames it to the file
+func (s *FileStore) save() error {
+	if s.retention > 0 {
+		if err := s.mem.DeleteEvents(context.Background(), s.now().Add(-s.retention)); err != nil {
+			return err
+		}
+	}
+
+	entries, events := s.snapshot()
+	doc := fileDocument{Entries: make([]fileEntry, 0, len(entries)), Events: make([]fileEvent, 0, len(events))}
+	for _, e := range entries {
+		doc.Entries = append(doc.Entries, newFileEntry(e))
+	}
+	for _, e := range events {
+		doc.Events = append(doc.Events, newFileEvent(e))
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %v", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed creating temporary file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed writing %s: %v", f.Name(), err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed syncing %s: %v", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed closing %s: %v", f.Name(), err)
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("failed replacing %s: %v", s.path, err)
+	}
+
+	return nil
+}
+
+// change applies fn to the store in memory and writes the file. The change is reverted when the file can not be
+// written. The file is written even if fn fails since some of the events of AddEvents may be added.
+func (s *FileStore) change(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, events := s.snapshot()
+	fnErr := fn()
+	if err := s.save(); err != nil {
+		s.set(entries, events)
+		return err
+	}
+
+	return fnErr
+}
+
+// Health checks that the file exists
+func (s *FileStore) Health(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := os.Stat(s.path)
+	return err
+}
+
+// Lock locks the mutex of the store, it does not lock the file
+func (s *FileStore) Lock(ctx context.Context) error {
+	return s.mem.Lock(ctx)
+}
+
+func (s *FileStore) Unlock(ctx context.Context) error {
+	return s.mem.Unlock(ctx)
+}
+
+func (s *FileStore) GetEntries(ctx context.Context, opts ...EntriesOption) ([]Entry, error) {
+	return s.mem.GetEntries(ctx, opts...)
+}
+
+func (s *FileStore) GetEntry(ctx context.Context, name string) (Entry, error) {
+	return s.mem.GetEntry(ctx, name)
+}
+
+func (s *FileStore) AddEntry(ctx context.Context, entry Entry) error {
+	if err := validateStoredEntry(entry); err != nil {
+		return err
+	}
+	return s.change(ctx, func() error { return s.mem.AddEntry(ctx, entry) })
+}
+
+func (s *FileStore) DeleteEntry(ctx context.Context, entry Entry) error {
+	return s.change(ctx, func() error { return s.mem.DeleteEntry(ctx, entry) })
+}
+
+func (s *FileStore) UpdateEntry(ctx context.Context, name string, updated Entry) error {
+	if err := validateStoredEntry(updated); err != nil {
+		return err
+	}
+	return s.change(ctx, func() error { return s.mem.UpdateEntry(ctx, name, updated) })
+}
+
+func (s *FileStore) SetEntryActive(ctx context.Context, name string, active bool) error {
+	return s.change(ctx, func() error { return s.mem.SetEntryActive(ctx, name, active) })
+}
+
+func (s *FileStore) AddEvent(ctx context.Context, e Event) error {
+	if err := validateStoredEntry(e.Entry); err != nil {
+		return err
+	}
+	return s.change(ctx, func() error { return s.mem.AddEvent(ctx, e) })
+}
+
+// AddEvents implements EventsAdder, the file is written once for the events
+func (s *FileStore) AddEvents(ctx context.Context, events []Event) error {
+	return s.change(ctx, func() error {
+		var errs EventErrors
+		for i, e := range events {
+			err := validateStoredEntry(e.Entry)
+			if err == nil {
+				err = s.mem.AddEvent(ctx, e)
+			}
+			if err != nil {
+				if errs == nil {
+					errs = make(EventErrors, len(events))
+				}
+				errs[i] = err
+			}
+		}
+		if errs == nil {
+			return nil
+		}
+		return errs
+	})
+}
+
+func (s *FileStore) GetEvents(ctx context.Context, from, to time.Time) ([]Event, error) {
+	return s.mem.GetEvents(ctx, from, to)
+}
+
+func (s *FileStore) QueryEvents(ctx context.Context, from, to time.Time, opts ...EventsOption) ([]Event, error) {
+	return s.mem.QueryEvents(ctx, from, to, opts...)
+}
+
+func (s *FileStore) GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]Event, error) {
+	return s.mem.GetEventsByName(ctx, name, from, to)
+}
+
+// GetEventsForEntries implements EntryEventsGetter
+func (s *FileStore) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]Event, error) {
+	return s.mem.GetEventsForEntries(ctx, names, from, to)
+}
+
+func (s *FileStore) CountEvents(ctx context.Context, from, to time.Time) (int64, error) {
+	return s.mem.CountEvents(ctx, from, to)
+}
+
+func (s *FileStore) LastEvent(ctx context.Context, name string, opts ...EventsOption) (Event, bool, error) {
+	return s.mem.LastEvent(ctx, name, opts...)
+}
+
+func (s *FileStore) DeleteEvents(ctx context.Context, until time.Time) error {
+	return s.change(ctx, func() error { return s.mem.DeleteEvents(ctx, until) })
+}
@@ -0,0 +1,141 @@
+package cron
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_ Store             = (*FileStore)(nil)
+	_ EntryEventsGetter = (*FileStore)(nil)
+	_ EventsAdder       = (*FileStore)(nil)
+)
+
+func TestCron_FileStore(t *testing.T) {
+	storeTest(t, NewFileStore(filepath.Join(t.TempDir(), "cron.json")))
+}
+
+func TestFileStore_reload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cron.json")
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	open := func(opts ...FileStoreOption) *FileStore {
+		t.Helper()
+		store := NewFileStore(path, opts...)
+		store.now = func() time.Time { return on.Add(time.Hour) }
+		if err := store.Initialize(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	store := open()
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = "META"
+	if err := store.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetEntryActive(ctx, entry.Name, false); err != nil {
+		t.Fatal(err)
+	}
+	events := []Event{{Entry: entry, Time: on}, {Entry: entry, Time: on.Add(time.Minute)}, {Entry: entry, Time: on.Add(time.Hour)}}
+	if err := store.AddEvents(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteEvents(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	store = open()
+	got, err := store.GetEntry(ctx, entry.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Expression() != entry.Expression() || got.Meta != entry.Meta || !got.Paused {
+		t.Errorf("got entry %+v want paused %+v", got, entry)
+	}
+	if n, err := store.CountEvents(ctx, on, on.Add(2*time.Hour)); err != nil || n != 2 {
+		t.Errorf("got count %d, %v after reload want 2", n, err)
+	}
+	if err := store.AddEvent(ctx, events[1]); err != ErrAlreadyTriggered {
+		t.Errorf("got error %v want %v", err, ErrAlreadyTriggered)
+	}
+
+	// the events older than the retention are deleted on write
+	store = open(WithFileRetention(30 * time.Minute))
+	if err := store.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	store = open()
+	if n, err := store.CountEvents(ctx, on, on.Add(2*time.Hour)); err != nil || n != 1 {
+		t.Errorf("got count %d, %v after retention want 1", n, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("got files %v want only the store file", files)
+	}
+}
+
+func TestFileStore_corrupted(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cron.json")
+	if err := os.WriteFile(path, []byte(`{"entries": [`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewFileStore(path).Initialize(ctx)
+	if err == nil || !strings.Contains(err.Error(), "is corrupted") {
+		t.Fatalf("got error %v want corrupted file", err)
+	}
+
+	store := NewFileStore(path, WithFileResetOnCorruption())
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := store.GetEntries(ctx, IncludeInactive()); err != nil || len(entries) != 0 {
+		t.Errorf("got entries %v, %v want empty store", entries, err)
+	}
+	if data, err := os.ReadFile(path + ".corrupted"); err != nil || string(data) != `{"entries": [` {
+		t.Errorf("got corrupted file %q, %v want the previous content", data, err)
+	}
+}
+
+func TestFileStore_neverMatchingEntry(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cron.json")
+	// saved before Parse rejected the expressions that never match
+	doc := `{"entries": [
+		{"name": "ENTRY_1", "expression": "0 0 30 2 *", "location": "UTC"},
+		{"name": "ENTRY_2", "expression": "* * * * *", "location": "UTC"}
+	]}`
+	if err := os.WriteFile(path, []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewFileStore(path)
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := store.GetEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got entries %v want 2", entries)
+	}
+	if entries[0].Expression() != "0 0 30 2 *" || entries[0].IsSatisfiable() {
+		t.Errorf("got entry %v want the entry that never matches", entries[0])
+	}
+}
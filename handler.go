@@ -0,0 +1,100 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Middleware wraps a handler to add behavior around it (ex: logging, metrics, panic recovery). A middleware can
+// short-circuit by not calling next.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type metaHandler struct {
+	key, value string
+	fn         HandlerFunc
+}
+
+// Handle registers fn to handle triggered entries with the given name. It replaces previously registered handler
+// with the same name. It is safe to call Handle while the scheduler is running.
+func (s *Scheduler) Handle(name string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[name] = fn
+}
+
+// HandleMeta registers fn to handle triggered entries which Meta is a JSON object containing key with the given value
+// (ex: `{"type":"report"}`). Handlers registered with Handle take precedence, meta handlers are checked in the order
+// they are registered. It is safe to call HandleMeta while the scheduler is running.
+func (s *Scheduler) HandleMeta(key, value string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.metaHandlers = append(s.metaHandlers, metaHandler{key: key, value: value, fn: fn})
+}
+
+// Use adds middlewares that wrap every handler. Middlewares run in the order they are registered, the first one
+// registered is the outermost. It is safe to call Use while the scheduler is running.
+func (s *Scheduler) Use(mw ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middlewares = append(s.middlewares, mw...)
+}
+
+// handlerFor finds handler for an entry wrapped with the middlewares.
+func (s *Scheduler) handlerFor(e Entry) (HandlerFunc, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fn, err := s.lookupHandler(e)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		fn = s.middlewares[i](fn)
+	}
+
+	return fn, nil
+}
+
+// lookupHandler looks up handler registered by name, then by meta and then falls back to the default handler.
+// Caller must hold s.mu
+func (s *Scheduler) lookupHandler(e Entry) (HandlerFunc, error) {
+	if fn, ok := s.handlers[e.Name]; ok {
+		return fn, nil
+	}
+
+	if len(s.metaHandlers) > 0 && e.Meta != "" {
+		var meta map[string]interface{}
+		if err := json.Unmarshal([]byte(e.Meta), &meta); err == nil {
+			for _, h := range s.metaHandlers {
+				if v, ok := meta[h.key]; ok && v == h.value {
+					return h.fn, nil
+				}
+			}
+		}
+	}
+
+	if s.handler != nil {
+		return s.handler, nil
+	}
+
+	return nil, fmt.Errorf("no handler for entry %q", e.Name)
+}
+
+// Recover is a middleware that recovers panic in the handler and returns it as an error.
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, e Entry) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+
+			return next(ctx, e)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduler_handlerFor(t *testing.T) {
+	var called string
+	handlerFn := func(name string) HandlerFunc {
+		return func(ctx context.Context, e Entry) error {
+			called = name
+			return nil
+		}
+	}
+
+	parse := func(name, meta string) Entry {
+		e, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Meta = meta
+		return e
+	}
+
+	s := NewScheduler(handlerFn("default"), &MemStore{})
+	s.Handle("ENTRY_1", handlerFn("name"))
+	s.HandleMeta("type", "report", handlerFn("meta report"))
+	s.HandleMeta("type", "cleanup", handlerFn("meta cleanup"))
+
+	tests := []struct {
+		name  string
+		entry Entry
+		want  string
+	}{
+		{name: "exact name", entry: parse("ENTRY_1", `{"type":"report"}`), want: "name"},
+		{name: "meta", entry: parse("ENTRY_2", `{"type":"report"}`), want: "meta report"},
+		{name: "second meta", entry: parse("ENTRY_2", `{"type":"cleanup"}`), want: "meta cleanup"},
+		{name: "meta value not match", entry: parse("ENTRY_2", `{"type":"other"}`), want: "default"},
+		{name: "meta not json", entry: parse("ENTRY_2", "META"), want: "default"},
+		{name: "fallback", entry: parse("ENTRY_3", ""), want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = ""
+			fn, err := s.handlerFor(tt.entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fn(context.Background(), tt.entry); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := called, tt.want; got != want {
+				t.Errorf("got handler %q want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestScheduler_handlerForMissing(t *testing.T) {
+	s := NewScheduler(nil, &MemStore{})
+	s.Handle("ENTRY_1", func(ctx context.Context, e Entry) error { return nil })
+
+	e, err := Parse("* * * * *", time.UTC, "ENTRY_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.handlerFor(e)
+	if got, want := err, `no handler for entry "ENTRY_2"`; got == nil || got.Error() != want {
+		t.Errorf("got error %v want %q", got, want)
+	}
+}
+
+func TestScheduler_Use(t *testing.T) {
+	var calls []string
+	middleware := func(name string, next bool) Middleware {
+		return func(fn HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, e Entry) error {
+				calls = append(calls, name)
+				if !next {
+					return errors.New("short-circuit by " + name)
+				}
+				return fn(ctx, e)
+			}
+		}
+	}
+
+	e, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("order", func(t *testing.T) {
+		calls = nil
+		s := NewScheduler(func(ctx context.Context, e Entry) error {
+			calls = append(calls, "handler")
+			return nil
+		}, &MemStore{})
+		s.Use(middleware("first", true), middleware("second", true))
+		s.Use(middleware("third", true))
+
+		fn, err := s.handlerFor(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fn(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := calls, []string{"first", "second", "third", "handler"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v want %v", got, want)
+		}
+	})
+
+	t.Run("short-circuit", func(t *testing.T) {
+		calls = nil
+		s := NewScheduler(nil, &MemStore{})
+		s.Handle("ENTRY_1", func(ctx context.Context, e Entry) error {
+			calls = append(calls, "handler")
+			return nil
+		})
+		s.Use(middleware("first", true), middleware("second", false), middleware("third", true))
+
+		fn, err := s.handlerFor(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = fn(context.Background(), e)
+		if got, want := err, "short-circuit by second"; got == nil || got.Error() != want {
+			t.Errorf("got error %v want %q", got, want)
+		}
+		if got, want := calls, []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v want %v", got, want)
+		}
+	})
+}
+
+func TestRecover(t *testing.T) {
+	fn := Recover()(func(ctx context.Context, e Entry) error {
+		panic("boom")
+	})
+
+	err := fn(context.Background(), Entry{Name: "ENTRY_1"})
+	if got, want := err, "panic: boom"; got == nil || got.Error() != want {
+		t.Errorf("got error %v want %q", got, want)
+	}
+}
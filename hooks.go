@@ -0,0 +1,96 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+)
+
+// Hooks are callbacks on the lifecycle of a triggered entry. They are called synchronously, a panic in a hook is
+// recovered and sent to Scheduler.ErrCh. Nil callbacks are skipped.
+type Hooks struct {
+	// OnTrigger is called when the event is recorded and the handler is about to be started
+	OnTrigger func(ev Event)
+	// OnStart is called from the handler go routine right before the handler is called
+	OnStart func(ev Event)
+	// OnComplete is called when the handler finished without error
+	OnComplete func(ev Event, d time.Duration)
+	// OnError is called when the handler returned an error
+	OnError func(ev Event, d time.Duration, err error)
+	// OnSkip is called when the entry matches but the event is already triggered (ex: by another instance) or it is
+	// within a blackout window (Event.Status is EventStatusSuppressedBlackout). It is also called from the handler go
+	// routine when a dependent entry is skipped (Event.Status is EventStatusSkippedDependency).
+	OnSkip func(ev Event)
+}
+
+// WithHooks registers lifecycle hooks. It can be given multiple times, hooks are called in the order they are given.
+func WithHooks(h Hooks) Option {
+	return func(s *Scheduler) {
+		s.hooks = append(s.hooks, h)
+	}
+}
+
+func (s *Scheduler) onTrigger(ev Event) {
+	for _, h := range s.hooks {
+		if h.OnTrigger != nil {
+			s.callHook("OnTrigger", func() { h.OnTrigger(ev) })
+		}
+	}
+}
+
+func (s *Scheduler) onStart(ev Event) {
+	for _, h := range s.hooks {
+		if h.OnStart != nil {
+			s.callHook("OnStart", func() { h.OnStart(ev) })
+		}
+	}
+}
+
+func (s *Scheduler) onComplete(ev Event, d time.Duration) {
+	for _, h := range s.hooks {
+		if h.OnComplete != nil {
+			s.callHook("OnComplete", func() { h.OnComplete(ev, d) })
+		}
+	}
+}
+
+func (s *Scheduler) onError(ev Event, d time.Duration, err error) {
+	for _, h := range s.hooks {
+		if h.OnError != nil {
+			s.callHook("OnError", func() { h.OnError(ev, d, err) })
+		}
+	}
+}
+
+func (s *Scheduler) onSkip(ev Event) {
+	for _, h := range s.hooks {
+		if h.OnSkip != nil {
+			s.callHook("OnSkip", func() { h.OnSkip(ev) })
+		}
+	}
+}
+
+// WithHeartbeat calls fn at the end of every successful check with the checked minute and the number of triggered
+// entries, including when nothing matches (ex: to alert on a stalled scheduler). In dry run mode matched is the number
+// of entries that would be triggered.
+func WithHeartbeat(fn func(t time.Time, matched int)) Option {
+	return func(s *Scheduler) {
+		s.heartbeatFn = fn
+	}
+}
+
+func (s *Scheduler) onHeartbeat(t time.Time, matched int) {
+	if s.heartbeatFn != nil {
+		s.callHook("Heartbeat", func() { s.heartbeatFn(t, matched) })
+	}
+}
+
+// callHook recovers panic of a hook so that it does not break the scheduler
+func (s *Scheduler) callHook(name string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.log(fmt.Errorf("hook %s panic: %v", name, r))
+		}
+	}()
+
+	fn()
+}
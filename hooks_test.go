@@ -0,0 +1,125 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_hooks(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	store := &MemStore{}
+	var entries []Entry
+	for _, name := range []string{"ENTRY_1", "ENTRY_2", "ENTRY_3"} {
+		e, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(ctx, e)
+		entries = append(entries, e)
+	}
+	// ENTRY_3 is already triggered by another instance
+	store.AddEvent(ctx, Event{Entry: entries[2], Time: now})
+
+	handler := func(ctx context.Context, e Entry) error {
+		if e.Name == "ENTRY_2" {
+			return errors.New("failed")
+		}
+		return nil
+	}
+
+	var (
+		mu    sync.Mutex
+		calls = make(map[string][]string)
+		wg    sync.WaitGroup
+	)
+	record := func(hook string, ev Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[hook] = append(calls[hook], ev.Entry.Name)
+		if !ev.Time.Equal(now) {
+			t.Errorf("%s got event time %s want %s", hook, ev.Time, now)
+		}
+	}
+
+	wg.Add(2) // ENTRY_1 and ENTRY_2 handlers
+	hooks := Hooks{
+		OnTrigger: func(ev Event) { record("OnTrigger", ev) },
+		OnStart:   func(ev Event) { record("OnStart", ev) },
+		OnComplete: func(ev Event, d time.Duration) {
+			record("OnComplete", ev)
+			wg.Done()
+		},
+		OnError: func(ev Event, d time.Duration, err error) {
+			record("OnError", ev)
+			wg.Done()
+		},
+		OnSkip: func(ev Event) { record("OnSkip", ev) },
+	}
+	panicHooks := Hooks{
+		OnTrigger: func(ev Event) { panic("hook panic") },
+	}
+
+	s := NewScheduler(handler, store, WithHooks(panicHooks), WithHooks(hooks))
+	if err := s.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	for hook, want := range map[string][]string{
+		"OnTrigger":  {"ENTRY_1", "ENTRY_2"},
+		"OnStart":    {"ENTRY_1", "ENTRY_2"},
+		"OnComplete": {"ENTRY_1"},
+		"OnError":    {"ENTRY_2"},
+		"OnSkip":     {"ENTRY_3"},
+	} {
+		got := calls[hook]
+		if hook == "OnStart" && len(got) == 2 && got[0] > got[1] {
+			got[0], got[1] = got[1], got[0] // handlers run concurrently
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %s calls %v want %v", hook, got, want)
+		}
+	}
+}
+
+func TestScheduler_heartbeat(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	for _, expression := range []string{"0 * * * *", "0 0 * * *", "30 * * * *"} {
+		e, err := Parse(expression, time.UTC, expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(ctx, e)
+	}
+
+	type beat struct {
+		t       time.Time
+		matched int
+	}
+	var beats []beat
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store, WithHeartbeat(func(t time.Time, matched int) {
+		beats = append(beats, beat{t: t, matched: matched})
+	}))
+
+	midnight := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	want := []beat{
+		{t: midnight, matched: 2},
+		{t: midnight.Add(time.Minute), matched: 0},
+		{t: midnight.Add(30 * time.Minute), matched: 1},
+	}
+	for _, b := range want {
+		if err := s.check(ctx, b.t.Add(10*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(beats, want) {
+		t.Errorf("got heartbeats %v want %v", beats, want)
+	}
+}
@@ -0,0 +1,44 @@
+package cron
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// IdempotencyKey returns the idempotency key of the scheduled event of the entry with the name on t. It only depends
+// on the name and the minute of t (regardless of its location), so every instance and every restart (ex: a claim run
+// again with AtLeastOnce delivery) gets the same key for the same run. Handlers can use it to dedupe the work they
+// enqueue downstream.
+func IdempotencyKey(name string, t time.Time) string {
+	return idempotencyKey(name, t.Truncate(time.Minute).UTC().Format(time.RFC3339))
+}
+
+// manualIdempotencyKey returns a key of the event of TriggerNow, it has a random nonce so that it is not the same as
+// the key of the scheduled event on the same minute or another manual trigger
+func manualIdempotencyKey(name string, t time.Time) string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	return idempotencyKey(name, t.UTC().Format(time.RFC3339Nano), "manual", hex.EncodeToString(nonce))
+}
+
+func idempotencyKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// IdempotencyKeyFromContext returns the idempotency key of the triggered event from the context given to the handler,
+// see Event.IdempotencyKey
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	ev, ok := EventFromContext(ctx)
+	if !ok || ev.IdempotencyKey == "" {
+		return "", false
+	}
+	return ev.IdempotencyKey, true
+}
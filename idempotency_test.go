@@ -0,0 +1,115 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	key := IdempotencyKey("ENTRY_1", on)
+
+	for _, tt := range []struct {
+		name string
+		t    time.Time
+	}{
+		{name: "jitter", t: on.Add(10*time.Second + time.Millisecond)},
+		{name: "location", t: on.In(jakarta)},
+	} {
+		if got := IdempotencyKey("ENTRY_1", tt.t); got != key {
+			t.Errorf("%s: got key %q want %q", tt.name, got, key)
+		}
+	}
+	if IdempotencyKey("ENTRY_1", on.Add(time.Minute)) == key {
+		t.Error("got the same key on the next minute")
+	}
+	if IdempotencyKey("ENTRY_2", on) == key {
+		t.Error("got the same key of another entry")
+	}
+}
+
+// keyHandler sends the idempotency key given to the handler
+func keyHandler(keys chan<- string) HandlerFunc {
+	return func(ctx context.Context, e Entry) error {
+		key, _ := IdempotencyKeyFromContext(ctx)
+		keys <- key
+		return nil
+	}
+}
+
+func TestScheduler_idempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IdempotencyKey("ENTRY_1", on)
+
+	// a scheduler restarted with a new store gets the same key
+	keys := make(chan string, 4)
+	for i := 0; i < 2; i++ {
+		store := &MemStore{}
+		store.AddEntry(ctx, entry)
+		s := NewScheduler(keyHandler(keys), store)
+		if err := s.check(ctx, on.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		s.inFlight.Wait()
+		if got := <-keys; got != want {
+			t.Errorf("[%d] got key %q want %q", i, got, want)
+		}
+	}
+
+	// manual triggers get distinct keys
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+	s := NewScheduler(keyHandler(keys), store)
+	seen := map[string]bool{want: true}
+	for i := 0; i < 2; i++ {
+		s.now = func() time.Time { return on.Add(time.Duration(i) * time.Second) }
+		if err := s.TriggerNow(ctx, "ENTRY_1"); err != nil {
+			t.Fatal(err)
+		}
+		s.inFlight.Wait()
+		got := <-keys
+		if got == "" || seen[got] {
+			t.Errorf("[%d] got manual key %q, seen %v", i, got, seen)
+		}
+		seen[got] = true
+	}
+}
+
+func TestScheduler_idempotencyKeyRecoveredClaim(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	keys := make(chan string, 2)
+	s1 := NewScheduler(keyHandler(keys), crashStore{store}, WithDeliveryMode(AtLeastOnce, time.Minute))
+	if err := s1.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	s1.inFlight.Wait()
+
+	// the claim is run again after a restart with the same key
+	s2 := NewScheduler(keyHandler(keys), store, WithDeliveryMode(AtLeastOnce, time.Minute))
+	s2.now = func() time.Time { return on.Add(time.Hour) }
+	s2.recoverClaims(ctx)
+	s2.inFlight.Wait()
+
+	first, second := <-keys, <-keys
+	if want := IdempotencyKey("ENTRY_1", on); first != want || second != want {
+		t.Errorf("got keys %q and %q want %q", first, second, want)
+	}
+}
@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// LiveInstanceDuration is how long an instance is considered alive after its last heartbeat
+var LiveInstanceDuration = 2 * time.Minute
+
+// Instance is a scheduler instance recorded by its heartbeat, see WithInstance
+type Instance struct {
+	ID       string    `json:"id"`
+	Hostname string    `json:"hostname"`
+	Version  string    `json:"version"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Alive reports whether the instance was seen within LiveInstanceDuration before now
+func (i Instance) Alive(now time.Time) bool {
+	return now.Sub(i.LastSeen) < LiveInstanceDuration
+}
+
+// InstanceStore is an optional interface of a Store that records the heartbeat of the scheduler instances. The methods
+// are called without Lock.
+type InstanceStore interface {
+	// Heartbeat inserts or updates the instance with the same ID
+	Heartbeat(ctx context.Context, inst Instance) error
+	// ListInstances returns the recorded instances including the ones that are no longer alive
+	ListInstances(ctx context.Context) ([]Instance, error)
+}
+
+// WithInstance records the instance in the store on every check (the store must implement InstanceStore) and sets
+// Event.FiredBy of the events triggered by this instance. id must be unique among the instances sharing the store,
+// version is the version of the application.
+func WithInstance(id, version string) Option {
+	return func(s *Scheduler) {
+		hostname, _ := os.Hostname()
+		s.instance = Instance{ID: id, Hostname: hostname, Version: version}
+	}
+}
+
+// WithMinimumInstances logs an error on every check when fewer than n instances are alive (ex: 2 to be warned when
+// this is the only instance left). It requires WithInstance.
+func WithMinimumInstances(n int) Option {
+	return func(s *Scheduler) {
+		s.minInstances = n
+	}
+}
+
+// recordInstance records the heartbeat of the instance and checks the number of live instances. Errors are logged,
+// they do not fail the check.
+func (s *Scheduler) recordInstance(ctx context.Context) {
+	store, ok := s.store.(InstanceStore)
+	if s.instance.ID == "" || !ok {
+		return
+	}
+
+	inst := s.instance
+	inst.LastSeen = s.now()
+	if err := store.Heartbeat(ctx, inst); err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		s.logger.Error("failed to record heartbeat", "instance", inst.ID, "error", err)
+		return
+	}
+	if s.minInstances <= 0 {
+		return
+	}
+
+	instances, err := store.ListInstances(ctx)
+	if err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		s.logger.Error("failed to list instances", "instance", inst.ID, "error", err)
+		return
+	}
+	alive := 0
+	for _, i := range instances {
+		if i.Alive(inst.LastSeen) {
+			alive++
+		}
+	}
+	if alive < s.minInstances {
+		s.logger.Error("fewer live instances than the minimum", "instance", inst.ID, "alive", alive, "minimum", s.minInstances)
+	}
+}
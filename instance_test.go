@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// errorLogger records the messages of Error
+type errorLogger struct {
+	nopLogger
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *errorLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, msg)
+}
+
+func TestScheduler_instance(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	handler := func(ctx context.Context, e Entry) error { return nil }
+	s1 := NewScheduler(handler, store, WithInstance("instance-1", "v1.0.0"))
+	s1.now = func() time.Time { return now }
+	logger := &errorLogger{}
+	s2 := NewScheduler(handler, store, WithInstance("instance-2", "v1.0.0"), WithMinimumInstances(2),
+		WithLogger(logger))
+	s2.now = func() time.Time { return now }
+
+	if err := s1.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	instances, err := store.ListInstances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(instances), 2; got != want {
+		t.Fatalf("got %d instances want %d", got, want)
+	}
+	if got, want := instances[0], (Instance{ID: "instance-1", Hostname: s1.instance.Hostname, Version: "v1.0.0", LastSeen: now}); got != want {
+		t.Errorf("got instance %+v want %+v", got, want)
+	}
+	if got, want := store.events[0].FiredBy, "instance-1"; got != want {
+		t.Errorf("got event fired by %q want %q", got, want)
+	}
+	if got, want := len(logger.errors), 0; got != want {
+		t.Errorf("got errors %q with 2 live instances", logger.errors)
+	}
+
+	// instance-1 stops, instance-2 is the only live instance
+	now = now.Add(LiveInstanceDuration)
+	if err := s2.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := logger.errors, []string{"fewer live instances than the minimum"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got errors %q want %q", got, want)
+	}
+	if got, want := store.events[1].FiredBy, "instance-2"; got != want {
+		t.Errorf("got event fired by %q want %q", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package cron
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger is a structured logger. keysAndValues are alternating key and value pairs (ex: "entry", "ENTRY_1").
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// WithLogger logs scheduler activity to l. By default nothing is logged. Errors are still sent to Scheduler.ErrCh.
+func WithLogger(l Logger) Option {
+	return func(s *Scheduler) {
+		s.logger = l
+	}
+}
+
+// WithVerboseLogging includes Meta of the entries in the log. It is disabled by default because Meta may contain
+// secrets.
+func WithVerboseLogging() Option {
+	return func(s *Scheduler) {
+		s.verbose = true
+	}
+}
+
+// entryKV is the key value pairs that describe an entry in the log
+func (s *Scheduler) entryKV(e Entry, keysAndValues ...interface{}) []interface{} {
+	kv := []interface{}{"entry", e.Name, "expression", e.expression}
+	if s.verbose {
+		kv = append(kv, "meta", e.Meta)
+	}
+
+	return append(kv, keysAndValues...)
+}
+
+// nopLogger discards the logs
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keysAndValues ...interface{}) {}
+func (nopLogger) Info(msg string, keysAndValues ...interface{})  {}
+func (nopLogger) Error(msg string, keysAndValues ...interface{}) {}
+
+// NewSlogLogger adapts slog.Logger to Logger
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debug(msg string, keysAndValues ...interface{}) {
+	s.l.Log(context.Background(), slog.LevelDebug, msg, keysAndValues...)
+}
+
+func (s slogLogger) Info(msg string, keysAndValues ...interface{}) {
+	s.l.Log(context.Background(), slog.LevelInfo, msg, keysAndValues...)
+}
+
+func (s slogLogger) Error(msg string, keysAndValues ...interface{}) {
+	s.l.Log(context.Background(), slog.LevelError, msg, keysAndValues...)
+}
@@ -0,0 +1,90 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_logger(t *testing.T) {
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	run := func(opts ...Option) string {
+		ctx := context.Background()
+		store := &MemStore{}
+		for _, name := range []string{"ENTRY_1", "ENTRY_2"} {
+			e, err := Parse("* * * * *", time.UTC, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.Meta = "SECRET"
+			store.AddEntry(ctx, e)
+			if name == "ENTRY_2" {
+				store.AddEvent(ctx, Event{Entry: e, Time: now})
+			}
+		}
+
+		var (
+			mu  sync.Mutex
+			buf bytes.Buffer
+			wg  sync.WaitGroup
+		)
+		wg.Add(1)
+		logger := slog.New(slog.NewTextHandler(&lockedWriter{mu: &mu, w: &buf}, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == "duration" {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		opts = append(opts, WithLogger(NewSlogLogger(logger)), WithHooks(Hooks{
+			OnComplete: func(ev Event, d time.Duration) { wg.Done() },
+		}))
+		s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store, opts...)
+		if err := s.check(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.String()
+	}
+
+	got := run()
+	for _, want := range []string{
+		`level=DEBUG msg=check at=2018-12-15T00:00:00.000Z entries=2 events=1`,
+		`level=DEBUG msg="entry already triggered" entry=ENTRY_2 expression="* * * * *" at=2018-12-15T00:00:00.000Z`,
+		`level=INFO msg="entry triggered" entry=ENTRY_1 expression="* * * * *" at=2018-12-15T00:00:00.000Z`,
+		`level=INFO msg="handler completed" entry=ENTRY_1 expression="* * * * *" at=2018-12-15T00:00:00.000Z`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log does not contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "SECRET") {
+		t.Errorf("log contains meta, got:\n%s", got)
+	}
+
+	got = run(WithVerboseLogging())
+	if want := `msg="entry triggered" entry=ENTRY_1 expression="* * * * *" meta=SECRET`; !strings.Contains(got, want) {
+		t.Errorf("verbose log does not contain %q, got:\n%s", want, got)
+	}
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  *bytes.Buffer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
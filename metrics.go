@@ -0,0 +1,56 @@
+package cron
+
+import "time"
+
+// Error kinds given to Metrics.IncError
+const (
+	// ErrorKindStore is an error from the store
+	ErrorKindStore = "store"
+	// ErrorKindHandler is an error returned by a handler
+	ErrorKindHandler = "handler"
+	// ErrorKindNoHandler is a triggered entry without handler
+	ErrorKindNoHandler = "no_handler"
+	// ErrorKindTimeout is a handler that did not return within the timeout, see Entry.Timeout
+	ErrorKindTimeout = "timeout"
+)
+
+// Skip reasons given to Metrics.IncSkipped
+const (
+	// SkipAlreadyTriggered is a matched entry that is already triggered, most likely by another instance
+	SkipAlreadyTriggered = "already_triggered"
+	// SkipBlackout is a matched entry within a blackout window, see WithBlackout
+	SkipBlackout = "blackout"
+	// SkipDependency is a dependent entry whose dependencies did not succeed, see Entry.DependsOn
+	SkipDependency = "dependency"
+)
+
+// Metrics is an instrumentation point of the scheduler. It allows plugging in a metrics library without depending
+// on it, see package cronprom for Prometheus implementation. Implementation must be safe for concurrent use.
+type Metrics interface {
+	// IncTriggered is called when an entry is triggered
+	IncTriggered(entry string)
+	// ObserveHandlerDuration is called when the handler of an entry finished
+	ObserveHandlerDuration(entry string, d time.Duration)
+	// IncError is called on error, see ErrorKind constants for the kind
+	IncError(kind string)
+	// ObserveCheckDuration is called when a check tick finished
+	ObserveCheckDuration(d time.Duration)
+	// IncSkipped is called when a matched entry is not triggered, see Skip constants for the reason
+	IncSkipped(entry, reason string)
+}
+
+// WithMetrics instruments the scheduler with m. By default metrics are discarded.
+func WithMetrics(m Metrics) Option {
+	return func(s *Scheduler) {
+		s.metrics = m
+	}
+}
+
+// nopMetrics discards the metrics
+type nopMetrics struct{}
+
+func (nopMetrics) IncTriggered(entry string)                            {}
+func (nopMetrics) ObserveHandlerDuration(entry string, d time.Duration) {}
+func (nopMetrics) IncError(kind string)                                 {}
+func (nopMetrics) ObserveCheckDuration(d time.Duration)                 {}
+func (nopMetrics) IncSkipped(entry, reason string)                      {}
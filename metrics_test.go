@@ -0,0 +1,111 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingMetrics counts the calls of Metrics
+type countingMetrics struct {
+	mu              sync.Mutex
+	triggered       map[string]int
+	handlerDuration map[string]int
+	errors          map[string]int
+	checkDuration   int
+	skipped         map[string]int
+}
+
+func newCountingMetrics() *countingMetrics {
+	return &countingMetrics{
+		triggered:       make(map[string]int),
+		handlerDuration: make(map[string]int),
+		errors:          make(map[string]int),
+		skipped:         make(map[string]int),
+	}
+}
+
+func (m *countingMetrics) IncTriggered(entry string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.triggered[entry]++
+}
+
+func (m *countingMetrics) ObserveHandlerDuration(entry string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlerDuration[entry]++
+}
+
+func (m *countingMetrics) IncError(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[kind]++
+}
+
+func (m *countingMetrics) ObserveCheckDuration(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkDuration++
+}
+
+func (m *countingMetrics) IncSkipped(entry, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.skipped[entry+"|"+reason]++
+}
+
+func TestScheduler_metrics(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	store := &MemStore{}
+	var entries []Entry
+	for _, name := range []string{"ENTRY_1", "ENTRY_2", "ENTRY_3", "ENTRY_4"} {
+		e, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(ctx, e)
+		entries = append(entries, e)
+	}
+	// ENTRY_3 is already triggered by another instance
+	store.AddEvent(ctx, Event{Entry: entries[2], Time: now})
+
+	var wg sync.WaitGroup
+	wg.Add(2) // ENTRY_1 and ENTRY_2 handlers
+	metrics := newCountingMetrics()
+	s := NewScheduler(nil, store, WithMetrics(metrics), WithHooks(Hooks{
+		OnComplete: func(ev Event, d time.Duration) { wg.Done() },
+		OnError:    func(ev Event, d time.Duration, err error) { wg.Done() },
+	}))
+	s.Handle("ENTRY_1", func(ctx context.Context, e Entry) error { return nil })
+	s.Handle("ENTRY_2", func(ctx context.Context, e Entry) error { return errors.New("failed") })
+	// ENTRY_4 has no handler
+
+	if err := s.check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if got, want := metrics.triggered, map[string]int{"ENTRY_1": 1, "ENTRY_2": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got triggered %v want %v", got, want)
+	}
+	if got, want := metrics.handlerDuration, map[string]int{"ENTRY_1": 1, "ENTRY_2": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got handler duration %v want %v", got, want)
+	}
+	if got, want := metrics.errors, map[string]int{ErrorKindHandler: 1, ErrorKindNoHandler: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got errors %v want %v", got, want)
+	}
+	if got, want := metrics.checkDuration, 1; got != want {
+		t.Errorf("got check duration %d want %d", got, want)
+	}
+	if got, want := metrics.skipped, map[string]int{"ENTRY_3|" + SkipAlreadyTriggered: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got skipped %v want %v", got, want)
+	}
+}
@@ -6,35 +6,104 @@
 package cron
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"math/bits"
 	"strconv"
 	"strings"
 	"time"
 )
 
 // represent '*' in cron where all bit is set to 1
-const star = field(^uint64(0))
+const star = Field(^uint64(0))
 
-type field uint64
+// Field is a bitmap of a cron field where bit position represents an allowed value (see ParseField)
+type Field uint64
 
-// match check if the current value matches the field bitmap.
-func (f field) match(value int) bool {
+// Match check if the current value matches the field bitmap.
+func (f Field) Match(value int) bool {
 	return f&(1<<uint64(value)) != 0
 }
 
-func (f field) format() string {
+// Next returns the smallest value >= from within [min, max] that matches the field. It returns false if there is none.
+func (f Field) Next(from, min, max int) (int, bool) {
+	if from < min {
+		from = min
+	}
+	if from > max || from > 63 {
+		return 0, false
+	}
+
+	// clear the bits below from, the lowest bit left is the next value
+	v := uint64(f) &^ (1<<uint64(from) - 1)
+	if v == 0 {
+		return 0, false
+	}
+	if n := bits.TrailingZeros64(v); n <= max {
+		return n, true
+	}
+
+	return 0, false
+}
+
+// values returns the values within [min, max] that match the field
+func (f Field) values(min, max int) []int {
+	var values []int
+	for v, ok := f.Next(min, min, max); ok; v, ok = f.Next(v+1, min, max) {
+		values = append(values, v)
+	}
+	return values
+}
+
+// isStar reports whether the field is given as '*' (or '?'). A field with the full range (ex: '1-31') is not a star.
+func (f Field) isStar() bool {
+	return f == star
+}
+
+// Format returns the field as '*', '*/step' or comma separated values where a contiguous run is written as 'a-b'. It
+// assumes the full range of the bitmap (0 - 63), the fields of an Entry are formatted within their own range (see
+// Entry.String).
+func (f Field) Format() string {
+	return f.format(0, 63)
+}
+
+// format returns the field within [min, max]. Values that are evenly spaced from min up to max are collapsed into
+// '*/step' and a run of at least 3 contiguous values into 'a-b'. Any other values are comma separated.
+func (f Field) format(min, max int) string {
 	if f == star {
 		return "*"
 	}
 
-	buffer := make([]string, 0)
-	for i := 0; i < 64; i++ {
-		if f.match(i) {
-			buffer = append(buffer, strconv.Itoa(i))
+	values := f.values(min, max)
+	if len(values) >= 2 && values[0] == min {
+		step := values[1] - values[0]
+		spaced := step > 1 && values[len(values)-1]+step > max
+		for i := 2; spaced && i < len(values); i++ {
+			spaced = values[i]-values[i-1] == step
+		}
+		if spaced {
+			return "*/" + strconv.Itoa(step)
 		}
 	}
 
+	buffer := make([]string, 0, len(values))
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			buffer = append(buffer, strconv.Itoa(values[i])+"-"+strconv.Itoa(values[j]))
+		} else {
+			for _, v := range values[i : j+1] {
+				buffer = append(buffer, strconv.Itoa(v))
+			}
+		}
+		i = j + 1
+	}
+
 	return strings.Join(buffer, ",")
 }
 
@@ -43,9 +112,18 @@ type Entry struct {
 	Name     string
 	Meta     string // optional metadata
 	Location *time.Location
+	Paused   bool // paused entry is not triggered, see Scheduler.Pause
+	// IgnoreBlackout entry is triggered even within blackout windows (ex: critical jobs), see WithBlackout
+	IgnoreBlackout bool
+	// Timeout of the handler context, zero uses the scheduler default (see WithDefaultTimeout)
+	Timeout time.Duration
+	// DependsOn is the names of the entries that must succeed before this entry runs on the same minute. The handler
+	// waits until the events of the dependencies that match the minute succeeded, see WithDependencyWait. The status
+	// is read from a store implementing EventStatusStore, otherwise the handler waits until the timeout.
+	DependsOn []string
 
 	// parsed representation of expression
-	minute, hour, dom, month, dow field
+	minute, hour, dom, month, dow Field
 	expression                    string
 }
 
@@ -54,25 +132,246 @@ func (e Entry) Expression() string {
 	return e.expression
 }
 
-// Match the entry with a time
+// Fields returns the values of each field in ascending order, '*' is the full range of the field (ex: 0-59 for minute).
+// Day of week is 0 (Sunday) to 6.
+func (e Entry) Fields() (minute, hour, dom, month, dow []int) {
+	return e.minute.values(0, 59), e.hour.values(0, 23), e.dom.values(1, 31), e.month.values(1, 12), e.dow.values(0, 6)
+}
+
+// UTCOffsetAt returns the offset from UTC of the location of the entry at t, ex: to display the offset of the next run.
+// It follows the daylight saving time of the location, a nil location is UTC.
+func (e Entry) UTCOffsetAt(t time.Time) time.Duration {
+	loc := e.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	_, offset := t.In(loc).Zone()
+	return time.Duration(offset) * time.Second
+}
+
+// WithLocation returns a copy of the entry in loc, a nil loc is UTC like Parse. The fields are kept since they do not
+// depend on the location, the entry matches the same wall clock in loc.
+func (e Entry) WithLocation(loc *time.Location) Entry {
+	if loc == nil {
+		loc = time.UTC
+	}
+	e.Location = loc
+	return e
+}
+
+// Match the entry with a time. When both day of month and day of week are restricted, the day matches if either
+// of them matches.
 func (e Entry) Match(t time.Time) bool {
-	t = t.In(e.Location)
+	return e.matchClock(newWallClock(t.In(e.Location)))
+}
+
+// MatchAll returns the entries that match t. The wall clock is computed once per location for entries that share the
+// same *time.Location, which is faster than calling Match on each entry for a large number of entries.
+func MatchAll(entries []Entry, t time.Time) []Entry {
+	var (
+		matched []Entry
+		clocks  = make(map[*time.Location]wallClock)
+		lastLoc *time.Location
+		last    wallClock
+	)
+	for _, e := range entries {
+		if e.Location != lastLoc || lastLoc == nil {
+			c, ok := clocks[e.Location]
+			if !ok {
+				c = newWallClock(t.In(e.Location))
+				clocks[e.Location] = c
+			}
+			lastLoc, last = e.Location, c
+		}
+		if e.matchClock(last) {
+			matched = append(matched, e)
+		}
+	}
+
+	return matched
+}
+
+// wallClock is the parts of a time in a location that are matched by an entry
+type wallClock struct {
+	minute, hour, dom, month, dow int
+}
 
-	return e.minute.match(t.Minute()) &&
-		e.hour.match(t.Hour()) &&
-		e.dom.match(t.Day()) &&
-		e.dow.match(int(t.Weekday())) &&
-		e.month.match(int(t.Month()))
+func newWallClock(t time.Time) wallClock {
+	_, month, day := t.Date()
+	hour, minute, _ := t.Clock()
+
+	return wallClock{minute: minute, hour: hour, dom: day, month: int(month), dow: int(t.Weekday())}
+}
+
+func (e Entry) matchClock(c wallClock) bool {
+	return e.minute.Match(c.minute) &&
+		e.hour.Match(c.hour) &&
+		e.dayMatch(c.dom, c.dow) &&
+		e.month.Match(c.month)
+}
+
+// dayMatch check the day of month and day of week (in the entry location). Like Vixie cron, when both day of
+// month and day of week are restricted (not '*'), it matches if either of them matches.
+// ex: `0 0 13 * 5` matches on the 13th and on every Friday, not only on Friday the 13th.
+func (e Entry) dayMatch(dom, dow int) bool {
+	domMatch, dowMatch := e.dom.Match(dom), e.dow.Match(dow)
+	if e.dom.isStar() || e.dow.isStar() {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// Next returns the first minute after t that matches the entry. The returned time is in the entry location.
+// It returns zero time if there is no match within 5 years, see NextWithin.
+func (e Entry) Next(t time.Time) time.Time {
+	next, _ := e.nextUntil(t, t.AddDate(5, 0, 0))
+	return next
+}
+
+// NextWithin returns the first minute after after that matches the entry within horizon, ex: more than 4 years for
+// `0 0 29 2 *`. ok is false if there is no match within horizon.
+func (e Entry) NextWithin(after time.Time, horizon time.Duration) (_ time.Time, ok bool) {
+	return e.nextUntil(after, after.Add(horizon))
+}
+
+// DurationUntilNext returns the duration from from until the next run of the entry (see Next), ex: to sleep until the
+// next run instead of checking every minute. ok is false if the entry has no next run, ex: @reboot.
+func (e Entry) DurationUntilNext(from time.Time) (_ time.Duration, ok bool) {
+	next := e.Next(from)
+	if next.IsZero() {
+		return 0, false
+	}
+
+	return next.Sub(from), true
+}
+
+// nextUntil returns the first minute after t that matches the entry and is not after limit
+func (e Entry) nextUntil(t, limit time.Time) (time.Time, bool) {
+	loc := e.Location
+	t = t.In(loc).Truncate(time.Minute).Add(time.Minute)
+
+	// skip to the start of the next month, day or hour when that part does not match
+	for !t.After(limit) {
+		if !e.month.Match(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !e.dayMatch(t.Day(), int(t.Weekday())) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		// jump with duration rather than time.Date to always move forward on ambiguous wall clock (DST)
+		if !e.hour.Match(t.Hour()) {
+			if h, ok := e.hour.Next(t.Hour(), 0, 23); ok {
+				t = t.Add(time.Duration(h-t.Hour())*time.Hour - time.Duration(t.Minute())*time.Minute)
+			} else {
+				t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			}
+			continue
+		}
+		if !e.minute.Match(t.Minute()) {
+			if m, ok := e.minute.Next(t.Minute(), 0, 59); ok {
+				t = t.Add(time.Duration(m-t.Minute()) * time.Minute)
+			} else {
+				t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			}
+			continue
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
 }
 
 func (e Entry) String() string {
-	str := []string{e.minute.format(), e.hour.format(), e.dom.format(), e.month.format(), e.dow.format()}
+	return fmt.Sprintf("{ name:%q schedule:%q, location:%q }", e.Name, e.schedule(), e.Location)
+}
+
+// MarshalJSON encodes the entry as JSON object with name, expression, location and meta
+func (e Entry) MarshalJSON() ([]byte, error) {
+	loc := time.UTC
+	if e.Location != nil {
+		loc = e.Location
+	}
+	var timeout string
+	if e.Timeout > 0 {
+		timeout = e.Timeout.String()
+	}
+
+	return json.Marshal(struct {
+		Name       string `json:"name"`
+		Expression string `json:"expression"`
+		Location   string `json:"location"`
+		Meta       string `json:"meta,omitempty"`
+		Paused     bool   `json:"paused,omitempty"`
+		// IgnoreBlackout entry runs within blackout windows
+		IgnoreBlackout bool     `json:"ignore_blackout,omitempty"`
+		Timeout        string   `json:"timeout,omitempty"`
+		DependsOn      []string `json:"depends_on,omitempty"`
+	}{
+		Name:       e.Name,
+		Expression: e.expression,
+		Location:   loc.String(),
+		Meta:       e.Meta,
+		Paused:     e.Paused,
+
+		IgnoreBlackout: e.IgnoreBlackout,
+		Timeout:        timeout,
+		DependsOn:      e.DependsOn,
+	})
+}
+
+// DefaultLocation is the location of entries decoded by UnmarshalText without CRON_TZ prefix
+var DefaultLocation = time.Local
+
+// MarshalText encodes the entry as its expression. The expression is prefixed with `CRON_TZ=<zone>` when the location
+// is not DefaultLocation. The name and meta are not included.
+func (e Entry) MarshalText() ([]byte, error) {
+	if e.expression == "" {
+		return nil, errors.New("empty expression")
+	}
+	if e.Location != nil && DefaultLocation != nil && e.Location.String() != DefaultLocation.String() {
+		return []byte("CRON_TZ=" + e.Location.String() + " " + e.expression), nil
+	}
+
+	return []byte(e.expression), nil
+}
+
+// UnmarshalText parses the expression in DefaultLocation (see Parse), ex: for flag.TextVar. The name of the entry is
+// kept.
+func (e *Entry) UnmarshalText(text []byte) error {
+	entry, err := Parse(string(text), DefaultLocation, e.Name)
+	if err != nil {
+		return err
+	}
+	*e = entry
 
-	return fmt.Sprintf("{ name:%q schedule:%q, location:%q }", e.Name, strings.Join(str, " "), e.Location)
+	return nil
+}
+
+// schedule is the normalized expression constructed from the parsed fields
+func (e Entry) schedule() string {
+	str := []string{
+		e.minute.format(0, 59), e.hour.format(0, 23), e.dom.format(1, 31), e.month.format(1, 12), e.dow.format(0, 6),
+	}
+
+	return strings.Join(str, " ")
 }
 
 // Parse a cron expression on a location. If location is nil it uses UTC
-// it does not support macro (ex: @monthly)
+// it does not support macro (ex: @monthly) except @reboot, see Entry.IsReboot
+//
+// The expression can be prefixed with `CRON_TZ=<zone>` (ex: `CRON_TZ=America/New_York 0 9 * * *`) which overrides
+// loc. In that case the prefix is not retained in Entry.Expression
+//
+// A trailing comment that starts with '#' at the beginning of a word (ex: `0 0 * * * # nightly backup`) is stripped and
+// set as Entry.Meta. A '#' inside a field (ex: `2#2`) is not a comment. The comment is not retained in
+// Entry.Expression.
+//
+// Like Jenkins, a field can use 'H' token to pick a stable value derived from the hash of name, ex: `H * * * *` runs
+// hourly on a minute that depends on the name, `H(0-29)/10 * * * *` runs every 10 minutes with offset from the name.
 //
 // ex format:
 //
@@ -83,6 +382,33 @@ func (e Entry) String() string {
 //  | |   |    |     +- Day of Week  (0-6)  : [Sun, Mon, Tue, Wed]
 //  5 *  */5 1-12/2 0-3
 func Parse(expression string, loc *time.Location, name string) (Entry, error) {
+	e, err := parse(expression, loc, name, false)
+	if err != nil {
+		return e, err
+	}
+
+	return e, satisfiable(e)
+}
+
+// ParseStrict is like Parse but '?' (no specific value) is only allowed in day of month and day of week like Quartz,
+// ex: `* * ? * *` is valid but `? * * * *` is not. Parse accepts '?' as '*' in every field.
+func ParseStrict(expression string, loc *time.Location, name string) (Entry, error) {
+	e, err := parse(expression, loc, name, true)
+	if err != nil {
+		return e, err
+	}
+
+	return e, satisfiable(e)
+}
+
+// ParseStored is like Parse but it accepts an expression that never matches (ex: `0 0 30 2 *`, see
+// Entry.IsSatisfiable). It is used by the stores to read back the entries that were saved before Parse rejected them
+// so that one of them does not fail the whole check, Entry.Validate reports them with WarningNeverFires.
+func ParseStored(expression string, loc *time.Location, name string) (Entry, error) {
+	return parse(expression, loc, name, false)
+}
+
+func parse(expression string, loc *time.Location, name string, strict bool) (Entry, error) {
 	if loc == nil {
 		loc = time.UTC
 	}
@@ -91,42 +417,139 @@ func Parse(expression string, loc *time.Location, name string) (Entry, error) {
 		Location:   loc,
 		expression: expression,
 	}
-	fields := strings.Fields(expression)
+	if expr, comment, ok := cutComment(expression); ok {
+		e.expression, e.Meta = expr, comment
+	}
+	fields := strings.Fields(e.expression)
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "CRON_TZ=") {
+		zone := strings.TrimPrefix(fields[0], "CRON_TZ=")
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return e, fmt.Errorf("failed to load location %q: %v", zone, err)
+		}
+		fields = fields[1:]
+		e.Location = loc
+		e.expression = strings.Join(fields, " ")
+	}
+	if len(fields) == 1 && fields[0] == rebootExpression {
+		// the fields are empty so that the entry never matches on a check
+		e.expression = rebootExpression
+		return e, nil
+	}
 	if len(fields) != 5 {
 		return e, fmt.Errorf("got %d want %d expressions", len(fields), 5)
 	}
 
-	var err error
-	e.minute, err = parseField(fields[0], 0, 59)
-	if err != nil {
-		return e, fmt.Errorf("failed parsing 'minute' field %q: %v", fields[0], err)
+	for i, f := range []struct {
+		field    *Field
+		name     string
+		min, max int
+		question bool // '?' is allowed in strict mode
+	}{
+		{&e.minute, "minute", 0, 59, false},
+		{&e.hour, "hour", 0, 23, false},
+		{&e.dom, "day of month", 1, 31, true},
+		{&e.month, "month", 1, 12, false},
+		{&e.dow, "day of week", 0, 6, true},
+	} {
+		if strict && !f.question && strings.Contains(fields[i], "?") {
+			err := errors.New("'?' is only allowed in day of month and day of week")
+			return e, &FieldError{Field: f.name, Index: i, Expr: fields[i], Err: err}
+		}
+		v, err := parseHashedField(fields[i], f.min, f.max, name)
+		if err != nil {
+			return e, &FieldError{Field: f.name, Index: i, Expr: fields[i], Err: err}
+		}
+		*f.field = v
 	}
-	e.hour, err = parseField(fields[1], 0, 23)
-	if err != nil {
-		return e, fmt.Errorf("failed parsing 'hour' field %q: %v", fields[1], err)
+
+	return e, nil
+}
+
+// satisfiable returns an error when the parsed entry never matches, see Entry.IsSatisfiable
+func satisfiable(e Entry) error {
+	if e.IsReboot() || e.IsSatisfiable() {
+		return nil
 	}
-	e.dom, err = parseField(fields[2], 1, 31)
-	if err != nil {
-		return e, fmt.Errorf("failed parsing 'day of month' field %q: %v", fields[2], err)
+	fields := strings.Fields(e.expression)
+
+	return fmt.Errorf("day of month %q never occurs in month %q", fields[2], fields[3])
+}
+
+// FieldError is returned by Parse when a field of the expression is not valid
+type FieldError struct {
+	Field string // name of the field, ex: "minute" or "day of week"
+	Index int    // position of the field in the expression, 0 for minute
+	Expr  string // expression of the field
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("failed parsing '%s' field %q: %v", e.Field, e.Expr, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// cutComment splits expression on '#' that starts a word. The '#' of a field (ex: `2#2`) is not a comment.
+func cutComment(expression string) (expr, comment string, ok bool) {
+	for i := 0; i < len(expression); i++ {
+		if expression[i] != '#' || (i > 0 && expression[i-1] != ' ' && expression[i-1] != '\t') {
+			continue
+		}
+		return strings.TrimSpace(expression[:i]), strings.TrimSpace(expression[i+1:]), true
 	}
-	e.month, err = parseField(fields[3], 1, 12)
-	if err != nil {
-		return e, fmt.Errorf("failed parsing 'month' field %q: %v", fields[3], err)
+
+	return expression, "", false
+}
+
+// maximum days of each month, February is 29 on leap year
+var monthDays = [13]int{1: 31, 2: 29, 3: 31, 4: 30, 5: 31, 6: 30, 7: 31, 8: 31, 9: 30, 10: 31, 11: 30, 12: 31}
+
+// IsSatisfiable reports whether the entry can ever match a time. It is false when none of the days of month exists
+// in the selected months (ex: `0 0 30 2 *`). When day of week is also restricted, the entry still matches on those
+// days of week (see Match).
+func (e Entry) IsSatisfiable() bool {
+	if !e.dom.isStar() && !e.dow.isStar() {
+		return true
 	}
-	e.dow, err = parseField(fields[4], 0, 6)
-	if err != nil {
-		return e, fmt.Errorf("failed parsing 'day of week' field %q: %v", fields[4], err)
+
+	for month := 1; month <= 12; month++ {
+		if !e.month.Match(month) {
+			continue
+		}
+		for day := 1; day <= monthDays[month]; day++ {
+			if e.dom.Match(day) {
+				return true
+			}
+		}
 	}
 
-	return e, nil
+	return false
 }
 
-// parseField construct bitmap where position represents a value for that field
+// parseField is kept for compatibility, see ParseField
+func parseField(s string, min, max int) (Field, error) {
+	return ParseField(s, min, max)
+}
+
+// ParseField construct bitmap where position represents a value for that field. It supports '*', single value,
+// range, step and list (ex: '*', '2', '1-5', '*/5', '1-30/2', '5/15', '1,3,5') between min and max (max is at most 63).
+// 'H' token is hashed with an empty seed, see Parse.
 // ex: value of minutes `1,3,5`:
 //   bit             7654 3210
 //   possible value  6543 210
 //   bit value       0010 1010  -> [0,2,4] will be represented as uint64 value 42 (0x2A)
-func parseField(s string, min, max int) (field, error) {
+func ParseField(s string, min, max int) (Field, error) {
+	return parseHashedField(s, min, max, "")
+}
+
+// parseHashedField is ParseField where 'H' token is hashed with seed
+func parseHashedField(s string, min, max int, seed string) (Field, error) {
+	if min < 0 || max > 63 {
+		return 0, fmt.Errorf("field range (%d - %d) must be within 0 - 63", min, max)
+	}
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, errors.New("empty field")
@@ -136,27 +559,45 @@ func parseField(s string, min, max int) (field, error) {
 		return star, nil
 	}
 
-	var f field
+	var f Field
 	// parse single element or parse range (ex: '2' '1-5' '*/5' '1-30/2' )
 	// determine start, end and interval. Construct bitmap by traversing from start-end with interval.
 	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			return 0, fmt.Errorf("empty element in list %q", s)
+		}
+		if strings.HasPrefix(part, "H") {
+			h, err := parseHash(part, min, max, seed)
+			if err != nil {
+				return 0, err
+			}
+			f |= h
+			continue
+		}
+
 		var (
 			err                        error
 			interval                   = 1
 			startInterval, endInterval = min, max
+			toMax                      bool
 		)
 
-		// parse interval (ex: '*/5' '1-30/2') if exists
+		// parse interval (ex: '*/5' '1-30/2' '5/15') if exists, a single start value means start until max
 		if i := strings.IndexByte(part, '/'); i >= 0 {
-			if r := part[:i]; r != "*" && r != "?" && strings.IndexByte(r, '-') < 0 {
-				return 0, fmt.Errorf("step given without range, expression %q", s)
+			r := part[:i]
+			if r == "" {
+				return 0, fmt.Errorf("step given without start, expression %q", s)
 			}
+			toMax = r != "*" && r != "?" && strings.IndexByte(r, '-') < 0
 
 			step := part[i+1:]
 			interval, err = strconv.Atoi(step)
 			if err != nil {
 				return 0, fmt.Errorf("failed parsing interval expression %q: %s", step, err)
 			}
+			if interval <= 0 {
+				return 0, fmt.Errorf("invalid interval %d in expression %q", interval, part)
+			}
 			part = part[:i]
 		}
 
@@ -180,6 +621,9 @@ func parseField(s string, min, max int) (field, error) {
 					return 0, fmt.Errorf("failed parsing expression %q: %s", s, err)
 				}
 			}
+			if toMax {
+				endInterval = max
+			}
 		}
 
 		if startInterval < min || endInterval > max || startInterval > endInterval {
@@ -194,3 +638,61 @@ func parseField(s string, min, max int) (field, error) {
 
 	return f, nil
 }
+
+// parseHash parses Jenkins like hash token 'H', 'H(1-5)', 'H/15' or 'H(0-29)/10'. The value (or the offset of the
+// step) is derived from the hash of the seed so that it is stable for a seed but spread across different seeds.
+func parseHash(part string, min, max int, seed string) (Field, error) {
+	start, end := min, max
+	rest := part[1:]
+	if strings.HasPrefix(rest, "(") {
+		i := strings.IndexByte(rest, ')')
+		if i < 0 {
+			return 0, fmt.Errorf("missing ')' in hash expression %q", part)
+		}
+		r := strings.SplitN(rest[1:i], "-", 2)
+		if len(r) != 2 {
+			return 0, fmt.Errorf("invalid range in hash expression %q", part)
+		}
+		var err error
+		if start, err = strconv.Atoi(r[0]); err != nil {
+			return 0, fmt.Errorf("failed parsing hash expression %q: %s", part, err)
+		}
+		if end, err = strconv.Atoi(r[1]); err != nil {
+			return 0, fmt.Errorf("failed parsing hash expression %q: %s", part, err)
+		}
+		rest = rest[i+1:]
+	}
+	if start < min || end > max || start > end {
+		return 0, fmt.Errorf("value out of range (%d - %d): %s", min, max, part)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	sum := int(h.Sum32() & 0x7fffffff)
+
+	if rest == "" {
+		return 1 << uint64(start+sum%(end-start+1)), nil
+	}
+	if !strings.HasPrefix(rest, "/") {
+		return 0, fmt.Errorf("invalid hash expression %q", part)
+	}
+	step, err := strconv.Atoi(rest[1:])
+	if err != nil {
+		return 0, fmt.Errorf("failed parsing interval expression %q: %s", rest[1:], err)
+	}
+	if step <= 0 {
+		return 0, fmt.Errorf("invalid interval %d in hash expression %q", step, part)
+	}
+
+	// offset within the first step that fits the range
+	offset := step
+	if n := end - start + 1; n < offset {
+		offset = n
+	}
+	var f Field
+	for i := start + sum%offset; i <= end; i += step {
+		f |= 1 << uint64(i)
+	}
+
+	return f, nil
+}
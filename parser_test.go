@@ -1,6 +1,12 @@
 package cron
 
 import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -79,16 +85,60 @@ func TestParse(t *testing.T) {
 		},
 		{
 			name: "with step", args: args{expression: "*/2 23 31 12 6", loc: time.UTC},
-			want: `{ name:"with step" schedule:"0,2,4,6,8,10,12,14,16,18,20,22,24,26,28,30,32,34,36,38,40,42,44,46,48,50,52,54,56,58 23 31 12 6", location:"UTC" }`, wantErr: "",
+			want: `{ name:"with step" schedule:"*/2 23 31 12 6", location:"UTC" }`, wantErr: "",
 		},
 		{
-			name: "with step without range", args: args{expression: "30/2 23 31 12 7", loc: time.UTC}, want: ``,
-			wantErr: `failed parsing 'minute' field "30/2": step given without range, expression "30/2"`,
+			name: "with step without range", args: args{expression: "30/2 23 31 12 6", loc: time.UTC},
+			want: `{ name:"with step without range" schedule:"30,32,34,36,38,40,42,44,46,48,50,52,54,56,58 23 31 12 6", location:"UTC" }`, wantErr: "",
+		},
+		{
+			name: "with step without start", args: args{expression: "/15 23 31 12 6", loc: time.UTC}, want: ``,
+			wantErr: `failed parsing 'minute' field "/15": step given without start, expression "/15"`,
 		},
 		{
 			name: "with step and range", args: args{expression: "10-30/3 23 31 12 6", loc: time.UTC},
 			want: `{ name:"with step and range" schedule:"10,13,16,19,22,25,28 23 31 12 6", location:"UTC" }`, wantErr: "",
 		},
+		{
+			name: "with timezone prefix", args: args{expression: "CRON_TZ=America/New_York 0 9 * * *", loc: jkt},
+			want: `{ name:"with timezone prefix" schedule:"0 9 * * *", location:"America/New_York" }`, wantErr: "",
+		},
+		{
+			name: "with invalid timezone prefix", args: args{expression: "CRON_TZ=Mars/Phobos 0 9 * * *", loc: time.UTC}, want: ``,
+			wantErr: `failed to load location "Mars/Phobos": unknown time zone Mars/Phobos`,
+		},
+		{
+			name: "zero interval", args: args{expression: "*/0 * * * *", loc: time.UTC}, want: ``,
+			wantErr: `failed parsing 'minute' field "*/0": invalid interval 0 in expression "*/0"`,
+		},
+		{
+			name: "negative interval", args: args{expression: "0 1-5/-1 * * *", loc: time.UTC}, want: ``,
+			wantErr: `failed parsing 'hour' field "1-5/-1": invalid interval -1 in expression "1-5/-1"`,
+		},
+		{
+			name: "impossible february 30", args: args{expression: "0 0 30 2 *", loc: time.UTC}, want: ``,
+			wantErr: `day of month "30" never occurs in month "2"`,
+		},
+		{
+			name: "impossible april 31", args: args{expression: "0 0 31 4 *", loc: time.UTC}, want: ``,
+			wantErr: `day of month "31" never occurs in month "4"`,
+		},
+		{
+			name: "impossible 31 on 30 days months", args: args{expression: "0 0 31 4,6,9,11 *", loc: time.UTC}, want: ``,
+			wantErr: `day of month "31" never occurs in month "4,6,9,11"`,
+		},
+		{
+			name: "impossible day of month with day of week", args: args{expression: "0 0 30 2 1", loc: time.UTC},
+			want: `{ name:"impossible day of month with day of week" schedule:"0 0 30 2 1", location:"UTC" }`, wantErr: "",
+		},
+		{
+			name: "leap day", args: args{expression: "0 0 29 2 *", loc: time.UTC},
+			want: `{ name:"leap day" schedule:"0 0 29 2 *", location:"UTC" }`, wantErr: "",
+		},
+		{
+			name: "31 on some months", args: args{expression: "0 0 30,31 2,5 *", loc: time.UTC},
+			want: `{ name:"31 on some months" schedule:"0 0 30,31 2,5 *", location:"UTC" }`, wantErr: "",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -169,7 +219,8 @@ func TestMatch(t *testing.T) {
 				time.Date(2008, 2, 3, 15, 4, 5, 0, time.UTC), // sunday
 				time.Date(2009, 2, 2, 15, 4, 5, 0, time.UTC), // monday
 				time.Date(2010, 2, 2, 15, 4, 5, 0, time.UTC), // tuesday
-
+				time.Date(2006, 1, 1, 15, 4, 5, 0, time.UTC), // day of month not match but sunday
+				time.Date(2015, 1, 2, 15, 4, 5, 0, time.UTC), // friday but day of month match
 			},
 			wantNotMatch: []time.Time{
 				zero,
@@ -177,11 +228,46 @@ func TestMatch(t *testing.T) {
 				time.Date(2006, 1, 2, 15, 6, 5, 0, time.UTC),  // minute
 				time.Date(2006, 1, 2, 14, 4, 5, 0, time.UTC),  // hours
 				time.Date(2006, 1, 2, 17, 4, 5, 0, time.UTC),  // hours
-				time.Date(2006, 1, 1, 15, 4, 5, 0, time.UTC),  // day of month
-				time.Date(2006, 1, 4, 15, 4, 5, 0, time.UTC),  // day of month
+				time.Date(2006, 1, 4, 15, 4, 5, 0, time.UTC),  // day of month and wednesday
 				time.Date(2006, 12, 2, 15, 4, 5, 0, time.UTC), // month
 				time.Date(2006, 3, 2, 15, 4, 5, 0, time.UTC),  // month
-				time.Date(2015, 1, 2, 15, 4, 5, 0, time.UTC),  // friday
+				time.Date(2015, 1, 9, 15, 4, 5, 0, time.UTC),  // day of month and friday
+			},
+		},
+		{
+			name: "day of month or day of week", args: args{expression: "0 0 13 * 5", loc: time.UTC},
+			wantMatch: []time.Time{
+				time.Date(2018, 7, 13, 0, 0, 0, 0, time.UTC), // friday the 13th
+				time.Date(2018, 8, 13, 0, 0, 0, 0, time.UTC), // monday the 13th
+				time.Date(2018, 8, 17, 0, 0, 0, 0, time.UTC), // friday
+			},
+			wantNotMatch: []time.Time{
+				time.Date(2018, 8, 14, 0, 0, 0, 0, time.UTC), // tuesday the 14th
+			},
+		},
+		{
+			name: "day of month with star day of week", args: args{expression: "0 0 13 * *", loc: time.UTC},
+			wantMatch: []time.Time{
+				time.Date(2018, 8, 13, 0, 0, 0, 0, time.UTC),
+			},
+			wantNotMatch: []time.Time{
+				time.Date(2018, 8, 17, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "day of week with star day of month", args: args{expression: "0 0 * * 5", loc: time.UTC},
+			wantMatch: []time.Time{
+				time.Date(2018, 8, 17, 0, 0, 0, 0, time.UTC),
+			},
+			wantNotMatch: []time.Time{
+				time.Date(2018, 8, 13, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "full range is not a star", args: args{expression: "0 0 1-31 * 5", loc: time.UTC},
+			wantMatch: []time.Time{
+				time.Date(2018, 8, 13, 0, 0, 0, 0, time.UTC),
+				time.Date(2018, 8, 17, 0, 0, 0, 0, time.UTC),
 			},
 		},
 	}
@@ -209,3 +295,645 @@ func TestMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_timezonePrefixExpression(t *testing.T) {
+	e, err := Parse("CRON_TZ=America/New_York 0 9 * * *", nil, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Expression(), "0 9 * * *"; got != want {
+		t.Errorf("got expression %q want %q", got, want)
+	}
+	if got, want := e.Match(time.Date(2018, 12, 15, 14, 0, 0, 0, time.UTC)), true; got != want {
+		t.Errorf("got match %t want %t", got, want)
+	}
+}
+
+func TestParse_fieldError(t *testing.T) {
+	_, err := Parse("0 24 * * *", time.UTC, "ENTRY_1")
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("got error %v want FieldError", err)
+	}
+	if got, want := fieldErr.Field, "hour"; got != want {
+		t.Errorf("got field %q want %q", got, want)
+	}
+	if got, want := fieldErr.Index, 1; got != want {
+		t.Errorf("got index %d want %d", got, want)
+	}
+	if got, want := fieldErr.Expr, "24"; got != want {
+		t.Errorf("got expression %q want %q", got, want)
+	}
+
+	if _, err := Parse("0 0 * *", time.UTC, "ENTRY_1"); errors.As(err, &fieldErr) {
+		t.Errorf("got FieldError on the number of fields: %v", err)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    bool
+	}{
+		{expression: "* * ? * *"},
+		{expression: "0 0 1 * ?"},
+		{expression: "? * * * *", wantErr: true},
+		{expression: "0 ? * * *", wantErr: true},
+		{expression: "0 0 * ?/2 *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := ParseStrict(tt.expression, time.UTC, "ENTRY_1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStrict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var fieldErr *FieldError
+			if tt.wantErr && !errors.As(err, &fieldErr) {
+				t.Errorf("got error %v want FieldError", err)
+			}
+
+			// Parse is permissive
+			if _, err := Parse(tt.expression, time.UTC, "ENTRY_1"); err != nil {
+				t.Errorf("Parse() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestParseStored(t *testing.T) {
+	if _, err := Parse("0 0 30 2 *", time.UTC, "ENTRY_1"); err == nil {
+		t.Fatal("Parse() accepted an expression that never matches")
+	}
+
+	e, err := ParseStored("0 0 30 2 *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatalf("ParseStored() error = %v", err)
+	}
+	if e.IsSatisfiable() {
+		t.Errorf("got satisfiable entry %v", e)
+	}
+	if warnings := e.Validate(); len(warnings) != 1 || warnings[0].Reason != WarningNeverFires {
+		t.Errorf("got warnings %v want %s", warnings, WarningNeverFires)
+	}
+
+	if _, err := ParseStored("0 0 32 2 *", time.UTC, "ENTRY_1"); err == nil {
+		t.Error("ParseStored() accepted an invalid field")
+	}
+}
+
+func TestParse_comment(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+		wantMeta   string
+		wantErr    string
+	}{
+		{expression: "0 0 * * * # nightly backup", want: "0 0 * * *", wantMeta: "nightly backup"},
+		{expression: "0 0 * * *\t#foo", want: "0 0 * * *", wantMeta: "foo"},
+		{expression: "CRON_TZ=Asia/Jakarta 0 0 * * * # foo", want: "0 0 * * *", wantMeta: "foo"},
+		{expression: "0 0 * * * #", want: "0 0 * * *"},
+		{expression: "0 0 * * 2#2", wantErr: `failed parsing 'day of week' field "2#2"`},
+		{expression: "0 0 * * 2#2 # foo", wantErr: `failed parsing 'day of week' field "2#2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := Parse(tt.expression, time.UTC, "ENTRY_1")
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := e.Expression(), tt.want; got != want {
+				t.Errorf("got expression %q want %q", got, want)
+			}
+			if got, want := e.Meta, tt.wantMeta; got != want {
+				t.Errorf("got meta %q want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestEntry_Next(t *testing.T) {
+	jkt, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expression string
+		loc        *time.Location
+		from       time.Time
+		want       time.Time
+	}{
+		{
+			expression: "* * * * *", loc: time.UTC,
+			from: time.Date(2018, 12, 15, 0, 0, 30, 0, time.UTC),
+			want: time.Date(2018, 12, 15, 0, 1, 0, 0, time.UTC),
+		},
+		{
+			expression: "*/15 * * * *", loc: time.UTC,
+			from: time.Date(2018, 12, 15, 0, 15, 0, 0, time.UTC),
+			want: time.Date(2018, 12, 15, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			expression: "30 9 * * *", loc: time.UTC,
+			from: time.Date(2018, 12, 15, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2018, 12, 16, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			expression: "0 0 1 1 *", loc: time.UTC,
+			from: time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expression: "0 0 29 2 *", loc: time.UTC,
+			from: time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expression: "0 9 * * 1", loc: jkt,
+			from: time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC), // Saturday
+			want: time.Date(2018, 12, 17, 9, 0, 0, 0, jkt),
+		},
+		{
+			expression: "30 2 * * *", loc: nyc,
+			from: time.Date(2018, 3, 11, 0, 0, 0, 0, nyc), // 02:30 does not exist on DST start
+			want: time.Date(2018, 3, 12, 2, 30, 0, 0, nyc),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := Parse(tt.expression, tt.loc, "ENTRY_1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := e.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("got next %s want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntry_NextWithin(t *testing.T) {
+	e, err := Parse("0 0 29 2 *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	year := 365 * 24 * time.Hour
+
+	if got, ok := e.NextWithin(from, year); ok {
+		t.Errorf("got next %s within a year want none", got)
+	}
+	got, ok := e.NextWithin(from, 4*year)
+	if want := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("got next (%s, %t) within 4 years want %s", got, ok, want)
+	}
+}
+
+func TestEntry_DurationUntilNext(t *testing.T) {
+	from := time.Date(2018, 12, 15, 9, 29, 30, 0, time.UTC)
+	for _, expression := range []string{"* * * * *", "30 9 * * *", "0 0 1 1 *"} {
+		e, err := Parse(expression, time.UTC, "ENTRY_1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := e.DurationUntilNext(from)
+		if want := e.Next(from).Sub(from); !ok || got != want {
+			t.Errorf("%s: got (%s, %t) want %s", expression, got, ok, want)
+		}
+	}
+	e, err := Parse("30 9 * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := e.DurationUntilNext(from); got != 30*time.Second {
+		t.Errorf("got duration %s want %s", got, 30*time.Second)
+	}
+
+	reboot, err := Parse("@reboot", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reboot.DurationUntilNext(from); ok {
+		t.Errorf("got duration %s for @reboot want none", got)
+	}
+}
+
+func TestEntry_Fields(t *testing.T) {
+	e, err := Parse("*/15 0 1 * 1-5", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minute, hour, dom, month, dow := e.Fields()
+	tests := []struct {
+		name      string
+		got, want []int
+	}{
+		{name: "minute", got: minute, want: []int{0, 15, 30, 45}},
+		{name: "hour", got: hour, want: []int{0}},
+		{name: "day of month", got: dom, want: []int{1}},
+		{name: "month", got: month, want: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{name: "day of week", got: dow, want: []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("got %s %v want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestEntry_UTCOffsetAt(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Parse("0 9 * * *", amsterdam, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Duration
+	}{
+		{name: "winter", t: time.Date(2018, 1, 15, 8, 0, 0, 0, time.UTC), want: time.Hour},
+		{name: "summer", t: time.Date(2018, 7, 15, 8, 0, 0, 0, time.UTC), want: 2 * time.Hour},
+		// DST starts on 2018-03-25 01:00 UTC
+		{name: "before DST", t: time.Date(2018, 3, 25, 0, 59, 0, 0, time.UTC), want: time.Hour},
+		{name: "after DST", t: time.Date(2018, 3, 25, 1, 0, 0, 0, time.UTC), want: 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := entry.UTCOffsetAt(tt.t); got != tt.want {
+			t.Errorf("%s: got offset %s want %s", tt.name, got, tt.want)
+		}
+	}
+	if got := (Entry{}).UTCOffsetAt(tests[1].t); got != 0 {
+		t.Errorf("got offset %s of an entry without location want 0", got)
+	}
+}
+
+func TestEntry_WithLocation(t *testing.T) {
+	jkt, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := Parse("0 9 * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2 := e.WithLocation(jkt)
+
+	if got, want := e.Location, time.UTC; got != want {
+		t.Errorf("original entry location changed to %s", got)
+	}
+	if got, want := e2.Expression(), e.Expression(); got != want {
+		t.Errorf("got expression %q want %q", got, want)
+	}
+	if got, want := e2.schedule(), e.schedule(); got != want {
+		t.Errorf("got schedule %q want %q", got, want)
+	}
+
+	// 09:00 UTC is 16:00 in Jakarta, 09:00 in Jakarta is 02:00 UTC
+	utc9 := time.Date(2018, 12, 15, 9, 0, 0, 0, time.UTC)
+	jkt9 := time.Date(2018, 12, 15, 2, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		e    Entry
+		t    time.Time
+		want bool
+	}{
+		{e: e, t: utc9, want: true},
+		{e: e, t: jkt9, want: false},
+		{e: e2, t: utc9, want: false},
+		{e: e2, t: jkt9, want: true},
+	} {
+		if got := tt.e.Match(tt.t); got != tt.want {
+			t.Errorf("%s got match %s %t want %t", tt.e.Location, tt.t, got, tt.want)
+		}
+	}
+
+	if got, want := e2.WithLocation(nil).Location, time.UTC; got != want {
+		t.Errorf("got nil location %s want %s", got, want)
+	}
+}
+
+func TestParseField(t *testing.T) {
+	// custom field with 0-100 range does not fit the bitmap
+	if _, err := ParseField("*/10", 0, 100); err == nil {
+		t.Errorf("expected error for range 0-100")
+	}
+
+	tests := []struct {
+		field   string
+		min     int
+		max     int
+		want    string
+		wantErr bool
+	}{
+		{field: "*", min: 0, max: 63, want: "*"},
+		{field: "0-60/20", min: 0, max: 63, want: "*/20"},
+		{field: "1,50,63", min: 0, max: 63, want: "1,50,63"},
+		{field: "10-20/5", min: 10, max: 20, want: "10,15,20"},
+		{field: "9", min: 10, max: 20, wantErr: true},
+		{field: "5/15", min: 0, max: 59, want: "5,20,35,50"},
+		{field: "30/2", min: 0, max: 59, want: "30,32,34,36,38,40,42,44,46,48,50,52,54,56,58"},
+		{field: "/15", min: 0, max: 59, wantErr: true},
+		{field: "60/2", min: 0, max: 59, wantErr: true},
+		{field: "1,,3", min: 0, max: 59, wantErr: true},
+		{field: ",5", min: 0, max: 59, wantErr: true},
+		{field: "5,", min: 0, max: 59, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "1-5/-1", min: 0, max: 59, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			f, err := ParseField(tt.field, tt.min, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseField() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := f.Format(); got != tt.want {
+				t.Errorf("got %q want %q", got, tt.want)
+			}
+			if tt.want != "*" && f.Match(tt.min-1) {
+				t.Errorf("unexpected match of %d", tt.min-1)
+			}
+		})
+	}
+}
+
+func TestField_format(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     string
+	}{
+		{field: "*", min: 0, max: 59, want: "*"},
+		{field: "*/2", min: 0, max: 59, want: "*/2"},
+		{field: "*/2", min: 1, max: 31, want: "*/2"},
+		{field: "0,30", min: 0, max: 59, want: "*/30"},
+		{field: "0-58/2", min: 0, max: 59, want: "*/2"},
+		{field: "0-50/2", min: 0, max: 59, want: "0,2,4,6,8,10,12,14,16,18,20,22,24,26,28,30,32,34,36,38,40,42,44,46,48,50"},
+		{field: "10-30/3", min: 0, max: 59, want: "10,13,16,19,22,25,28"},
+		{field: "1,3,5", min: 0, max: 59, want: "1,3,5"},
+		{field: "0-59", min: 0, max: 59, want: "0-59"},
+		{field: "1-5,7,8,10-12", min: 0, max: 59, want: "1-5,7,8,10-12"},
+		{field: "0", min: 0, max: 59, want: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			f, err := ParseField(tt.field, tt.min, tt.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := f.format(tt.min, tt.max)
+			if got != tt.want {
+				t.Errorf("got %q want %q", got, tt.want)
+			}
+			if g, err := ParseField(got, tt.min, tt.max); err != nil || g != f {
+				t.Errorf("got %q parsed as %v, %v want the same field", got, g.values(tt.min, tt.max), err)
+			}
+		})
+	}
+}
+
+func TestParseField_emptyElement(t *testing.T) {
+	for _, field := range []string{"1,,3", ",5", "5,"} {
+		_, err := ParseField(field, 0, 59)
+		if got, want := fmt.Sprint(err), fmt.Sprintf("empty element in list %q", field); got != want {
+			t.Errorf("got error %q want %q", got, want)
+		}
+	}
+
+	_, err := Parse("1,,3 * * * *", time.UTC, "ENTRY_1")
+	if got, want := fmt.Sprint(err), `failed parsing 'minute' field "1,,3": empty element in list "1,,3"`; got != want {
+		t.Errorf("got error %q want %q", got, want)
+	}
+}
+
+func TestField_Next(t *testing.T) {
+	f, err := ParseField("5,17,42", 0, 59)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from   int
+		want   int
+		wantOk bool
+	}{
+		{from: 0, want: 5, wantOk: true},
+		{from: 5, want: 5, wantOk: true},
+		{from: 6, want: 17, wantOk: true},
+		{from: 42, want: 42, wantOk: true},
+		{from: 43, want: 0, wantOk: false},
+		{from: 60, want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		got, ok := f.Next(tt.from, 0, 59)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("Next(%d) got (%d, %t) want (%d, %t)", tt.from, got, ok, tt.want, tt.wantOk)
+		}
+	}
+
+	// value after max is not returned
+	if _, ok := star.Next(24, 0, 23); ok {
+		t.Errorf("got next value beyond max")
+	}
+}
+
+func TestParse_hash(t *testing.T) {
+	parse := func(expression, name string) Entry {
+		t.Helper()
+		e, err := Parse(expression, time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	// stable for the same name
+	e1, e2 := parse("H * * * *", "ENTRY_1"), parse("H * * * *", "ENTRY_1")
+	if got, want := e1.minute, e2.minute; got != want {
+		t.Errorf("got minute %s want %s", got.Format(), want.Format())
+	}
+	// spread across names
+	if e3 := parse("H * * * *", "ENTRY_2"); e3.minute == e1.minute {
+		t.Errorf("got same minute %s for different names", e3.minute.Format())
+	}
+
+	for _, name := range []string{"ENTRY_1", "ENTRY_2", "ENTRY_3", "backup", "report"} {
+		e := parse("H(10-19) H(2-4) * * H/2", name)
+		m, ok := e.minute.Next(0, 0, 59)
+		if !ok || m < 10 || m > 19 {
+			t.Errorf("got minute %s want within 10-19", e.minute.Format())
+		}
+		if e.minute != Field(1)<<uint64(m) {
+			t.Errorf("got minute %s want single value", e.minute.Format())
+		}
+		if h, ok := e.hour.Next(0, 0, 23); !ok || h < 2 || h > 4 {
+			t.Errorf("got hour %s want within 2-4", e.hour.Format())
+		}
+		if got, want := len(e.dow.values(0, 6)), 3; got < want {
+			t.Errorf("got day of week %s want at least %d values", e.dow.Format(), want)
+		}
+	}
+
+	for _, expression := range []string{"H(10-70) * * * *", "H(5) * * * *", "H(1-5 * * * *", "H/0 * * * *", "Hx * * * *"} {
+		if _, err := Parse(expression, time.UTC, "ENTRY_1"); err == nil {
+			t.Errorf("expected error for %q", expression)
+		}
+	}
+}
+
+func TestMatchAll(t *testing.T) {
+	jkt, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []Entry
+	for _, v := range []struct {
+		expression string
+		loc        *time.Location
+	}{
+		{"0 0 * * *", time.UTC},
+		{"0 7 * * *", jkt},
+		{"0 0 * * *", jkt},
+		{"* * * * *", time.UTC},
+	} {
+		e, err := Parse(v.expression, v.loc, v.expression+" "+v.loc.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	matched := MatchAll(entries, on)
+	var want []Entry
+	for _, e := range entries {
+		if e.Match(on) {
+			want = append(want, e)
+		}
+	}
+	if got, want := len(matched), 3; got != want {
+		t.Fatalf("got %d matched entries want %d", got, want)
+	}
+	for i := range want {
+		if got, want := matched[i].Name, want[i].Name; got != want {
+			t.Errorf("got entry %q want %q", got, want)
+		}
+	}
+}
+
+func benchmarkEntries(b *testing.B) []Entry {
+	locs := make([]*time.Location, 0, 4)
+	for _, name := range []string{"UTC", "Asia/Jakarta", "Europe/Amsterdam", "America/New_York"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		locs = append(locs, loc)
+	}
+
+	entries := make([]Entry, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		e, err := Parse("*/5 9-17 * * 1-5", locs[i%len(locs)], "ENTRY")
+		if err != nil {
+			b.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func BenchmarkMatch(b *testing.B) {
+	entries := benchmarkEntries(b)
+	on := time.Date(2018, 12, 17, 10, 0, 0, 0, time.UTC)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var matched []Entry
+		for _, e := range entries {
+			if e.Match(on) {
+				matched = append(matched, e)
+			}
+		}
+	}
+}
+
+func BenchmarkMatchAll(b *testing.B) {
+	entries := benchmarkEntries(b)
+	on := time.Date(2018, 12, 17, 10, 0, 0, 0, time.UTC)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MatchAll(entries, on)
+	}
+}
+
+func TestEntry_MarshalText(t *testing.T) {
+	jkt, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		expression string
+		loc        *time.Location
+		want       string
+	}{
+		{expression: "*/5 * * * 1-5", loc: DefaultLocation, want: "*/5 * * * 1-5"},
+		{expression: "0 9 * * *", loc: jkt, want: "CRON_TZ=Asia/Jakarta 0 9 * * *"},
+	} {
+		e, err := Parse(tt.expression, tt.loc, "ENTRY_1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m encoding.TextMarshaler = e
+		text, err := m.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(text), tt.want; got != want {
+			t.Errorf("got text %q want %q", got, want)
+		}
+
+		decoded := Entry{Name: "ENTRY_1"}
+		var u encoding.TextUnmarshaler = &decoded
+		if err := u.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := decoded, e; !reflect.DeepEqual(got, want) {
+			t.Errorf("got entry %+v want %+v", got, want)
+		}
+	}
+
+	var e Entry
+	if err := e.UnmarshalText([]byte("60 * * * *")); err == nil {
+		t.Errorf("expected error for invalid expression")
+	}
+	if _, err := e.MarshalText(); err == nil {
+		t.Errorf("expected error for empty entry")
+	}
+
+	// works with flag.TextVar
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var schedule Entry
+	fs.TextVar(&schedule, "schedule", &Entry{}, "schedule")
+	if err := fs.Parse([]string{"-schedule", "0 0 * * *"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := schedule.Expression(), "0 0 * * *"; got != want {
+		t.Errorf("got expression %q want %q", got, want)
+	}
+}
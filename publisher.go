@@ -0,0 +1,43 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Publisher publishes a message to a topic of a message broker, see NewPublisherHandler. Implementations for NATS and
+// Kafka are in package cronnats and cronkafka.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+}
+
+// NewPublisherHandler creates handler that publishes the triggered event as JSON to the topic returned by
+// topicFromEntry so that workers elsewhere run the job. If topicFromEntry is nil the topic is the entry name. The
+// publish error is returned as the handler error.
+func NewPublisherHandler(p Publisher, topicFromEntry func(Entry) string) HandlerFunc {
+	if topicFromEntry == nil {
+		topicFromEntry = func(e Entry) string { return e.Name }
+	}
+
+	return func(ctx context.Context, e Entry) error {
+		ev, ok := EventFromContext(ctx)
+		if !ok {
+			// the publisher sees the event like when the handler is run by the scheduler, ex: for the key of the message
+			ev = Event{Entry: e, Time: time.Now().Truncate(time.Minute)}
+			ctx = context.WithValue(ctx, eventKey{}, ev)
+		}
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			return fmt.Errorf("failed encoding event: %v", err)
+		}
+
+		topic := topicFromEntry(e)
+		if err := p.Publish(ctx, topic, payload); err != nil {
+			return fmt.Errorf("failed publishing to %q: %v", topic, err)
+		}
+
+		return nil
+	}
+}
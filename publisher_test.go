@@ -0,0 +1,82 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memPublisher keeps the published messages
+type memPublisher struct {
+	topics   []string
+	payloads [][]byte
+	events   []Event // event in the context of Publish
+	err      error
+}
+
+func (m *memPublisher) Publish(ctx context.Context, topic string, payload []byte) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.topics = append(m.topics, topic)
+	m.payloads = append(m.payloads, payload)
+	ev, _ := EventFromContext(ctx)
+	m.events = append(m.events, ev)
+	return nil
+}
+
+func TestNewPublisherHandler(t *testing.T) {
+	entry, err := Parse("*/5 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = "META"
+	ev := Event{Entry: entry, Time: time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)}
+	ctx := context.WithValue(context.Background(), eventKey{}, ev)
+
+	p := &memPublisher{}
+	handler := NewPublisherHandler(p, func(e Entry) string { return "jobs." + e.Name })
+	if err := handler(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.topics, []string{"jobs.ENTRY_1"}; len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("got topics %v want %v", got, want)
+	}
+	var got struct {
+		Entry struct {
+			Name       string `json:"name"`
+			Expression string `json:"expression"`
+			Meta       string `json:"meta"`
+		} `json:"entry"`
+		Time time.Time `json:"time"`
+	}
+	if err := json.Unmarshal(p.payloads[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Entry.Name != "ENTRY_1" || got.Entry.Expression != "*/5 * * * *" || got.Entry.Meta != "META" || !got.Time.Equal(ev.Time) {
+		t.Errorf("got event %s", p.payloads[0])
+	}
+
+	// default topic is the entry name
+	if err := NewPublisherHandler(p, nil)(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.topics[1], "ENTRY_1"; got != want {
+		t.Errorf("got topic %q want %q", got, want)
+	}
+
+	// the publisher sees the event of the handler called without the scheduler
+	if err := NewPublisherHandler(p, nil)(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.events[2].Entry.Name, "ENTRY_1"; got != want {
+		t.Errorf("got event of entry %q want %q", got, want)
+	}
+
+	p.err = errors.New("broker is down")
+	if err := handler(ctx, entry); err == nil {
+		t.Error("expected publish error")
+	}
+}
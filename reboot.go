@@ -0,0 +1,113 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// rebootExpression is the expression of the entries that fire when the scheduler starts
+const rebootExpression = "@reboot"
+
+// IsReboot reports whether the entry is parsed from `@reboot`. It fires once when Run starts (after the store is
+// initialized) and never matches on the checks. Its event is recorded on the start minute, so it is not fired again
+// when an instance starts within the same dedupe bucket (see WithDedupeGranularity), ex: a restart or another instance
+// starting on the same minute.
+func (e Entry) IsReboot() bool {
+	return e.expression == rebootExpression
+}
+
+// runReboot fires the active @reboot entries that are not triggered yet on the bucket of the start minute. Like check
+// the events are recorded while the store is locked and the handlers are dispatched after it is unlocked. Errors are
+// logged.
+func (s *Scheduler) runReboot(ctx context.Context) {
+	on := s.now().Truncate(time.Minute)
+	var fired []Event
+	err := s.withLock(ctx, func() error {
+		entries, err := s.store.GetEntries(ctx)
+		if err != nil {
+			s.metrics.IncError(ErrorKindStore)
+			return fmt.Errorf("failed to get entries: %v", err)
+		}
+		var (
+			reboot []Entry
+			names  []string
+		)
+		for _, e := range entries {
+			if e.IsReboot() && !e.Paused && e.Name != "" {
+				reboot = append(reboot, e)
+				names = append(names, e.Name)
+			}
+		}
+		if len(reboot) == 0 {
+			return nil
+		}
+		events, err := getEventsForEntries(ctx, s.store, names, on.Truncate(s.dedupe), on.Add(time.Minute))
+		if err != nil {
+			s.metrics.IncError(ErrorKindStore)
+			return fmt.Errorf("failed to get events: %v", err)
+		}
+		triggered := make(map[triggeredKey]bool)
+		for _, ev := range events {
+			if !ev.Manual {
+				triggered[s.triggeredKey(ev.Entry.Name, ev.Time)] = true
+			}
+		}
+
+		var pending []Event
+		for _, e := range reboot {
+			event := Event{Entry: e, Time: on, FiredBy: s.instance.ID, IdempotencyKey: IdempotencyKey(e.Name, on)}
+			if triggered[s.triggeredKey(e.Name, on)] {
+				s.metrics.IncSkipped(e.Name, SkipAlreadyTriggered)
+				s.incStats(&s.stats.Skipped)
+				s.logger.Debug("reboot entry already triggered", s.entryKV(e, "at", on)...)
+				s.onSkip(event)
+				continue
+			}
+			if s.dryRun {
+				event.DryRun = true
+				s.logger.Info("entry would trigger", s.entryKV(e, "at", on)...)
+				s.onTrigger(event)
+				continue
+			}
+			pending = append(pending, event)
+		}
+		for i, err := range s.addEvents(ctx, pending) {
+			event := pending[i]
+			if errors.Is(err, ErrAlreadyTriggered) {
+				s.metrics.IncSkipped(event.Entry.Name, SkipAlreadyTriggered)
+				s.incStats(&s.stats.Skipped)
+				s.onSkip(event)
+				continue
+			}
+			if err != nil {
+				s.logger.Error("failed to store event", s.entryKV(event.Entry, "at", on, "error", err)...)
+				s.log(fmt.Errorf("failed to store event: %v", err))
+				continue
+			}
+			fired = append(fired, event)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("firing reboot entries failed", "error", err)
+		s.log(err)
+	}
+
+	for _, event := range fired {
+		fn, err := s.handlerFor(event.Entry)
+		if err != nil {
+			s.metrics.IncError(ErrorKindNoHandler)
+			s.incStats(&s.stats.Errored)
+			s.logger.Error("no handler", s.entryKV(event.Entry, "at", on)...)
+			s.log(err)
+			continue
+		}
+		s.metrics.IncTriggered(event.Entry.Name)
+		s.incStats(&s.stats.Triggered)
+		s.logger.Info("entry triggered on start", s.entryKV(event.Entry, "at", on)...)
+		s.onTrigger(event)
+		s.goRun(ctx, fn, event)
+	}
+}
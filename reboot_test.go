@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParse_reboot(t *testing.T) {
+	e, err := Parse("@reboot # cleanup", time.UTC, "CLEANUP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.IsReboot() || e.Expression() != "@reboot" || e.Meta != "cleanup" {
+		t.Errorf("got reboot %t expression %q meta %q", e.IsReboot(), e.Expression(), e.Meta)
+	}
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 24*60; i++ {
+		if on := now.Add(time.Duration(i) * time.Minute); e.Match(on) {
+			t.Fatalf("reboot entry matches %s", on)
+		}
+	}
+	if warnings := e.Validate(); len(warnings) != 0 {
+		t.Errorf("got warnings %v", warnings)
+	}
+
+	// the other macros are still not supported
+	if _, err := Parse("@daily", time.UTC, "DAILY"); err == nil {
+		t.Error("expected error for @daily")
+	}
+}
+
+func TestScheduler_reboot(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 5, 30, 0, time.UTC)
+	reboot, err := Parse("@reboot", time.UTC, "REBOOT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, reboot)
+
+	// start runs the scheduler on the store at the time with a few ticks and returns the number of reboot runs
+	start := func(at time.Time, opts ...Option) int {
+		var h countHandler
+		s := NewScheduler(h.handle, store, opts...)
+		s.now = func() time.Time { return at }
+		ticks, done := runWithTicks(t, s)
+		for i := 1; i <= 3; i++ {
+			ticks <- at.Truncate(time.Minute).Add(time.Duration(i) * time.Minute)
+		}
+		s.Stop()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		return h.count("REBOOT")
+	}
+
+	if got, want := start(now), 1; got != want {
+		t.Errorf("got %d runs on start want %d", got, want)
+	}
+	// a restart on the same minute or dedupe bucket does not fire again
+	if got, want := start(now.Add(20*time.Second)), 0; got != want {
+		t.Errorf("got %d runs on restart within the minute want %d", got, want)
+	}
+	if got, want := start(now.Add(10*time.Minute), WithDedupeGranularity(time.Hour)), 0; got != want {
+		t.Errorf("got %d runs on restart within the hour want %d", got, want)
+	}
+	if got, want := start(now.Add(10*time.Minute)), 1; got != want {
+		t.Errorf("got %d runs on a later start want %d", got, want)
+	}
+}
@@ -0,0 +1,36 @@
+package cron
+
+import "time"
+
+// WithEventRetention sets how long the events are kept in the store (default KeepEventDuration) and how often check
+// deletes the older events (default on every check). keep of zero or less keeps the events forever. Every instance
+// deletes the events on its own interval.
+func WithEventRetention(keep, every time.Duration) Option {
+	return func(s *Scheduler) {
+		s.retention = &keep
+		s.pruneEvery = every
+	}
+}
+
+// keepEvents returns how long the events are kept, zero or less is forever
+func (s *Scheduler) keepEvents() time.Duration {
+	if s.retention != nil {
+		return *s.retention
+	}
+	return KeepEventDuration
+}
+
+// pruneDue reports whether check on on deletes the old events, it records the time of the deletion
+func (s *Scheduler) pruneDue(on time.Time) bool {
+	if s.keepEvents() <= 0 {
+		return false
+	}
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if !s.lastPrune.IsZero() && on.Sub(s.lastPrune) < s.pruneEvery {
+		return false
+	}
+	s.lastPrune = on
+	return true
+}
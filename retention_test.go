@@ -0,0 +1,67 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler_eventRetention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	entry, err := Parse("0 0 1 1 *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		keep   time.Duration
+		every  time.Duration
+		checks []time.Duration // offsets of check from now
+		old    []time.Duration // offsets of the events added before each check, relative to the check
+		want   []int           // events in the store after each check
+	}{
+		{
+			name: "every check", keep: time.Hour,
+			checks: []time.Duration{0, time.Minute},
+			old:    []time.Duration{-2 * time.Hour, -2 * time.Hour},
+			want:   []int{0, 0},
+		},
+		{
+			name: "interval", keep: time.Hour, every: 10 * time.Minute,
+			checks: []time.Duration{0, time.Minute, 10 * time.Minute},
+			old:    []time.Duration{-2 * time.Hour, -2 * time.Hour, -2 * time.Hour},
+			want:   []int{0, 1, 0},
+		},
+		{
+			name: "forever", keep: 0,
+			checks: []time.Duration{0, time.Minute},
+			old:    []time.Duration{-2 * time.Hour, -2 * time.Hour},
+			want:   []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MemStore{}
+			store.AddEntry(ctx, entry)
+			s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store,
+				WithEventRetention(tt.keep, tt.every))
+
+			for i, offset := range tt.checks {
+				on := now.Add(offset)
+				store.AddEvent(ctx, Event{Entry: entry, Time: on.Add(tt.old[i]).Add(time.Duration(i) * time.Second)})
+				if err := s.check(ctx, on); err != nil {
+					t.Fatal(err)
+				}
+				got, err := store.CountEvents(ctx, time.Time{}, on)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != int64(tt.want[i]) {
+					t.Errorf("[%d] got %d events want %d", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// retryPolicy of the store operations in check
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration // wait before the second attempt, doubled on each attempt
+	budget   time.Duration // total time since the start of check after which it gives up
+}
+
+// default retry is 3 attempts within 20 seconds so that it does not cross into the next minute
+var defaultRetry = retryPolicy{attempts: 3, backoff: time.Second, budget: 20 * time.Second}
+
+// WithRetry sets how check retries locking the store and reading the entries (default 3 attempts, 1 second
+// backoff doubled on each attempt, 20 seconds budget). budget should be less than a minute so that a check does not
+// cross into the next minute. Recording an event is retried once. attempts of 1 disables retry.
+func WithRetry(attempts int, backoff, budget time.Duration) Option {
+	return func(s *Scheduler) {
+		if attempts < 1 {
+			attempts = 1
+		}
+		s.retry = retryPolicy{attempts: attempts, backoff: backoff, budget: budget}
+	}
+}
+
+// withRetry calls op until it succeeds, the attempts or the budget since start are exhausted or ctx is done.
+// It returns the last error.
+func (s *Scheduler) withRetry(ctx context.Context, start time.Time, op func() error) error {
+	wait := s.retry.backoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		s.metrics.IncError(ErrorKindStore)
+		if attempt >= s.retry.attempts || time.Since(start)+wait > s.retry.budget {
+			return err
+		}
+		s.logger.Error("store operation failed, retrying", "attempt", attempt, "backoff", wait, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// lockAndRead acquires the coordinator and reads the active entries and the events of the entries matching on, from
+// the start of the dedupe bucket of on until on+1 minute. acquired is false when another instance runs the check. The
+// coordinator is released on error. Every attempt starts from acquiring since a failed read may leave the lock (ex:
+// transaction) unusable. The returned context is the one given by the coordinator. A failed read of the events is not
+// an error, the events triggered by this scheduler are returned instead so that an entry is not triggered twice by the
+// same scheduler.
+func (s *Scheduler) lockAndRead(ctx context.Context, start, on time.Time) (_ context.Context, acquired bool, entries []Entry, events []Event, err error) {
+	actx := ctx
+	err = s.withRetry(ctx, start, func() error {
+		var err error
+		if actx, acquired, err = s.coordinator.Acquire(ctx); err != nil {
+			return fmt.Errorf("locking store failed: %v", err)
+		}
+		if !acquired {
+			return nil
+		}
+		if entries, err = s.activeEntries(actx); err != nil {
+			s.coordinator.Release(actx)
+			return fmt.Errorf("failed to get entries: %v", err)
+		}
+		from := on.Truncate(s.dedupe)
+		if events, err = getEventsForEntries(actx, s.store, candidates(entries, on), from, on.Add(time.Minute)); err != nil {
+			// a failed read does not prevent the entries from triggering, only the events triggered by this scheduler
+			// are known
+			s.metrics.IncError(ErrorKindStore)
+			s.logger.Error("failed to get events, checking with the events of this scheduler", "at", on, "error", err)
+			s.log(fmt.Errorf("failed to get events: %v", err))
+			events = s.firedEvents(on)
+		}
+		return nil
+	})
+
+	return actx, acquired, entries, events, err
+}
+
+// candidates returns the names of the entries that may trigger on
+func candidates(entries []Entry, on time.Time) []string {
+	var names []string
+	for _, e := range entries {
+		if e.Name != "" && !e.Paused && e.Match(on) {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+// addEvent records the event, it is retried once since a failure causes a missed run. ErrAlreadyTriggered and
+// ErrLeaseLost are not retried.
+func (s *Scheduler) addEvent(ctx context.Context, event Event) error {
+	err := s.store.AddEvent(ctx, event)
+	if err == nil || errors.Is(err, ErrAlreadyTriggered) {
+		return err
+	}
+	s.metrics.IncError(ErrorKindStore)
+	if errors.Is(err, ErrLeaseLost) {
+		return err
+	}
+	s.logger.Error("failed to store event, retrying", s.entryKV(event.Entry, "at", event.Time, "error", err)...)
+	if err := s.store.AddEvent(ctx, event); err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		return err
+	}
+
+	return nil
+}
+
+// addEvents records the events and returns the error of each event by index. The events are recorded in one batch if
+// the store implements EventsAdder, the failed events are then retried one by one. ErrAlreadyTriggered and
+// ErrLeaseLost are not retried.
+func (s *Scheduler) addEvents(ctx context.Context, events []Event) []error {
+	errs := make([]error, len(events))
+	a, ok := s.store.(EventsAdder)
+	if !ok || len(events) < 2 {
+		for i, e := range events {
+			errs[i] = s.addEvent(ctx, e)
+		}
+		return errs
+	}
+
+	err := a.AddEvents(ctx, events)
+	if err == nil {
+		return errs
+	}
+	var evErrs EventErrors
+	if !errors.As(err, &evErrs) || len(evErrs) != len(events) {
+		// the whole batch failed
+		evErrs = make(EventErrors, len(events))
+		for i := range evErrs {
+			evErrs[i] = err
+		}
+	}
+	for i, err := range evErrs {
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrAlreadyTriggered) {
+			errs[i] = err
+			continue
+		}
+		s.metrics.IncError(ErrorKindStore)
+		if errors.Is(err, ErrLeaseLost) {
+			errs[i] = err
+			continue
+		}
+		s.logger.Error("failed to store event, retrying", s.entryKV(events[i].Entry, "at", events[i].Time, "error", err)...)
+		if err := s.store.AddEvent(ctx, events[i]); err != nil {
+			s.metrics.IncError(ErrorKindStore)
+			errs[i] = err
+		}
+	}
+
+	return errs
+}
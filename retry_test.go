@@ -0,0 +1,226 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// flakyStore fails the first n calls of each operation
+type flakyStore struct {
+	*MemStore
+	failLock, failGetEntries, failGetEvents, failAddEvent int
+}
+
+func (f *flakyStore) Lock(ctx context.Context) error {
+	if f.failLock > 0 {
+		f.failLock--
+		return errors.New("lock failed")
+	}
+	return f.MemStore.Lock(ctx)
+}
+
+func (f *flakyStore) GetEntries(ctx context.Context, opts ...EntriesOption) ([]Entry, error) {
+	if f.failGetEntries > 0 {
+		f.failGetEntries--
+		return nil, errors.New("get entries failed")
+	}
+	return f.MemStore.GetEntries(ctx, opts...)
+}
+
+func (f *flakyStore) GetEvents(ctx context.Context, from, to time.Time) ([]Event, error) {
+	if f.failGetEvents > 0 {
+		f.failGetEvents--
+		return nil, errors.New("get events failed")
+	}
+	return f.MemStore.GetEvents(ctx, from, to)
+}
+
+func (f *flakyStore) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]Event, error) {
+	events, err := f.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return filterEvents(events, names), nil
+}
+
+func (f *flakyStore) AddEvents(ctx context.Context, events []Event) error {
+	return addEachEvent(ctx, f, events)
+}
+
+func (f *flakyStore) AddEvent(ctx context.Context, e Event) error {
+	if f.failAddEvent > 0 {
+		f.failAddEvent--
+		return errors.New("add event failed")
+	}
+	return f.MemStore.AddEvent(ctx, e)
+}
+
+func TestScheduler_checkRetry(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		store     flakyStore
+		wantErr   bool
+		wantFired bool
+	}{
+		{name: "lock", store: flakyStore{failLock: 2}, wantFired: true},
+		{name: "get entries", store: flakyStore{failGetEntries: 1, failGetEvents: 1}, wantFired: true},
+		{name: "add event", store: flakyStore{failAddEvent: 1}, wantFired: true},
+		{name: "add event twice", store: flakyStore{failAddEvent: 2}},
+		{name: "exhausted", store: flakyStore{failLock: 3}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+			store.MemStore = &MemStore{}
+			entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			store.MemStore.AddEntry(ctx, entry)
+
+			fired := make(chan struct{}, 1)
+			s := NewScheduler(func(ctx context.Context, e Entry) error {
+				fired <- struct{}{}
+				return nil
+			}, &store, WithRetry(3, time.Millisecond, time.Second))
+
+			err = s.check(ctx, on)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantFired {
+				select {
+				case <-fired:
+				case <-time.After(time.Second):
+					t.Fatal("entry is not triggered")
+				}
+			}
+			if got, want := len(store.MemStore.events) > 0, tt.wantFired; got != want {
+				t.Errorf("got recorded event %t want %t", got, want)
+			}
+
+			// every successful lock is unlocked
+			if !store.MemStore.Mutex.TryLock() {
+				t.Errorf("store is still locked")
+			}
+		})
+	}
+}
+
+func TestScheduler_checkRetryBudget(t *testing.T) {
+	store := &flakyStore{MemStore: &MemStore{}, failLock: 3}
+	s := NewScheduler(nil, store, WithRetry(3, 50*time.Millisecond, 10*time.Millisecond))
+
+	start := time.Now()
+	if err := s.check(context.Background(), start); err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := store.failLock, 2; got != want {
+		t.Errorf("got %d remaining failures want %d, retried beyond the budget", got, want)
+	}
+}
+
+// batchStore records the batches of AddEvents and fails the events of the entries in fail
+type batchStore struct {
+	*MemStore
+	batches [][]Event
+	fail    map[string]bool
+}
+
+func (b *batchStore) AddEvents(ctx context.Context, events []Event) error {
+	b.batches = append(b.batches, events)
+	return addEachEvent(ctx, b, events)
+}
+
+func (b *batchStore) AddEvent(ctx context.Context, e Event) error {
+	if b.fail[e.Entry.Name] {
+		return errors.New("bad row")
+	}
+	return b.MemStore.AddEvent(ctx, e)
+}
+
+func TestScheduler_checkAddEvents(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	store := &batchStore{MemStore: &MemStore{}, fail: map[string]bool{"ENTRY_2": true}}
+	for _, name := range []string{"ENTRY_1", "ENTRY_2", "ENTRY_3"} {
+		entry, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.MemStore.AddEntry(ctx, entry)
+	}
+
+	fired := make(chan string, 3)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		fired <- e.Name
+		return nil
+	}, store, WithRetry(1, 0, time.Second))
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	s.inFlight.Wait()
+	close(fired)
+
+	if got, want := len(store.batches), 1; got != want {
+		t.Fatalf("got %d batches want %d", got, want)
+	}
+	if got, want := len(store.batches[0]), 3; got != want {
+		t.Errorf("got %d events in the batch want %d", got, want)
+	}
+	// the failed event does not prevent the others from running
+	got := make(map[string]bool)
+	for name := range fired {
+		got[name] = true
+	}
+	if want := map[string]bool{"ENTRY_1": true, "ENTRY_3": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got triggered %v want %v", got, want)
+	}
+	if got, want := len(store.MemStore.events), 2; got != want {
+		t.Errorf("got %d recorded events want %d", got, want)
+	}
+}
+
+func TestEventErrors(t *testing.T) {
+	err := error(EventErrors{nil, errors.New("bad row"), ErrLeaseLost})
+	if got, want := err.Error(), "failed to add 2 of 3 events: bad row"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestScheduler_checkGetEventsFailed(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	store := &flakyStore{MemStore: &MemStore{}, failGetEvents: 10}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.MemStore.AddEntry(ctx, entry)
+
+	fired := make(chan struct{}, 3)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		fired <- struct{}{}
+		return nil
+	}, store, WithRetry(1, 0, time.Second))
+
+	// the entry is triggered without the events, and only once by the same scheduler on the same minute
+	for _, at := range []time.Time{on, on, on.Add(time.Minute)} {
+		if err := s.check(ctx, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.inFlight.Wait()
+	if got, want := len(fired), 2; got != want {
+		t.Errorf("got %d triggers want %d", got, want)
+	}
+	if got, want := len(store.MemStore.events), 2; got != want {
+		t.Errorf("got %d recorded events want %d", got, want)
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // ErrorCh contain error that can not be passed as return value. This gives flexibility to the user to handle err.
 // For example if user are using custom logger. If user do not read the channel that error will be silently ignored
+//
+// Deprecated: use Scheduler.ErrCh. ErrorCh receives the errors of every scheduler in the process, it is kept as a copy
+// of the scheduler errors until the errors are only reported by the Logger.
 var ErrorCh = make(chan error, 1)
 
 // KeepEventDuration in days. Recorded events outside of this duration (default 30 days) will be cleanup from the store.
+// It can be set per scheduler with WithEventRetention.
 var KeepEventDuration = 30 * 24 * time.Hour
 
 func log(err error) {
@@ -21,96 +27,372 @@ func log(err error) {
 	}
 }
 
+// errChSize is the buffer of Scheduler.ErrCh
+const errChSize = 16
+
+// ErrCh returns the channel of the errors of this scheduler that can not be passed as return value (ex: a failed
+// handler). Like ErrorCh, an error is dropped when the channel is full. The errors are also sent to ErrorCh.
+func (s *Scheduler) ErrCh() <-chan error {
+	return s.errCh
+}
+
+// LastError returns the most recent error sent to ErrCh, nil if there is none. The error is kept until another error
+// is reported, ex: for a quick health check without reading ErrCh.
+func (s *Scheduler) LastError() error {
+	if err := s.lastError.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// log sends the error to the channel of the scheduler and to ErrorCh
+func (s *Scheduler) log(err error) {
+	s.lastError.Store(&err)
+	select {
+	case s.errCh <- err:
+	default:
+	}
+	log(err)
+}
+
+// ErrEntryNotFound is returned when an entry with the given name does not exist
+var ErrEntryNotFound = errors.New("entry not found")
+
+// ErrAlreadyTriggered is returned by Store.AddEvent when the entry is already triggered on the same time, ex: by
+// another instance. check skips the entry so that it is triggered at most once.
+var ErrAlreadyTriggered = errors.New("entry already triggered")
+
 // event is record of executed entry
 type Event struct {
-	Entry Entry
-	Time  time.Time
+	Entry Entry     `json:"entry"`
+	Time  time.Time `json:"time"`
+	// Manual is set for event triggered by TriggerNow. It does not prevent the scheduled trigger on the same minute.
+	Manual bool `json:"manual,omitempty"`
+	// DryRun is set for event that would be triggered, see WithDryRun and Scheduler.Simulate. It is never recorded.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Status is empty for a triggered event (EventStatusClaimed with AtLeastOnce delivery), or
+	// EventStatusSuppressedBlackout. The result of the handler is recorded as EventStatusSucceeded or
+	// EventStatusFailed, see EventStatusStore.
+	Status string `json:"status,omitempty"`
+	// FiredBy is the ID of the instance that triggered the event, see WithInstance
+	FiredBy string `json:"fired_by,omitempty"`
+	// Duration is the run time of the handler, it is only set on the events sent to Scheduler.Subscribe
+	Duration time.Duration `json:"duration,omitempty"`
+	// IdempotencyKey is the same for every run of the scheduled event of the entry on the minute, see IdempotencyKey.
+	// The event of TriggerNow has a unique key. SqlStore does not record it, the key of a scheduled event can be
+	// computed again with IdempotencyKey.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
-type handler func(e Entry)
+// HandlerFunc is called by the scheduler when an entry is triggered. ctx is the context given to Run.
+// Returned error is sent to Scheduler.ErrCh.
+type HandlerFunc func(ctx context.Context, e Entry) error
 
 type Scheduler struct {
-	handler handler
-	store   Store
+	store       Store
+	coordinator Coordinator
+
+	mu           sync.RWMutex
+	handler      HandlerFunc // default handler for entries that do not match any registered handler
+	handlers     map[string]HandlerFunc
+	metaHandlers []metaHandler
+	middlewares  []Middleware
+
+	hooks       []Hooks
+	heartbeatFn func(t time.Time, matched int)
+	tracer      Tracer
+	metrics     Metrics
+	logger      Logger
+	errCh       chan error            // see ErrCh
+	lastError   atomic.Pointer[error] // see LastError
+	verbose     bool
+	now         func() time.Time
+	dryRun      bool
+
+	dedupe         time.Duration // dedupe granularity, see WithDedupeGranularity
+	blackouts      []Blackout
+	blackoutRecord bool
+	defaultTimeout time.Duration
+	retry          retryPolicy
+
+	dependsTimeout time.Duration // wait for the dependencies, see WithDependencyWait
+	dependsPolicy  DependencyPolicy
+	dependsPoll    time.Duration
+
+	delivery       DeliveryMode
+	staleClaim     time.Duration // see WithDeliveryMode
+	recordAfterRun bool          // see WithRecordAfterRun
+
+	instance     Instance
+	minInstances int
+
+	immediateFirstCheck bool
+	lastTick            time.Time // last minute checked by Run
+
+	statusMu  sync.Mutex
+	running   map[string]int                           // number of handlers in flight by entry name
+	lastErr   map[string]error                         // error of the last handler run by entry name
+	cancels   map[string]map[uint64]context.CancelFunc // cancel of handlers in flight by entry name
+	runSeq    uint64
+	stats     Stats
+	firedOn   time.Time             // dedupe bucket of fired
+	fired     []string              // entries triggered by this scheduler on firedOn, see firedEvents
+	validated map[validatedKey]bool // entries checked by reportWarnings
+	lastPrune time.Time             // last deletion of the old events, see pruneDue
+
+	maxPerHour int            // see WithFrequencyWarning
+	retention  *time.Duration // nil is KeepEventDuration, see WithEventRetention
+	pruneEvery time.Duration
+
+	cache entryCache
+
+	subMu       sync.Mutex
+	subscribers map[chan Event]struct{} // see Subscribe
+
+	after    func(d time.Duration) <-chan time.Time // time.After, replaced in tests
+	started  atomic.Bool                            // Run is running
+	runMu    sync.Mutex
+	stop     chan struct{}  // closed by Stop
+	done     chan struct{}  // closed when Run returns
+	inFlight sync.WaitGroup // handlers that are running, see Stop
 }
 
-func NewScheduler(handlerFn handler, store Store) *Scheduler {
+// ErrAlreadyRunning is returned by Run when the scheduler is already running
+var ErrAlreadyRunning = errors.New("scheduler is already running")
+
+// Option configures the scheduler
+type Option func(s *Scheduler)
+
+// NewScheduler creates scheduler with handlerFn as the default handler. The default handler is called for triggered
+// entries that do not match any handler registered with Handle or HandleMeta. handlerFn can be nil if every entry
+// has a registered handler.
+func NewScheduler(handlerFn HandlerFunc, store Store, opts ...Option) *Scheduler {
 	s := &Scheduler{
-		handler: handlerFn,
-		store:   store,
+		handler:   handlerFn,
+		handlers:  make(map[string]HandlerFunc),
+		store:     store,
+		metrics:   nopMetrics{},
+		logger:    nopLogger{},
+		errCh:     make(chan error, errChSize),
+		validated: make(map[validatedKey]bool),
+		now:       time.Now,
+		running:   make(map[string]int),
+		lastErr:   make(map[string]error),
+		cancels:   make(map[string]map[uint64]context.CancelFunc),
+		retry:     defaultRetry,
+		after:     time.After,
+		dedupe:    time.Minute,
+
+		dependsTimeout: defaultDependencyTimeout,
+		dependsPoll:    defaultDependencyPoll,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.coordinator == nil {
+		s.coordinator = NewLockCoordinator(store)
 	}
 
 	return s
 }
 
+// Run checks the entries on every minute until ctx is done or Stop is called. It returns ctx.Err() when ctx is done
+// and nil when it is stopped with Stop. It returns ErrAlreadyRunning if Run is called again before it returns, the
+// scheduler can be run again after that.
 func (s *Scheduler) Run(ctx context.Context) error {
+	if !s.started.CompareAndSwap(false, true) {
+		return ErrAlreadyRunning
+	}
+	defer s.started.Store(false)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.runMu.Lock()
+	s.stop, s.done = stop, done
+	s.runMu.Unlock()
+	defer close(done)
+
 	err := s.store.Initialize(ctx)
 	if err != nil {
+		s.logger.Error("failed to initialize store", "error", err)
 		return fmt.Errorf("failed to initialize store: %v", err)
 	}
+	s.logger.Info("scheduler started")
+	// the scheduler is alive once the @reboot entries are fired
+	s.runReboot(ctx)
+	s.setAlive(true)
+	defer s.setAlive(false)
+	s.recoverClaims(ctx)
 
-	// align with next minute
-	now := time.Now()
-	nextRun := time.Now().Truncate(time.Minute).Add(time.Minute)
-	delay := nextRun.Sub(now)
-	time.Sleep(delay)
-	now = time.Now()
-	if err := s.check(ctx, now); err != nil {
-		log(fmt.Errorf("failed to do check on %s: %v", now, err))
+	if s.immediateFirstCheck {
+		s.lastTick = s.now().Truncate(time.Minute)
+		s.runCheck(ctx, s.lastTick)
 	}
 
-	ticker := time.NewTicker(time.Minute)
 	for {
+		// the next minute is computed on every tick so that the ticks do not drift from the minute boundary. It is
+		// after the last checked minute when the timer fired early or the clock went backwards.
+		now := s.now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		if !next.After(s.lastTick) {
+			next = s.lastTick.Add(time.Minute)
+		}
 		select {
 		case <-ctx.Done():
-			ticker.Stop()
-			return nil
-		case t := <-ticker.C:
-			if err := s.check(ctx, t); err != nil {
-				log(fmt.Errorf("failed to do check on %s: %v", t, err))
-			}
+			s.logger.Info("scheduler stopped")
+			return ctx.Err()
+		case <-stop:
+			return s.drain()
+		case t := <-s.after(next.Sub(now)):
+			s.tick(ctx, next, t)
 		}
 	}
+}
+
+// earlyTickTolerance is how early a timer can fire to be checked on the expected minute
+const earlyTickTolerance = time.Second
+
+// tick checks the minute of t, the time the timer for next fired. A timer that fires slightly early is checked on next.
+// When the clock jumped forward (ex: NTP step or VM pause) the skipped minutes are not checked, the jump is reported
+// and the ticks are aligned with the new clock. When the clock jumped backwards the minutes that are already checked
+// are skipped.
+func (s *Scheduler) tick(ctx context.Context, next, t time.Time) {
+	switch {
+	case !t.Before(next):
+	case next.Sub(t) <= earlyTickTolerance:
+		t = next
+	case !t.Truncate(time.Minute).After(s.lastTick):
+		s.incStats(&s.stats.ClockJumps)
+		s.logger.Error("clock jumped backward", "at", t, "last", s.lastTick)
+		s.log(fmt.Errorf("clock jumped backward to %s, minutes until %s are already checked", t, s.lastTick))
+		return
+	}
+	if gap := t.Truncate(time.Minute).Sub(next); gap > 0 {
+		s.incStats(&s.stats.ClockJumps)
+		s.logger.Error("clock jumped forward", "from", next, "to", t, "skipped", gap)
+		s.log(fmt.Errorf("clock jumped forward from %s to %s, %d minutes are not checked", next, t, int(gap/time.Minute)))
+	}
+
+	s.runCheck(ctx, t)
+	s.lastTick = t.Truncate(time.Minute)
+}
+
+// runCheck checks the minute of t, the error is logged
+func (s *Scheduler) runCheck(ctx context.Context, t time.Time) {
+	if err := s.check(ctx, t); err != nil {
+		s.logger.Error("check failed", "at", t, "error", err)
+		s.log(fmt.Errorf("failed to do check on %s: %v", t, err))
+	}
+}
+
+// WithImmediateFirstCheck makes Run check the current minute right away instead of waiting for the next minute. An
+// entry that is already triggered on the current minute (ex: by another instance or before a restart) is not
+// triggered again.
+func WithImmediateFirstCheck() Option {
+	return func(s *Scheduler) {
+		s.immediateFirstCheck = true
+	}
+}
+
+// Stop signals Run to return without canceling its context and waits until it returns: the current check completes
+// and the running handlers return. It must not be called from a handler. It is a no-op if the scheduler is not
+// running.
+func (s *Scheduler) Stop() {
+	s.runMu.Lock()
+	stop, done := s.stop, s.done
+	if stop != nil {
+		select {
+		case <-stop:
+		default:
+			close(stop)
+		}
+	}
+	s.runMu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// drain waits for the running handlers after Stop
+func (s *Scheduler) drain() error {
+	s.logger.Info("scheduler stopping, waiting for running handlers")
+	s.inFlight.Wait()
+	s.logger.Info("scheduler stopped")
 
 	return nil
 }
 
-func (s *Scheduler) check(ctx context.Context, on time.Time) error {
+// check triggers entries that match the minute of on and have not been triggered yet for that minute (or the dedupe
+// bucket, see WithDedupeGranularity).
+// on is truncated to the minute so that every instance agrees on the event time regardless of ticker jitter.
+func (s *Scheduler) check(ctx context.Context, on time.Time) (err error) {
+	on = on.Truncate(time.Minute)
+	start := time.Now()
+	matched := 0
+	defer func() {
+		s.metrics.ObserveCheckDuration(time.Since(start))
+		s.recordCheck(err)
+		if err == nil {
+			s.onHeartbeat(on, matched)
+		}
+	}()
+	if s.tracer != nil {
+		var end func(err error)
+		ctx, end = s.tracer.StartCheck(ctx, on)
+		defer func() { end(err) }()
+	}
+
 	if s.store == nil {
 		return errors.New("empty store")
 	}
-	err := s.store.Lock(ctx)
+	s.recordInstance(ctx)
+	ctx, acquired, entries, events, err := s.lockAndRead(ctx, start, on)
 	if err != nil {
-		return fmt.Errorf("locking store failed: %v", err)
+		s.logger.Error("reading store failed", "at", on, "error", err)
+		return err
 	}
-	defer s.store.Unlock(ctx)
-
-	entries, err := s.store.GetEntries(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get entries: %v", err)
+	if !acquired {
+		s.logger.Debug("check is run by another instance", "at", on)
+		return nil
 	}
-	until := on.Add(time.Minute)
-	events, err := s.store.GetEvents(ctx, on, until)
-	if err != nil {
-		return fmt.Errorf("failed to get events: %v", err)
+	// the coordinator is released before the handlers are dispatched, see below
+	released := false
+	release := func() {
+		if !released {
+			released = true
+			s.coordinator.Release(ctx)
+		}
 	}
+	defer release()
+	s.logger.Debug("check", "at", on, "entries", len(entries), "events", len(events))
 
-	mapTriggeredEvents := make(map[string]struct{})
-	timestampLayout := "2006-01-02-15-04"
+	mapTriggeredEvents := make(map[triggeredKey]struct{})
 	for _, e := range events {
+		if e.Manual {
+			continue
+		}
 		if e.Entry.Name == "" {
-			log(fmt.Errorf("got empty name for an event entry %+v", e.Entry))
+			s.log(fmt.Errorf("got empty name for an event entry %+v", e.Entry))
 			continue
 		}
-		key := e.Entry.Name + "|" + e.Time.Format(timestampLayout)
-		mapTriggeredEvents[key] = struct{}{}
+		mapTriggeredEvents[s.triggeredKey(e.Entry.Name, e.Time)] = struct{}{}
+	}
+	if s.recordAfterRun {
+		// the events of the handlers still running are not recorded yet
+		for _, e := range s.firedEvents(on) {
+			mapTriggeredEvents[s.triggeredKey(e.Entry.Name, e.Time)] = struct{}{}
+		}
 	}
 
 	// for each entries, figure which matched and not triggered yet
-	onTimestamp := on.Format(timestampLayout)
+	blackout := s.inBlackout(on)
+	var pending []Event
 	for _, e := range entries {
 		if e.Name == "" {
-			log(fmt.Errorf("got empty name for an event entry %+v", e))
+			s.log(fmt.Errorf("got empty name for an event entry %+v", e))
+			continue
+		}
+		if e.Paused {
 			continue
 		}
 
@@ -118,23 +400,325 @@ func (s *Scheduler) check(ctx context.Context, on time.Time) error {
 			continue
 		}
 
-		key := e.Name + "|" + onTimestamp
-		if _, ok := mapTriggeredEvents[key]; !ok {
-			event := Event{
-				Entry: e,
-				Time:  on,
+		event := Event{
+			Entry:          e,
+			Time:           on,
+			FiredBy:        s.instance.ID,
+			IdempotencyKey: IdempotencyKey(e.Name, on),
+		}
+		if _, ok := mapTriggeredEvents[s.triggeredKey(e.Name, on)]; ok {
+			// already triggered, most likely by another instance
+			s.metrics.IncSkipped(e.Name, SkipAlreadyTriggered)
+			s.incStats(&s.stats.Skipped)
+			s.logger.Debug("entry already triggered", s.entryKV(e, "at", on)...)
+			s.onSkip(event)
+			continue
+		}
+
+		if blackout && !e.IgnoreBlackout {
+			s.metrics.IncSkipped(e.Name, SkipBlackout)
+			s.incStats(&s.stats.Skipped)
+			s.logger.Info("entry suppressed by blackout", s.entryKV(e, "at", on)...)
+			event.Status = EventStatusSuppressedBlackout
+			if s.blackoutRecord && !s.dryRun {
+				if err := s.addEvent(ctx, event); err != nil {
+					s.logger.Error("failed to store event", s.entryKV(e, "at", on, "error", err)...)
+					s.log(fmt.Errorf("failed to store event: %v", err))
+				}
 			}
-			if err := s.store.AddEvent(ctx, event); err != nil {
-				log(fmt.Errorf("failed to store event: %v", err))
+			s.onSkip(event)
+			continue
+		}
+
+		if s.dryRun {
+			event.DryRun = true
+			matched++
+			s.logger.Info("entry would trigger", s.entryKV(e, "at", on)...)
+			s.onTrigger(event)
+			continue
+		}
+
+		if s.delivery == AtLeastOnce && !s.recordAfterRun {
+			event.Status = EventStatusClaimed
+		}
+		pending = append(pending, event)
+	}
+
+	// the events are recorded in one batch, only the recorded events are dispatched. With WithRecordAfterRun they are
+	// recorded when the handler succeeded.
+	var errs []error
+	if s.recordAfterRun {
+		errs = make([]error, len(pending))
+	} else {
+		errs = s.addEvents(ctx, pending)
+	}
+	var (
+		lost  error
+		fired []Event
+	)
+	for i, event := range pending {
+		if errors.Is(errs[i], ErrAlreadyTriggered) {
+			// recorded by another instance after the events were read
+			s.metrics.IncSkipped(event.Entry.Name, SkipAlreadyTriggered)
+			s.incStats(&s.stats.Skipped)
+			s.logger.Debug("entry already triggered", s.entryKV(event.Entry, "at", on)...)
+			s.onSkip(event)
+			continue
+		}
+		if err := errs[i]; err != nil {
+			s.logger.Error("failed to store event", s.entryKV(event.Entry, "at", on, "error", err)...)
+			if errors.Is(err, ErrLeaseLost) {
+				// another instance took over, it runs the entries not recorded
+				lost = err
 				continue
 			}
+			s.log(fmt.Errorf("failed to store event: %v", err))
+			continue
+		}
+		s.markFired(event.Entry.Name, on)
+		fired = append(fired, event)
+	}
+
+	// cleanup, stores like MemStore are only safe to use while locked
+	if lost == nil && !s.dryRun && s.pruneDue(on) {
+		if err := s.store.DeleteEvents(ctx, on.Add(-1*s.keepEvents())); err != nil {
+			s.metrics.IncError(ErrorKindStore)
+			s.logger.Error("failed to delete events", "error", err)
+			s.log(fmt.Errorf("failed to delete events: %v", err))
+		}
+	}
+
+	// the handlers are dispatched after the store is unlocked so that the other instances are not blocked
+	release()
+	var matching map[string]bool // entries matching on, for the dependencies
+	for _, event := range fired {
+		e := event.Entry
+		fn, err := s.handlerFor(e)
+		if err != nil {
+			s.metrics.IncError(ErrorKindNoHandler)
+			s.incStats(&s.stats.Errored)
+			s.logger.Error("no handler", s.entryKV(e, "at", on)...)
+			s.log(err)
+			continue
+		}
+		matched++
+		s.metrics.IncTriggered(e.Name)
+		s.incStats(&s.stats.Triggered)
+		s.logger.Info("entry triggered", s.entryKV(e, "at", on)...)
+		s.onTrigger(event)
+		if len(e.DependsOn) == 0 {
+			s.goRun(ctx, fn, event)
+			continue
+		}
+		if matching == nil {
+			matching = make(map[string]bool)
+			for _, name := range candidates(entries, on) {
+				matching[name] = true
+			}
+		}
+		if deps := dependencies(e, matching); len(deps) > 0 {
+			s.goRunAfter(ctx, fn, event, deps)
+		} else {
+			s.goRun(ctx, fn, event)
+		}
+	}
+	if lost != nil {
+		return lost
+	}
+
+	return nil
+}
 
-			go s.handler(e)
+// WithDedupeGranularity sets the bucket of time in which an entry is triggered at most once (default a minute), ex:
+// with an hour an entry that matches every minute is triggered on the first check of every hour. d is rounded down to
+// a whole number of minutes, the buckets are aligned like time.Truncate (to UTC for a day).
+func WithDedupeGranularity(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d = d.Truncate(time.Minute); d < time.Minute {
+			d = time.Minute
 		}
+		s.dedupe = d
+	}
+}
+
+// triggeredKey identifies the event of an entry on a dedupe bucket regardless of the characters in the name and the
+// location of the event time
+type triggeredKey struct {
+	name   string
+	bucket int64 // unix time of the start of the bucket
+}
+
+func (s *Scheduler) triggeredKey(name string, t time.Time) triggeredKey {
+	return triggeredKey{name: name, bucket: t.Truncate(s.dedupe).Unix()}
+}
+
+// markFired remembers that the entry is triggered by this scheduler on the bucket of on, only the last bucket is kept
+func (s *Scheduler) markFired(name string, on time.Time) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if bucket := on.Truncate(s.dedupe); !bucket.Equal(s.firedOn) {
+		s.firedOn, s.fired = bucket, nil
+	}
+	s.fired = append(s.fired, name)
+}
+
+// firedEvents returns the events triggered by this scheduler on the bucket of on. They are used when the events can
+// not be read from the store.
+func (s *Scheduler) firedEvents(on time.Time) []Event {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if !on.Truncate(s.dedupe).Equal(s.firedOn) {
+		return nil
+	}
+	events := make([]Event, 0, len(s.fired))
+	for _, name := range s.fired {
+		events = append(events, Event{Entry: Entry{Name: name}, Time: on})
+	}
+	return events
+}
+
+// TriggerNow triggers the entry with the given name immediately, regardless of its schedule (even if it is paused). The event is recorded
+// as manual so that it does not prevent the scheduled trigger. The handler runs in its own go routine like a scheduled
+// trigger and it is not canceled when ctx is done. It returns ErrEntryNotFound if there is no entry with the name and
+// AmbiguousEntryError if more than one entry has the name.
+func (s *Scheduler) TriggerNow(ctx context.Context, name string) error {
+	if s.store == nil {
+		return errors.New("empty store")
+	}
+	if err := s.store.Lock(ctx); err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		return fmt.Errorf("locking store failed: %v", err)
+	}
+	defer s.store.Unlock(ctx)
+
+	entry, err := s.store.GetEntry(ctx, name)
+	if errors.Is(err, ErrEntryNotFound) || errors.As(err, &AmbiguousEntryError{}) {
+		return err
+	}
+	if err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		return fmt.Errorf("failed to get entry: %v", err)
+	}
+
+	fn, err := s.handlerFor(entry)
+	if err != nil {
+		s.metrics.IncError(ErrorKindNoHandler)
+		s.incStats(&s.stats.Errored)
+		return err
+	}
+
+	// the stores keep the time of the event in microseconds, ex: the key of SqlStore
+	now := s.now().Truncate(time.Microsecond)
+	event := Event{
+		Entry:          entry,
+		Time:           now,
+		Manual:         true,
+		FiredBy:        s.instance.ID,
+		IdempotencyKey: manualIdempotencyKey(entry.Name, now),
 	}
+	if err := s.store.AddEvent(ctx, event); err != nil {
+		s.metrics.IncError(ErrorKindStore)
+		return fmt.Errorf("failed to store event: %v", err)
+	}
+
+	s.metrics.IncTriggered(entry.Name)
+	s.incStats(&s.stats.Triggered)
+	s.logger.Info("entry triggered manually", s.entryKV(entry, "at", event.Time)...)
+	s.onTrigger(event)
+	s.goRun(context.WithoutCancel(ctx), fn, event)
+
+	return nil
+}
+
+// Pause stops triggering the entry with the given name until it is resumed. The entry is kept in the store and the
+// state is shared with other instances using the same store. It returns ErrEntryNotFound if there is no entry with the
+// name.
+func (s *Scheduler) Pause(ctx context.Context, name string) error {
+	return s.setEntryActive(ctx, name, false)
+}
 
-	// cleanup
-	s.store.DeleteEvents(ctx, on.Add(-1*KeepEventDuration))
+// Resume continues triggering the entry paused with Pause. It returns ErrEntryNotFound if there is no entry with the
+// name.
+func (s *Scheduler) Resume(ctx context.Context, name string) error {
+	return s.setEntryActive(ctx, name, true)
+}
+
+func (s *Scheduler) setEntryActive(ctx context.Context, name string, active bool) error {
+	err := s.withLock(ctx, func() error {
+		return s.store.SetEntryActive(ctx, name, active)
+	})
+	if err != nil {
+		return err
+	}
+	s.ForceReload()
+	s.logger.Info("entry active changed", "name", name, "active", active)
 
 	return nil
 }
+
+type eventKey struct{}
+
+// EventFromContext returns the triggered event from the context given to the handler
+func EventFromContext(ctx context.Context) (Event, bool) {
+	ev, ok := ctx.Value(eventKey{}).(Event)
+	return ev, ok
+}
+
+// goRun runs the handler in its own go routine
+func (s *Scheduler) goRun(ctx context.Context, fn HandlerFunc, ev Event) {
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.run(ctx, fn, ev)
+	}()
+}
+
+// run the handler of a triggered event
+func (s *Scheduler) run(ctx context.Context, fn HandlerFunc, ev Event) {
+	s.setRunning(ev.Entry.Name, 1)
+	defer s.setRunning(ev.Entry.Name, -1)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.addCancel(ev.Entry.Name, cancel)()
+	s.onStart(ev)
+
+	end := func(err error) {}
+	if s.tracer != nil {
+		ctx, end = s.tracer.StartHandler(ctx, ev)
+	}
+
+	ctx = context.WithValue(ctx, eventKey{}, ev)
+	start := time.Now()
+	err := s.callWithTimeout(ctx, fn, ev, s.timeoutFor(ev.Entry))
+	d := time.Since(start)
+	end(err)
+	s.setLastErr(ev.Entry.Name, err)
+	s.metrics.ObserveHandlerDuration(ev.Entry.Name, d)
+	if err != nil {
+		kind := ErrorKindHandler
+		if errors.Is(err, ErrHandlerTimeout) {
+			kind = ErrorKindTimeout
+		}
+		s.metrics.IncError(kind)
+		s.incStats(&s.stats.Errored)
+		s.logger.Error("handler failed", s.entryKV(ev.Entry, "at", ev.Time, "duration", d, "error", err)...)
+		s.log(fmt.Errorf("handler of entry %q failed: %v", ev.Entry.Name, err))
+		if !s.recordsAfterRun(ev) {
+			s.recordStatus(ctx, ev, EventStatusFailed)
+		}
+		s.onError(ev, d, err)
+		ev.Status, ev.Duration = EventStatusFailed, d
+		s.publish(ev)
+		return
+	}
+
+	s.logger.Info("handler completed", s.entryKV(ev.Entry, "at", ev.Time, "duration", d)...)
+	if s.recordsAfterRun(ev) {
+		s.recordSucceeded(ctx, ev)
+	} else {
+		s.recordStatus(ctx, ev, EventStatusSucceeded)
+	}
+	s.onComplete(ev, d)
+	ev.Status, ev.Duration = EventStatusSucceeded, d
+	s.publish(ev)
+}
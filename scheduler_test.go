@@ -2,8 +2,11 @@ package cron
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -37,14 +40,16 @@ func TestScheduler_check(t *testing.T) {
 
 	// there are 2 scheduler
 	var triggered1 []string
-	handler1 := func(e Entry) {
+	handler1 := func(ctx context.Context, e Entry) error {
 		triggered1 = append(triggered1, e.Name)
+		return nil
 	}
 	scheduler1 := NewScheduler(handler1, &store)
 
 	var triggered2 []string
-	handler2 := func(e Entry) {
+	handler2 := func(ctx context.Context, e Entry) error {
 		triggered2 = append(triggered2, e.Name)
+		return nil
 	}
 	scheduler2 := NewScheduler(handler2, &store)
 
@@ -73,6 +78,7 @@ func TestScheduler_check(t *testing.T) {
 	}()
 
 	wg.Wait()
+	scheduler1.inFlight.Wait()
 	if got, want := len(store.events), 2; got != want {
 		t.Errorf("got %d events want %d", got, want)
 	}
@@ -90,5 +96,899 @@ func TestScheduler_check(t *testing.T) {
 	if got, want := len(triggered2), 0; !reflect.DeepEqual(got, want) {
 		t.Errorf("got length triggered2 %d want %d", got, want)
 	}
+	if got, want := scheduler1.Stats().Skipped, uint64(1); got != want {
+		t.Errorf("got scheduler1 skipped %d want %d", got, want)
+	}
+	if got, want := scheduler2.Stats().Skipped, uint64(2); got != want {
+		t.Errorf("got scheduler2 skipped %d want %d", got, want)
+	}
+}
+
+func TestScheduler_checkAlreadyTriggered(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	fired := make(chan string, 2)
+	newScheduler := func(name string, store Store) *Scheduler {
+		return NewScheduler(func(ctx context.Context, e Entry) error {
+			fired <- name
+			return nil
+		}, store, WithRetry(1, 0, time.Second))
+	}
+	s1 := newScheduler("scheduler1", store)
+	// scheduler2 can not read the events, the insert of the event prevents it from triggering again
+	s2 := newScheduler("scheduler2", &flakyStore{MemStore: store, failGetEvents: 1})
+	for _, s := range []*Scheduler{s1, s2} {
+		if err := s.check(ctx, on); err != nil {
+			t.Fatal(err)
+		}
+		s.inFlight.Wait()
+	}
+	close(fired)
+
+	var got []string
+	for name := range fired {
+		got = append(got, name)
+	}
+	if want := []string{"scheduler1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got triggered by %v want %v", got, want)
+	}
+	if got, want := s2.Stats().Skipped, uint64(1); got != want {
+		t.Errorf("got skipped %d want %d", got, want)
+	}
+	if got, want := s2.Stats().Errored, uint64(0); got != want {
+		t.Errorf("got errored %d want %d", got, want)
+	}
+	if got, want := store.AddEvent(ctx, Event{Entry: entry, Time: on}), ErrAlreadyTriggered; got != want {
+		t.Errorf("got error %v want %v", got, want)
+	}
+}
+
+func TestScheduler_checkTruncateMinute(t *testing.T) {
+	entry, err := Parse("01 01 01 01 *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	store := MemStore{}
+	store.AddEntry(ctx, entry)
+
+	handler := func(ctx context.Context, e Entry) error { return nil }
+	scheduler1 := NewScheduler(handler, &store)
+	scheduler2 := NewScheduler(handler, &store)
+
+	// both instance tick on the same minute with different sub-minute jitter
+	minute := time.Date(2000, 01, 01, 01, 01, 0, 0, time.UTC)
+	if err := scheduler1.check(ctx, minute.Add(400*time.Millisecond)); err != nil {
+		t.Fatalf("scheduler1 got error: %v", err)
+	}
+	if err := scheduler2.check(ctx, minute.Add(900*time.Millisecond)); err != nil {
+		t.Fatalf("scheduler2 got error: %v", err)
+	}
+
+	if got, want := len(store.events), 1; got != want {
+		t.Fatalf("got %d events want %d", got, want)
+	}
+	if got, want := store.events[0].Time, minute; !got.Equal(want) {
+		t.Errorf("got event time %s want %s", got, want)
+	}
+}
+
+func TestEventFromContext(t *testing.T) {
+	ctx := context.Background()
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := MemStore{}
+	store.AddEntry(ctx, entry)
+
+	got := make(chan Event, 1)
+	handler := func(ctx context.Context, e Entry) error {
+		ev, ok := EventFromContext(ctx)
+		if !ok {
+			t.Error("missing event in context")
+		}
+		got <- ev
+		return nil
+	}
+
+	now := time.Date(2018, 12, 15, 0, 0, 10, 0, time.UTC)
+	if err := NewScheduler(handler, &store).check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	ev := <-got
+	if got, want := ev.Time, now.Truncate(time.Minute); !got.Equal(want) {
+		t.Errorf("got event time %s want %s", got, want)
+	}
+	if got, want := ev.Entry.Name, "ENTRY_1"; got != want {
+		t.Errorf("got event entry %q want %q", got, want)
+	}
+}
+
+func TestScheduler_TriggerNow(t *testing.T) {
+	ctx := context.Background()
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := MemStore{}
+	store.AddEntry(ctx, entry)
+
+	triggered := make(chan Event, 2)
+	handler := func(ctx context.Context, e Entry) error {
+		ev, _ := EventFromContext(ctx)
+		triggered <- ev
+		return nil
+	}
+	s := NewScheduler(handler, &store)
+
+	if got, want := s.TriggerNow(ctx, "UNKNOWN"), ErrEntryNotFound; got != want {
+		t.Errorf("got error %v want %v", got, want)
+	}
+
+	if err := s.TriggerNow(ctx, "ENTRY_1"); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-triggered; !ev.Manual {
+		t.Errorf("got manual %t want %t", ev.Manual, true)
+	}
+
+	// manual trigger does not suppress the scheduled one on the same minute
+	if err := s.check(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-triggered; ev.Manual {
+		t.Errorf("got manual %t want %t", ev.Manual, false)
+	}
+	if got, want := len(store.events), 2; got != want {
+		t.Errorf("got %d events want %d", got, want)
+	}
+
+	// the entry to trigger is ambiguous when another entry has the name
+	hourly, err := Parse("0 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.AddEntry(ctx, hourly)
+	var ambiguous AmbiguousEntryError
+	if err := s.TriggerNow(ctx, "ENTRY_1"); !errors.As(err, &ambiguous) {
+		t.Errorf("got error %v want AmbiguousEntryError", err)
+	}
+}
+
+func TestScheduler_TriggerNowEndOfMinute(t *testing.T) {
+	triggerNowTest(t, &MemStore{})
+}
+
+// triggerNowTest checks that the events of TriggerNow on the same second at the end of the minute are recorded
+// besides the scheduled event of the next minute
+func triggerNowTest(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Parse("* * * * *", time.UTC, "TRIGGER_NOW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.DeleteEntry(ctx, entry) })
+	on := time.Date(2018, 12, 15, 12, 0, 0, 0, time.UTC)
+	if err := store.DeleteEvents(ctx, on.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store)
+	now := &fakeNow{t: on.Add(59*time.Second + 500*time.Millisecond)}
+	s.now = now.Now
+
+	if err := s.TriggerNow(ctx, entry.Name); err != nil {
+		t.Fatal(err)
+	}
+	now.Add(time.Millisecond)
+	if err := s.TriggerNow(ctx, entry.Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.check(ctx, on.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	s.inFlight.Wait()
+
+	events, err := store.GetEventsByName(ctx, entry.Name, on, on.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		time   time.Time
+		manual bool
+	}{
+		{on.Add(59*time.Second + 500*time.Millisecond), true},
+		{on.Add(59*time.Second + 501*time.Millisecond), true},
+		{on.Add(time.Minute), false},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got events %v want %d", events, len(want))
+	}
+	for i, e := range events {
+		if !e.Time.Equal(want[i].time) || e.Manual != want[i].manual {
+			t.Errorf("got event %d on %s manual %t want on %s manual %t", i, e.Time, e.Manual, want[i].time, want[i].manual)
+		}
+	}
+}
+
+func TestScheduler_PauseResume(t *testing.T) {
+	ctx := context.Background()
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := MemStore{}
+	store.AddEntry(ctx, entry)
+
+	triggered := make(chan Entry, 1)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		triggered <- e
+		return nil
+	}, &store)
+
+	if got, want := s.Pause(ctx, "UNKNOWN"), ErrEntryNotFound; got != want {
+		t.Errorf("got error %v want %v", got, want)
+	}
+
+	if err := s.Pause(ctx, "ENTRY_1"); err != nil {
+		t.Fatal(err)
+	}
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(store.events), 0; got != want {
+		t.Fatalf("got %d events want %d", got, want)
+	}
+
+	if err := s.Resume(ctx, "ENTRY_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := (<-triggered).Name, "ENTRY_1"; got != want {
+		t.Errorf("got entry %q want %q", got, want)
+	}
+}
+
+func TestScheduler_checkDedupeKey(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	jkt, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := MemStore{}
+	names := []string{"foo", "foo|2018-12-15-00-00", "foo|2018-12-15-00-00|2018-12-15-00-00"}
+	for _, name := range names {
+		e, err := Parse("* * * * *", jkt, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(ctx, e)
+	}
+	// already triggered by another instance, the event time is in the entry location
+	store.AddEvent(ctx, Event{Entry: store.entries[1], Time: on.In(jkt)})
+
+	triggered := make(chan string, len(names))
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		triggered <- e.Name
+		return nil
+	}, &store)
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]bool{<-triggered: true, <-triggered: true}
+	for _, name := range []string{names[0], names[2]} {
+		if !got[name] {
+			t.Errorf("entry %q is not triggered", name)
+		}
+	}
+	if got, want := len(store.events), 3; got != want {
+		t.Errorf("got %d events want %d", got, want)
+	}
+}
+
+func TestScheduler_checkDedupeGranularity(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 10, 0, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	var triggered []time.Time
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		ev, _ := EventFromContext(ctx)
+		triggered = append(triggered, ev.Time)
+		return nil
+	}, store, WithDedupeGranularity(time.Hour))
+
+	// the first match of every hour triggers
+	for _, at := range []time.Time{on.Add(5 * time.Minute), on.Add(6 * time.Minute), on.Add(59 * time.Minute), on.Add(time.Hour)} {
+		if err := s.check(ctx, at); err != nil {
+			t.Fatal(err)
+		}
+		s.inFlight.Wait()
+	}
+	if want := []time.Time{on.Add(5 * time.Minute), on.Add(time.Hour)}; !reflect.DeepEqual(triggered, want) {
+		t.Errorf("got triggered on %v want %v", triggered, want)
+	}
+	if got, want := s.Stats().Skipped, uint64(2); got != want {
+		t.Errorf("got skipped %d want %d", got, want)
+	}
+}
+
+// lockStateStore records whether the store is locked when the events are recorded
+type lockStateStore struct {
+	*MemStore
+	mu             sync.Mutex // guards isLocked
+	isLocked       bool
+	lockedAddEvent []bool
+}
+
+func (l *lockStateStore) setLocked(locked bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.isLocked = locked
+}
+
+func (l *lockStateStore) Locked() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.isLocked
+}
+
+func (l *lockStateStore) Lock(ctx context.Context) error {
+	if err := l.MemStore.Lock(ctx); err != nil {
+		return err
+	}
+	l.setLocked(true)
+	return nil
+}
+
+func (l *lockStateStore) Unlock(ctx context.Context) error {
+	l.setLocked(false)
+	return l.MemStore.Unlock(ctx)
+}
 
+func (l *lockStateStore) AddEvents(ctx context.Context, events []Event) error {
+	return addEachEvent(ctx, l, events)
+}
+
+func (l *lockStateStore) AddEvent(ctx context.Context, e Event) error {
+	l.lockedAddEvent = append(l.lockedAddEvent, l.Locked())
+	return l.MemStore.AddEvent(ctx, e)
+}
+
+func TestScheduler_checkUnlockBeforeDispatch(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	store := &lockStateStore{MemStore: &MemStore{}}
+	for _, name := range []string{"ENTRY_1", "ENTRY_2"} {
+		entry, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.MemStore.AddEntry(ctx, entry)
+	}
+
+	lockedInHandler := make(chan bool, 2)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		lockedInHandler <- store.Locked()
+		return nil
+	}, store)
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	s.inFlight.Wait()
+	close(lockedInHandler)
+
+	if got, want := store.lockedAddEvent, []bool{true, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got locked on recording the events %v want %v", got, want)
+	}
+	var handled int
+	for locked := range lockedInHandler {
+		handled++
+		if locked {
+			t.Error("handler runs while the store is locked")
+		}
+	}
+	if got, want := handled, 2; got != want {
+		t.Errorf("got %d handlers want %d", got, want)
+	}
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	release := make(chan struct{})
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		<-release
+		return nil
+	}, &MemStore{})
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(context.Background())
+	}()
+	for !s.Stats().Alive {
+		time.Sleep(time.Millisecond)
+	}
+	// a handler is still running when the scheduler is stopped
+	s.goRun(context.Background(), func(ctx context.Context, e Entry) error {
+		<-release
+		return nil
+	}, Event{Entry: entry})
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Run returned %v before the running handler", err)
+	case <-stopped:
+		t.Fatal("Stop returned before the running handler")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("got error %v want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Stop")
+	}
+	<-stopped
+	s.Stop() // no-op when it is not running
+}
+
+func TestScheduler_RunCanceled(t *testing.T) {
+	s := NewScheduler(nil, &MemStore{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	for !s.Stats().Alive {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("got error %v want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context is canceled")
+	}
+}
+
+// runWithTicks starts Run where each minute boundary is reached by sending on the returned channel
+func runWithTicks(t *testing.T, s *Scheduler) (ticks chan time.Time, done chan error) {
+	t.Helper()
+	ticks = make(chan time.Time)
+	s.after = func(d time.Duration) <-chan time.Time { return ticks }
+	done = make(chan error, 1)
+	go func() {
+		done <- s.Run(context.Background())
+	}()
+	for !s.Stats().Alive {
+		time.Sleep(time.Millisecond)
+	}
+	return ticks, done
+}
+
+func TestScheduler_RunTwice(t *testing.T) {
+	s := NewScheduler(nil, &MemStore{})
+	_, done := runWithTicks(t, s)
+
+	if got, want := s.Run(context.Background()), ErrAlreadyRunning; got != want {
+		t.Errorf("got error %v want %v", got, want)
+	}
+	s.Stop()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// blockingStore blocks GetEntries until release is closed once it is armed (ex: after Run read the @reboot entries)
+type blockingStore struct {
+	*MemStore
+	armed   atomic.Bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingStore) GetEntries(ctx context.Context, opts ...EntriesOption) ([]Entry, error) {
+	if !b.armed.Load() {
+		return b.MemStore.GetEntries(ctx, opts...)
+	}
+	close(b.entered)
+	<-b.release
+	return b.MemStore.GetEntries(ctx, opts...)
+}
+
+func TestScheduler_StopDuringCheck(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &blockingStore{MemStore: &MemStore{}, entered: make(chan struct{}), release: make(chan struct{})}
+	store.MemStore.AddEntry(ctx, entry)
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store)
+	s.now = func() time.Time { return on }
+	ticks, done := runWithTicks(t, s)
+	store.armed.Store(true)
+
+	ticks <- on
+	<-store.entered
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned before the check completed")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(store.release)
+	<-stopped
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(store.MemStore.events), 1; got != want {
+		t.Errorf("got %d events want %d, the check did not complete", got, want)
+	}
+}
+
+// fakeNow is a settable time source that is safe for concurrent use
+type fakeNow struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (f *fakeNow) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.t
+}
+
+func (f *fakeNow) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = t
+}
+
+func (f *fakeNow) Add(d time.Duration) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = f.t.Add(d)
+	return f.t
+}
+
+func TestScheduler_RunStopRun(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeNow{t: time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+	triggered := make(chan time.Time, 2)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		ev, _ := EventFromContext(ctx)
+		triggered <- ev.Time
+		return nil
+	}, store)
+	s.now = clock.Now
+
+	for i := 0; i < 2; i++ {
+		startedAt := clock.Now()
+		ticks, done := runWithTicks(t, s)
+		on := clock.Add(time.Minute).Truncate(time.Minute)
+		ticks <- on
+		if got, want := <-triggered, on; !got.Equal(want) {
+			t.Errorf("run %d: got event time %s want %s", i, got, want)
+		}
+
+		stoppedAt := clock.Add(time.Second)
+		s.Stop()
+		if err := <-done; err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		st := s.Stats()
+		if st.Alive || !st.StartedAt.Equal(startedAt) || !st.StoppedAt.Equal(stoppedAt) {
+			t.Errorf("run %d: got alive %t started at %s stopped at %s want started at %s stopped at %s", i,
+				st.Alive, st.StartedAt, st.StoppedAt, startedAt, stoppedAt)
+		}
+	}
+}
+
+func TestScheduler_ImmediateFirstCheck(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 30, 0, time.UTC)
+	entry, err := Parse("0 0 * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, immediate := range []bool{false, true} {
+		store := &MemStore{}
+		store.AddEntry(ctx, entry)
+		triggered := make(chan time.Time, 1)
+		var opts []Option
+		if immediate {
+			opts = append(opts, WithImmediateFirstCheck())
+		}
+		s := NewScheduler(func(ctx context.Context, e Entry) error {
+			ev, _ := EventFromContext(ctx)
+			triggered <- ev.Time
+			return nil
+		}, store, opts...)
+		s.now = func() time.Time { return now }
+
+		// the next minute never comes
+		_, done := runWithTicks(t, s)
+		select {
+		case got := <-triggered:
+			if !immediate {
+				t.Errorf("triggered on %s before the next minute", got)
+			} else if want := now.Truncate(time.Minute); !got.Equal(want) {
+				t.Errorf("got event time %s want %s", got, want)
+			}
+		case <-time.After(50 * time.Millisecond):
+			if immediate {
+				t.Error("entry is not triggered right away")
+			}
+		}
+		s.Stop()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScheduler_RunAlignment(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeNow{t: time.Date(2018, 12, 15, 0, 0, 20, 0, time.UTC)}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+	triggered := make(chan time.Time, 1)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		ev, _ := EventFromContext(ctx)
+		triggered <- ev.Time
+		return nil
+	}, store)
+	s.now = clock.Now
+
+	// the timer fires when the test sends the time of the clock
+	waits := make(chan time.Duration, 1)
+	ticks := make(chan time.Time)
+	s.after = func(d time.Duration) <-chan time.Time {
+		waits <- d
+		return ticks
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	fire := func(at time.Time) (wait time.Duration, eventTime time.Time) {
+		t.Helper()
+		wait = <-waits
+		clock.Set(at)
+		ticks <- at
+		return wait, <-triggered
+	}
+
+	// timers fire late or early, every check is on the minute and the next wait is aligned to the minute again
+	minute := time.Date(2018, 12, 15, 0, 1, 0, 0, time.UTC)
+	tests := []struct {
+		fireAt   time.Time
+		wantWait time.Duration
+		want     time.Time
+	}{
+		{fireAt: minute.Add(2 * time.Second), wantWait: 40 * time.Second, want: minute},
+		{fireAt: minute.Add(time.Minute + 3*time.Second), wantWait: 58 * time.Second, want: minute.Add(time.Minute)},
+		{fireAt: minute.Add(2*time.Minute - time.Millisecond), wantWait: 57 * time.Second, want: minute.Add(2 * time.Minute)},
+	}
+	for _, tt := range tests {
+		wait, got := fire(tt.fireAt)
+		if wait != tt.wantWait {
+			t.Errorf("got wait %s want %s", wait, tt.wantWait)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("got event time %s want %s", got, tt.want)
+		}
+	}
+	if got := s.Stats().ClockJumps; got != 0 {
+		t.Errorf("got %d clock jumps want 0", got)
+	}
+
+	// the clock jumps 10 minutes forward while waiting for the next minute, the skipped minutes are not checked
+	wait, got := fire(minute.Add(13 * time.Minute).Add(5 * time.Second))
+	if want := time.Minute + time.Millisecond; wait != want {
+		t.Errorf("got wait %s want %s", wait, want)
+	}
+	if want := minute.Add(13 * time.Minute); !got.Equal(want) {
+		t.Errorf("got event time %s after the jump want %s", got, want)
+	}
+	if got, want := s.Stats().ClockJumps, uint64(1); got != want {
+		t.Errorf("got %d clock jumps want %d", got, want)
+	}
+	if got, want := <-waits, 55*time.Second; got != want {
+		t.Errorf("got wait %s after the jump want %s", got, want)
+	}
+	if got, want := len(store.events), 4; got != want {
+		t.Errorf("got %d events want %d", got, want)
+	}
+
+	go s.Stop()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScheduler_RunClockBackward(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeNow{t: time.Date(2018, 12, 15, 0, 0, 20, 0, time.UTC)}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store)
+	s.now = clock.Now
+
+	waits := make(chan time.Duration, 1)
+	ticks := make(chan time.Time)
+	s.after = func(d time.Duration) <-chan time.Time {
+		waits <- d
+		return ticks
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	fire := func(at time.Time) time.Duration {
+		t.Helper()
+		wait := <-waits
+		clock.Set(at)
+		ticks <- at
+		return wait
+	}
+
+	minute := time.Date(2018, 12, 15, 0, 1, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		fire(minute.Add(time.Duration(i) * time.Minute))
+	}
+	// the clock is rewound two minutes while waiting for 00:04, then it waits until 00:04 on the rewound clock
+	fire(minute.Add(time.Minute))
+	if got, want := fire(minute.Add(3*time.Minute)), 2*time.Minute; got != want {
+		t.Errorf("got wait %s want %s", got, want)
+	}
+	<-waits
+	if got, want := s.Stats().ClockJumps, uint64(1); got != want {
+		t.Errorf("got %d clock jumps want %d", got, want)
+	}
+
+	go s.Stop()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	var got []time.Time
+	for _, ev := range store.events {
+		got = append(got, ev.Time)
+	}
+	want := []time.Time{minute, minute.Add(time.Minute), minute.Add(2 * time.Minute), minute.Add(3 * time.Minute)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %v want %v", got, want)
+	}
+}
+
+func TestScheduler_ErrCh(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+
+	newScheduler := func(name string) *Scheduler {
+		entry, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store := &MemStore{}
+		store.AddEntry(ctx, entry)
+		return NewScheduler(func(ctx context.Context, e Entry) error {
+			return fmt.Errorf("%s failed", e.Name)
+		}, store)
+	}
+	s1, s2 := newScheduler("ENTRY_1"), newScheduler("ENTRY_2")
+
+	for _, s := range []*Scheduler{s1, s2} {
+		if err := s.check(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		s.inFlight.Wait()
+	}
+	for _, tt := range []struct {
+		s    *Scheduler
+		want string
+	}{
+		{s: s1, want: `handler of entry "ENTRY_1" failed: ENTRY_1 failed`},
+		{s: s2, want: `handler of entry "ENTRY_2" failed: ENTRY_2 failed`},
+	} {
+		select {
+		case err := <-tt.s.ErrCh():
+			if got := err.Error(); got != tt.want {
+				t.Errorf("got error %q want %q", got, tt.want)
+			}
+		default:
+			t.Fatalf("no error, want %q", tt.want)
+		}
+		select {
+		case err := <-tt.s.ErrCh():
+			t.Errorf("got unexpected error %v", err)
+		default:
+		}
+	}
+}
+
+func TestScheduler_LastError(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	store := &failingStore{MemStore: &MemStore{}}
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store, WithRetry(1, 0, 0))
+	if err := s.LastError(); err != nil {
+		t.Fatalf("got error %v before any check want nil", err)
+	}
+
+	store.setFail(true)
+	s.runCheck(ctx, on)
+	want := "failed to do check on 2018-12-15 00:05:00 +0000 UTC: locking store failed: connection refused"
+	if got := fmt.Sprint(s.LastError()); got != want {
+		t.Errorf("got last error %q want %q", got, want)
+	}
+
+	// the error is kept after a successful check and on read
+	store.setFail(false)
+	s.runCheck(ctx, on.Add(time.Minute))
+	if got := fmt.Sprint(s.LastError()); got != want {
+		t.Errorf("got last error %q after successful check want %q", got, want)
+	}
 }
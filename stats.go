@@ -0,0 +1,85 @@
+package cron
+
+import "time"
+
+// Stats is a snapshot of the scheduler counters since it is created, see Scheduler.Stats
+type Stats struct {
+	// Alive is true while Run is running
+	Alive bool
+	// StartedAt is the time Run started, zero if it is not started. StoppedAt is the time the last Run returned.
+	StartedAt time.Time
+	StoppedAt time.Time
+	// LastCheck is the time of the last check, LastSuccessfulCheck is the last check that did not fail
+	LastCheck           time.Time
+	LastSuccessfulCheck time.Time
+	// LastCheckError is the error of the last check, nil if it succeeded
+	LastCheckError error
+	// ConsecutiveFailures is the number of failed checks since the last successful one (ex: store is not reachable)
+	ConsecutiveFailures int
+	// Triggered, Skipped and Errored count the events that are triggered (including manual trigger), skipped
+	// because it is already triggered and the handlers that failed or were not found
+	Triggered uint64
+	Skipped   uint64
+	Errored   uint64
+	// DroppedEvents is the number of events not delivered to a subscriber because its buffer is full, see Subscribe
+	DroppedEvents uint64
+	// ClockJumps is the number of times Run detected the clock jumped forward (minutes are skipped) or backward (the
+	// checked minutes are not checked again)
+	ClockJumps uint64
+	// HandlersRunning is the number of handlers in flight
+	HandlersRunning int
+	// RunningAfterTimeout is the number of handlers that are still running after their timeout (ignoring the
+	// context), they are not counted in HandlersRunning
+	RunningAfterTimeout int
+}
+
+// Stats returns a snapshot of the scheduler counters. It is safe to call while the scheduler is running, ex: for a
+// readiness probe that fails when ConsecutiveFailures is above a threshold.
+func (s *Scheduler) Stats() Stats {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	st := s.stats
+	for _, n := range s.running {
+		st.HandlersRunning += n
+	}
+
+	return st
+}
+
+// setAlive marks Run as running or stopped
+func (s *Scheduler) setAlive(alive bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.stats.Alive = alive
+	if alive {
+		s.stats.StartedAt = s.now()
+	} else {
+		s.stats.StoppedAt = s.now()
+	}
+}
+
+// recordCheck records the result of a check
+func (s *Scheduler) recordCheck(err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	now := s.now()
+	s.stats.LastCheck = now
+	s.stats.LastCheckError = err
+	if err != nil {
+		s.stats.ConsecutiveFailures++
+		return
+	}
+	s.stats.LastSuccessfulCheck = now
+	s.stats.ConsecutiveFailures = 0
+}
+
+// incStats increments one of the event counters
+func (s *Scheduler) incStats(counter *uint64) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	*counter++
+}
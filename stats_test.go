@@ -0,0 +1,120 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingStore fails to lock while fail is set
+type failingStore struct {
+	*MemStore
+	mu   sync.Mutex
+	fail bool
+}
+
+func (f *failingStore) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+func (f *failingStore) Lock(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("connection refused")
+	}
+	return f.MemStore.Lock(ctx)
+}
+
+func TestScheduler_Stats(t *testing.T) {
+	ctx := context.Background()
+	store := &failingStore{MemStore: &MemStore{}}
+	for _, name := range []string{"ENTRY_1", "ENTRY_2"} {
+		entry, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.MemStore.AddEntry(ctx, entry)
+	}
+
+	var wg sync.WaitGroup
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		if e.Name == "ENTRY_2" {
+			return errors.New("failed")
+		}
+		return nil
+	}, store, WithRetry(1, 0, 0), WithHooks(Hooks{
+		OnComplete: func(ev Event, d time.Duration) { wg.Done() },
+		OnError:    func(ev Event, d time.Duration, err error) { wg.Done() },
+	}))
+
+	// read stats concurrently while checking
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = s.Stats()
+			}
+		}
+	}()
+
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		wg.Add(2)
+		if err := s.check(ctx, on.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// already triggered
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	store.setFail(true)
+	for i := 0; i < 2; i++ {
+		if err := s.check(ctx, on); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	close(done)
+
+	st := s.Stats()
+	if got, want := st.Triggered, uint64(6); got != want {
+		t.Errorf("got triggered %d want %d", got, want)
+	}
+	if got, want := st.Skipped, uint64(2); got != want {
+		t.Errorf("got skipped %d want %d", got, want)
+	}
+	if got, want := st.Errored, uint64(3); got != want {
+		t.Errorf("got errored %d want %d", got, want)
+	}
+	if got, want := st.ConsecutiveFailures, 2; got != want {
+		t.Errorf("got consecutive failures %d want %d", got, want)
+	}
+	if st.LastCheckError == nil {
+		t.Errorf("expected last check error")
+	}
+	if !st.LastSuccessfulCheck.Before(st.LastCheck) && !st.LastSuccessfulCheck.Equal(st.LastCheck) {
+		t.Errorf("got last successful check %s after last check %s", st.LastSuccessfulCheck, st.LastCheck)
+	}
+
+	store.setFail(false)
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	st = s.Stats()
+	if got, want := st.ConsecutiveFailures, 0; got != want {
+		t.Errorf("got consecutive failures %d want %d", got, want)
+	}
+	if st.LastCheckError != nil {
+		t.Errorf("got last check error %v want nil", st.LastCheckError)
+	}
+}
@@ -0,0 +1,130 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EntryStatus is a snapshot of an entry known by the scheduler
+type EntryStatus struct {
+	// Entry with Entry.Paused set if the entry is paused
+	Entry Entry
+	// Next is the next scheduled time, zero if the entry does not match in the foreseeable future
+	Next time.Time
+	// LastTriggered is the time of the last recorded event (by any instance), zero if there is none
+	LastTriggered time.Time
+	// LastStatus is the status of the last recorded event (see Event.Status), ex: EventStatusFailed when the handler
+	// of another instance failed
+	LastStatus string
+	// LastError is the error of the last handler run by this scheduler instance
+	LastError error
+	// Running is the number of handlers in flight on this scheduler instance
+	Running int
+	// Warnings of the entry that is likely a misconfiguration (ex: it never fires), see Entry.Validate
+	Warnings []EntryWarning
+}
+
+// WithClock sets the function that returns the current time (default time.Now). It is used to compute the next
+// scheduled time and the time of manual triggers.
+func WithClock(now func() time.Time) Option {
+	return func(s *Scheduler) {
+		s.now = now
+	}
+}
+
+// Entries returns snapshot of the entries in the store (including paused entries) with their next scheduled time and last run status.
+// The store is locked while reading the entries and the last event of each entry (see Store.LastEvent). It is safe to
+// call while the scheduler is running.
+func (s *Scheduler) Entries(ctx context.Context) ([]EntryStatus, error) {
+	var entries []Entry
+	lastEvents := make(map[string]Event)
+	err := s.withLock(ctx, func() (err error) {
+		entries, err = s.store.GetEntries(ctx, IncludeInactive())
+		if err != nil {
+			return fmt.Errorf("failed to get entries: %v", err)
+		}
+		for _, e := range entries {
+			if _, ok := lastEvents[e.Name]; ok {
+				continue
+			}
+			last, _, err := s.store.LastEvent(ctx, e.Name)
+			if err != nil {
+				return fmt.Errorf("failed to get last event of %q: %v", e.Name, err)
+			}
+			lastEvents[e.Name] = last
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	statuses := make([]EntryStatus, 0, len(entries))
+	for _, e := range entries {
+		statuses = append(statuses, EntryStatus{
+			Entry:         e,
+			Next:          e.Next(now),
+			LastTriggered: lastEvents[e.Name].Time,
+			LastStatus:    lastEvents[e.Name].Status,
+			LastError:     s.lastErr[e.Name],
+			Running:       s.running[e.Name],
+			Warnings:      s.entryWarnings(e),
+		})
+	}
+
+	return statuses, nil
+}
+
+func (s *Scheduler) setRunning(name string, delta int) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.running[name] += delta
+	if s.running[name] <= 0 {
+		delete(s.running, name)
+	}
+}
+
+// addCancel registers cancel of a running handler of the entry, the returned function unregisters it
+func (s *Scheduler) addCancel(name string, cancel context.CancelFunc) func() {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.runSeq++
+	id := s.runSeq
+	if s.cancels[name] == nil {
+		s.cancels[name] = make(map[uint64]context.CancelFunc)
+	}
+	s.cancels[name][id] = cancel
+
+	return func() {
+		s.statusMu.Lock()
+		defer s.statusMu.Unlock()
+
+		delete(s.cancels[name], id)
+		if len(s.cancels[name]) == 0 {
+			delete(s.cancels, name)
+		}
+	}
+}
+
+// cancelRunning cancels the context of the running handlers of the entry
+func (s *Scheduler) cancelRunning(name string) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	for _, cancel := range s.cancels[name] {
+		cancel()
+	}
+}
+
+func (s *Scheduler) setLastErr(name string, err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.lastErr[name] = err
+}
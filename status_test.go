@@ -0,0 +1,140 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Entries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 30, 0, 0, time.UTC)
+
+	store := &MemStore{}
+	var entries []Entry
+	for _, v := range []struct{ expression, name string }{
+		{expression: "0 * * * *", name: "ENTRY_1"},
+		{expression: "* * * * *", name: "ENTRY_2"},
+	} {
+		e, err := Parse(v.expression, time.UTC, v.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(ctx, e)
+		entries = append(entries, e)
+	}
+	store.AddEvent(ctx, Event{Entry: entries[0], Time: now.Add(-90 * time.Minute)})
+	store.AddEvent(ctx, Event{Entry: entries[0], Time: now.Add(-30 * time.Minute)})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	s := NewScheduler(nil, store, WithClock(func() time.Time { return now }))
+	s.Handle("ENTRY_1", func(ctx context.Context, e Entry) error { return errors.New("failed") })
+	s.Handle("ENTRY_2", func(ctx context.Context, e Entry) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	// ENTRY_1 failed on the previous run
+	s.run(ctx, s.handlers["ENTRY_1"], Event{Entry: entries[0], Time: now.Add(-30 * time.Minute)})
+	// ENTRY_2 is in flight
+	go s.run(ctx, s.handlers["ENTRY_2"], Event{Entry: entries[1], Time: now})
+	<-started
+	defer close(release)
+
+	statuses, err := s.Entries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(statuses), 2; got != want {
+		t.Fatalf("got statuses %d want %d", got, want)
+	}
+
+	st := statuses[0]
+	if got, want := st.Entry.Name, "ENTRY_1"; got != want {
+		t.Errorf("got name %q want %q", got, want)
+	}
+	if got, want := st.Next, time.Date(2018, 12, 15, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got next %s want %s", got, want)
+	}
+	if got, want := st.LastTriggered, now.Add(-30*time.Minute); !got.Equal(want) {
+		t.Errorf("got last triggered %s want %s", got, want)
+	}
+	if got, want := st.LastError, "failed"; got == nil || got.Error() != want {
+		t.Errorf("got last error %v want %q", got, want)
+	}
+	if got, want := st.LastStatus, EventStatusFailed; got != want {
+		t.Errorf("got last status %q want %q", got, want)
+	}
+	if got, want := st.Running, 0; got != want {
+		t.Errorf("got running %d want %d", got, want)
+	}
+
+	st = statuses[1]
+	if got, want := st.Next, now.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("got next %s want %s", got, want)
+	}
+	if !st.LastTriggered.IsZero() {
+		t.Errorf("got last triggered %s want zero", st.LastTriggered)
+	}
+	if st.LastStatus != "" {
+		t.Errorf("got last status %q want empty", st.LastStatus)
+	}
+	if st.LastError != nil {
+		t.Errorf("got last error %v want nil", st.LastError)
+	}
+	if got, want := st.Running, 1; got != want {
+		t.Errorf("got running %d want %d", got, want)
+	}
+}
+
+func TestScheduler_EntriesConcurrent(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	store := &MemStore{}
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store,
+		WithClock(func() time.Time { return on }))
+
+	// the snapshot is read while the check and AddEntry change the store, run with -race
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			e, err := Parse("* * * * *", time.UTC, fmt.Sprintf("ENTRY_%d", i))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := s.AddEntry(ctx, e); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := s.check(ctx, on.Add(time.Duration(i)*time.Minute)); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if _, err := s.Entries(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	s.inFlight.Wait()
+
+	statuses, err := s.Entries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(statuses), 20; got != want {
+		t.Errorf("got statuses %d want %d", got, want)
+	}
+}
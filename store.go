@@ -3,44 +3,333 @@ package cron
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 type Store interface {
 	// Initialize the store
 	Initialize(ctx context.Context) error
+	// Health returns an error if the store is not reachable, it does not lock the store (ex: for a liveness probe)
+	Health(ctx context.Context) error
 	// Lock the store from external read or write
 	Lock(ctx context.Context) error
 	// Unlock the store
 	Unlock(ctx context.Context) error
-	// GetEntries retrieve only active entries
-	GetEntries(ctx context.Context) ([]Entry, error)
-	// AddEntry to the store
+	// GetEntries retrieve only active entries unless IncludeInactive option is given, see EntriesQuery for the other
+	// options. Without options it returns every active entry in no particular order.
+	GetEntries(ctx context.Context, opts ...EntriesOption) ([]Entry, error)
+	// GetEntry returns the entry with the name, paused or not. It returns ErrEntryNotFound if there is no entry with the
+	// name and AmbiguousEntryError if there are more than one (the same name with different expressions or locations).
+	GetEntry(ctx context.Context, name string) (Entry, error)
+	// AddEntry to the store. Adding again an entry with the same name, expression and location updates it but keeps
+	// whether it is paused, see SetEntryActive.
 	AddEntry(ctx context.Context, entry Entry) error
 	// DeleteEntry from the store
 	DeleteEntry(ctx context.Context, entry Entry) error
-	//WriteEvent which is triggered cron entry
+	// UpdateEntry replaces the entries with the name by the updated entry in one operation, updated.Name can differ to
+	// rename the entry. Every field is taken from updated (ex: Paused, Meta). It returns ErrEntryNotFound if there is
+	// no entry with the name.
+	UpdateEntry(ctx context.Context, name string, updated Entry) error
+	// AddEvent records the event of a triggered entry. It returns ErrAlreadyTriggered if there is already an event of
+	// the entry on the same time.
 	AddEvent(ctx context.Context, e Event) error
-	// GetEvents on [from, to)
+	// GetEvents on [from, to) ordered by time, then name
 	GetEvents(ctx context.Context, from, to time.Time) ([]Event, error)
+	// QueryEvents on [from, to) ordered like GetEvents, see EventsQuery for the options
+	QueryEvents(ctx context.Context, from, to time.Time, opts ...EventsOption) ([]Event, error)
+	// GetEventsByName on [from, to) of the entries with the name ordered by time, an empty slice if there is none
+	GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]Event, error)
+	// CountEvents on [from, to)
+	CountEvents(ctx context.Context, from, to time.Time) (int64, error)
+	// LastEvent returns the latest event of the entry with the name regardless of its status, ok is false if the entry
+	// never ran. EventsWithStatus only considers the events with the status (ex: the last successful run), the other
+	// options are ignored.
+	LastEvent(ctx context.Context, name string, opts ...EventsOption) (_ Event, ok bool, err error)
 	//DeleteEvents
 	DeleteEvents(ctx context.Context, until time.Time) error
+	// SetEntryActive activates or deactivates (pause) entries with the name. It returns ErrEntryNotFound if there is
+	// no entry with the name.
+	SetEntryActive(ctx context.Context, name string, active bool) error
+}
+
+// EventsAdder is an optional interface of a Store that records many events at once. check records the events of the
+// triggered entries in one batch so that the store is locked shortly.
+type EventsAdder interface {
+	// AddEvents records the events. It returns EventErrors when only some of the events are not recorded.
+	AddEvents(ctx context.Context, events []Event) error
+}
+
+// EventErrors is returned by EventsAdder.AddEvents when some of the events are not recorded. It has the error of each
+// event by index, nil for the recorded events.
+type EventErrors []error
+
+func (e EventErrors) Error() string {
+	var (
+		failed int
+		first  error
+	)
+	for _, err := range e {
+		if err == nil {
+			continue
+		}
+		if first == nil {
+			first = err
+		}
+		failed++
+	}
+	return fmt.Sprintf("failed to add %d of %d events: %v", failed, len(e), first)
+}
+
+// AmbiguousEntryError is returned by Store.GetEntry when more than one entry has the name
+type AmbiguousEntryError struct {
+	Name    string
+	Entries []Entry
+}
+
+func (e AmbiguousEntryError) Error() string {
+	entries := make([]string, 0, len(e.Entries))
+	for _, v := range e.Entries {
+		entries = append(entries, fmt.Sprintf("%q (%s)", v.expression, v.Location))
+	}
+	return fmt.Sprintf("%d entries named %q: %s", len(e.Entries), e.Name, strings.Join(entries, ", "))
+}
+
+// singleEntry returns the only entry of the entries with the name
+func singleEntry(name string, entries []Entry) (Entry, error) {
+	switch len(entries) {
+	case 0:
+		return Entry{}, ErrEntryNotFound
+	case 1:
+		return entries[0], nil
+	default:
+		return Entry{}, AmbiguousEntryError{Name: name, Entries: entries}
+	}
+}
+
+// addEachEvent records the events one by one with Store.AddEvent so that a failed event does not prevent the others
+// from being recorded. It returns EventErrors if any of them failed.
+func addEachEvent(ctx context.Context, store Store, events []Event) error {
+	var errs EventErrors
+	for i, e := range events {
+		if err := store.AddEvent(ctx, e); err != nil {
+			if errs == nil {
+				errs = make(EventErrors, len(events))
+			}
+			errs[i] = err
+		}
+	}
+	if errs == nil {
+		return nil
+	}
+	return errs
 }
 
+// EntryEventsGetter is an optional interface of a Store that reads the events of some entries only. check uses it
+// to read the events of the entries matching the minute instead of every event in the minute.
+type EntryEventsGetter interface {
+	// GetEventsForEntries on [from, to) of the entries with the names
+	GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]Event, error)
+}
+
+// getEventsForEntries reads the events on [from, to) of the entries with the names. It filters Store.GetEvents if the
+// store does not implement EntryEventsGetter.
+func getEventsForEntries(ctx context.Context, store Store, names []string, from, to time.Time) ([]Event, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if g, ok := store.(EntryEventsGetter); ok {
+		return g.GetEventsForEntries(ctx, names, from, to)
+	}
+
+	events, err := store.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return filterEvents(events, names), nil
+}
+
+// filterEvents returns the events of the entries with the names
+func filterEvents(events []Event, names []string) []Event {
+	set := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		set[n] = struct{}{}
+	}
+	var ret []Event
+	for _, e := range events {
+		if _, ok := set[e.Entry.Name]; ok {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// EntriesQuery is the options of Store.GetEntries
+type EntriesQuery struct {
+	// IncludeInactive includes paused entries
+	IncludeInactive bool
+	// NamePrefix only includes the entries whose name starts with it, empty for every entry
+	NamePrefix string
+	// OrderByName orders the entries by name, then expression and location for the entries with the same name
+	OrderByName bool
+	// Limit is the maximum number of entries returned, zero or less for no limit. Offset skips the first entries.
+	Limit, Offset int
+}
+
+// EntriesOption is an option of Store.GetEntries
+type EntriesOption func(q *EntriesQuery)
+
+// IncludeInactive makes GetEntries also return paused entries
+func IncludeInactive() EntriesOption {
+	return func(q *EntriesQuery) {
+		q.IncludeInactive = true
+	}
+}
+
+// NamePrefix makes GetEntries only return the entries whose name starts with prefix
+func NamePrefix(prefix string) EntriesOption {
+	return func(q *EntriesQuery) {
+		q.NamePrefix = prefix
+	}
+}
+
+// OrderByName makes GetEntries return the entries ordered by name. SqlStore compares the names with the collation of
+// the column (case insensitive by default in MySQL).
+func OrderByName() EntriesOption {
+	return func(q *EntriesQuery) {
+		q.OrderByName = true
+	}
+}
+
+// Page makes GetEntries return at most limit entries (no limit when zero or less) after skipping offset entries. The
+// entries are ordered by name like OrderByName so that the pages do not overlap.
+func Page(limit, offset int) EntriesOption {
+	return func(q *EntriesQuery) {
+		q.Limit, q.Offset = limit, offset
+		q.OrderByName = true
+	}
+}
+
+// NewEntriesQuery applies the options, it is used by Store implementations
+func NewEntriesQuery(opts ...EntriesOption) EntriesQuery {
+	var q EntriesQuery
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	return q
+}
+
+// EventsQuery is the options of Store.QueryEvents
+type EventsQuery struct {
+	// Names only includes the events of the entries with the names, empty for every entry
+	Names []string
+	// Status only includes the events with the status (see Event.Status), empty for every status
+	Status string
+	// Limit is the maximum number of events returned, zero or less for no limit. Offset skips the first events.
+	Limit, Offset int
+}
+
+// EventsOption is an option of Store.QueryEvents
+type EventsOption func(q *EventsQuery)
+
+// ForEntries makes QueryEvents only return the events of the entries with the names
+func ForEntries(names ...string) EventsOption {
+	return func(q *EventsQuery) {
+		q.Names = append(q.Names, names...)
+	}
+}
+
+// EventsWithStatus makes QueryEvents and LastEvent only return the events with the status, ex: EventStatusSucceeded
+// for the successful runs. The status is only recorded by stores implementing EventStatusStore.
+func EventsWithStatus(status string) EventsOption {
+	return func(q *EventsQuery) {
+		q.Status = status
+	}
+}
+
+// EventsPage makes QueryEvents return at most limit events (no limit when zero or less) after skipping offset events
+func EventsPage(limit, offset int) EventsOption {
+	return func(q *EventsQuery) {
+		q.Limit, q.Offset = limit, offset
+	}
+}
+
+// NewEventsQuery applies the options, it is used by Store implementations
+func NewEventsQuery(opts ...EventsOption) EventsQuery {
+	var q EventsQuery
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	return q
+}
+
+// lessEvent orders the events by time, then by name, expression and location for the events on the same time
+func lessEvent(a, b Event) bool {
+	if !a.Time.Equal(b.Time) {
+		return a.Time.Before(b.Time)
+	}
+	if a.Entry.Name != b.Entry.Name {
+		return a.Entry.Name < b.Entry.Name
+	}
+	if a.Entry.expression != b.Entry.expression {
+		return a.Entry.expression < b.Entry.expression
+	}
+	return a.Entry.Location.String() < b.Entry.Location.String()
+}
+
+// MemStore is an in memory Store for a single instance. Its methods return the context error if ctx is already
+// canceled, except Unlock which always releases the lock.
+//
+// The methods are safe for concurrent use whether the store is locked or not, the embedded Mutex is only the lock of
+// Lock and Unlock. So it can be used with LeaseCoordinator, where the checks do not lock the store while the handlers
+// record the status of the events.
 type MemStore struct {
-	entries []Entry
-	events  []Event
+	mu        sync.Mutex // guards the fields below
+	entries   []Entry
+	byName    map[string][]int // indexes of entries by name, see setEntries
+	events    []Event
+	leases    map[string]Lease
+	instances []Instance
+	claims    map[memEventKey]time.Time // time of the reclaimed events, see ReclaimEvent
 	sync.Mutex
 }
 
+// memEventKey identifies an event like the primary key of the SqlStore events table
+type memEventKey struct {
+	name, expression, location string
+	time                       int64
+}
+
+func newMemEventKey(e Event) memEventKey {
+	return memEventKey{name: e.Entry.Name, expression: e.Entry.expression, location: e.Entry.Location.String(), time: e.Time.UnixNano()}
+}
+
+func (m *MemStore) Health(ctx context.Context) error {
+	return ctx.Err()
+}
+
 func (m *MemStore) Initialize(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (m *MemStore) Lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m.Mutex.Lock()
 	return nil
 }
@@ -50,43 +339,334 @@ func (m *MemStore) Unlock(ctx context.Context) error {
 	return nil
 }
 
-func (m *MemStore) GetEntries(ctx context.Context) ([]Entry, error) {
-	return m.entries, nil
+func (m *MemStore) GetEntries(ctx context.Context, opts ...EntriesOption) ([]Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q := NewEntriesQuery(opts...)
+	var entries []Entry
+	for _, v := range m.entries {
+		if (q.IncludeInactive || !v.Paused) && strings.HasPrefix(v.Name, q.NamePrefix) {
+			entries = append(entries, v)
+		}
+	}
+	if q.OrderByName {
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			if a.expression != b.expression {
+				return a.expression < b.expression
+			}
+			return a.Location.String() < b.Location.String()
+		})
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(entries) {
+			return nil, nil
+		}
+		entries = entries[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(entries) {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (m *MemStore) GetEntry(ctx context.Context, name string) (Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return Entry{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var entries []Entry
+	for _, i := range m.byName[name] {
+		entries = append(entries, m.entries[i])
+	}
+	return singleEntry(name, entries)
+}
+
+// setEntries replaces the entries and rebuilds the index by name
+func (m *MemStore) setEntries(entries []Entry) {
+	m.entries = entries
+	m.byName = make(map[string][]int, len(entries))
+	for i, v := range entries {
+		m.byName[v.Name] = append(m.byName[v.Name], i)
+	}
 }
 
 func (m *MemStore) AddEntry(ctx context.Context, entry Entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry.Name == "" {
+		return errors.New("got empty name")
+	}
+	if entry.expression == "" {
+		return errors.New("got empty expression")
+	}
+
+	// replace the same entry like SqlStore does, keeping whether it is paused
+	for _, i := range m.byName[entry.Name] {
+		if v := m.entries[i]; v.expression == entry.expression && v.Location.String() == entry.Location.String() {
+			entry.Paused = v.Paused
+			m.entries[i] = entry
+			return nil
+		}
+	}
+	if m.byName == nil {
+		m.byName = make(map[string][]int)
+	}
+	m.byName[entry.Name] = append(m.byName[entry.Name], len(m.entries))
 	m.entries = append(m.entries, entry)
 	return nil
 }
 
 func (m *MemStore) DeleteEntry(ctx context.Context, entry Entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// same entry like AddEntry and SqlStore, the entry of another location is kept
+	var new []Entry
+	for _, v := range m.entries {
+		if v.Name == entry.Name && v.expression == entry.expression && v.Location.String() == entry.Location.String() {
+			continue
+		}
+		new = append(new, v)
+	}
+	m.setEntries(new)
+	return nil
+}
+
+func (m *MemStore) UpdateEntry(ctx context.Context, name string, updated Entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if updated.Name == "" {
+		return errors.New("got empty name")
+	}
+	if updated.expression == "" {
+		return errors.New("got empty expression")
+	}
+
+	// the updated entry takes the place of the first entry with the name, it also replaces the same entry like AddEntry
+	index := -1
 	var new []Entry
 	for _, v := range m.entries {
-		if v.expression == entry.expression && v.Name == entry.Name {
+		same := v.Name == updated.Name && v.expression == updated.expression && v.Location.String() == updated.Location.String()
+		if v.Name == name && index < 0 {
+			index = len(new)
+			new = append(new, updated)
+			continue
+		}
+		if v.Name == name || same {
 			continue
 		}
 		new = append(new, v)
 	}
-	m.entries = new
+	if index < 0 {
+		return ErrEntryNotFound
+	}
+	m.setEntries(new)
+	return nil
+}
+
+func (m *MemStore) SetEntryActive(ctx context.Context, name string, active bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.byName[name]) == 0 {
+		return ErrEntryNotFound
+	}
+	for _, i := range m.byName[name] {
+		m.entries[i].Paused = !active
+	}
 	return nil
 }
 
 func (m *MemStore) AddEvent(ctx context.Context, e Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := LeaseFromContext(ctx); ok && m.leases[l.Name].Token != l.Token {
+		return ErrLeaseLost
+	}
+	// same uniqueness as the primary key of the SqlStore events table
+	for _, v := range m.events {
+		if v.Entry.Name == e.Entry.Name && v.Time.Equal(e.Time) && v.Entry.expression == e.Entry.expression &&
+			v.Entry.Location.String() == e.Entry.Location.String() {
+			return ErrAlreadyTriggered
+		}
+	}
 	m.events = append(m.events, e)
 	return nil
 }
 
+func (m *MemStore) AddEvents(ctx context.Context, events []Event) error {
+	return addEachEvent(ctx, m, events)
+}
+
+// SetEventStatus implements EventStatusStore
+func (m *MemStore) SetEventStatus(ctx context.Context, e Event, status string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, v := range m.events {
+		if v.Entry.Name == e.Entry.Name && v.Time.Equal(e.Time) && v.Entry.expression == e.Entry.expression &&
+			v.Entry.Location.String() == e.Entry.Location.String() {
+			m.events[i].Status = status
+		}
+	}
+	return nil
+}
+
+// GetEventStatuses implements EventStatusStore
+func (m *MemStore) GetEventStatuses(ctx context.Context, names []string, from, to time.Time) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	events, err := m.GetEventsForEntries(ctx, names, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return eventStatuses(events), nil
+}
+
+// eventStatuses returns the status of the events that are not manual by entry name
+func eventStatuses(events []Event) map[string]string {
+	statuses := make(map[string]string)
+	for _, e := range events {
+		if !e.Manual {
+			statuses[e.Entry.Name] = e.Status
+		}
+	}
+	return statuses
+}
+
 func (m *MemStore) GetEvents(ctx context.Context, from, to time.Time) ([]Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var ret []Event
 	for _, v := range m.events {
 		if (v.Time.Equal(from) || v.Time.After(from)) && v.Time.Before(to) {
 			ret = append(ret, v)
 		}
 	}
+	sort.SliceStable(ret, func(i, j int) bool { return lessEvent(ret[i], ret[j]) })
 	return ret, nil
 }
 
+func (m *MemStore) QueryEvents(ctx context.Context, from, to time.Time, opts ...EventsOption) ([]Event, error) {
+	events, err := m.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	q := NewEventsQuery(opts...)
+	if len(q.Names) > 0 {
+		events = filterEvents(events, q.Names)
+	}
+	if q.Status != "" {
+		var ret []Event
+		for _, e := range events {
+			if e.Status == q.Status {
+				ret = append(ret, e)
+			}
+		}
+		events = ret
+	}
+	if q.Offset > 0 {
+		if q.Offset >= len(events) {
+			return nil, nil
+		}
+		events = events[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(events) {
+		events = events[:q.Limit]
+	}
+	return events, nil
+}
+
+func (m *MemStore) CountEvents(ctx context.Context, from, to time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, v := range m.events {
+		if (v.Time.Equal(from) || v.Time.After(from)) && v.Time.Before(to) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemStore) LastEvent(ctx context.Context, name string, opts ...EventsOption) (Event, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Event{}, false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// the events are mostly appended in time order, the reverse scan keeps the last added of the same time
+	status := NewEventsQuery(opts...).Status
+	var (
+		last  Event
+		found bool
+	)
+	for i := len(m.events) - 1; i >= 0; i-- {
+		if v := m.events[i]; v.Entry.Name == name && (status == "" || v.Status == status) && (!found || v.Time.After(last.Time)) {
+			last, found = v, true
+		}
+	}
+	return last, found, nil
+}
+
+func (m *MemStore) GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]Event, error) {
+	events, err := m.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Event, 0)
+	for _, v := range events {
+		if v.Entry.Name == name {
+			ret = append(ret, v)
+		}
+	}
+	return ret, nil
+}
+
+func (m *MemStore) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]Event, error) {
+	events, err := m.GetEvents(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return filterEvents(events, names), nil
+}
+
 func (m *MemStore) DeleteEvents(ctx context.Context, until time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var filtered []Event
 	for _, v := range m.events {
 		if v.Time.Equal(until) || v.Time.After(until) {
@@ -95,113 +675,584 @@ func (m *MemStore) DeleteEvents(ctx context.Context, until time.Time) error {
 	}
 
 	m.events = filtered
+	for k := range m.claims {
+		if k.time < until.UnixNano() {
+			delete(m.claims, k)
+		}
+	}
 	return nil
 }
 
+// StaleClaims implements ClaimStore
+func (m *MemStore) StaleClaims(ctx context.Context, before time.Time) ([]Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ret []Event
+	for _, v := range m.events {
+		if v.Status == EventStatusClaimed && m.claimedAt(v).Before(before) {
+			ret = append(ret, v)
+		}
+	}
+	return ret, nil
+}
+
+// ReclaimEvent implements ClaimStore
+func (m *MemStore) ReclaimEvent(ctx context.Context, e Event, staleBefore, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := newMemEventKey(e)
+	for i, v := range m.events {
+		if newMemEventKey(v) != key || v.Status != EventStatusClaimed || !m.claimedAt(v).Before(staleBefore) {
+			continue
+		}
+		if m.claims == nil {
+			m.claims = make(map[memEventKey]time.Time)
+		}
+		m.claims[key] = now
+		m.events[i].FiredBy = e.FiredBy
+		return true, nil
+	}
+	return false, nil
+}
+
+// claimedAt returns the time the event is claimed
+func (m *MemStore) claimedAt(e Event) time.Time {
+	if t, ok := m.claims[newMemEventKey(e)]; ok {
+		return t
+	}
+	return e.Time
+}
+
+// AcquireLease implements LeaseStore
+func (m *MemStore) AcquireLease(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (Lease, error) {
+	if err := ctx.Err(); err != nil {
+		return Lease{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.leases[name]
+	if l.Holder != holder && now.Before(l.ExpiresAt) {
+		return l, nil
+	}
+	if l.Holder != holder {
+		l.Token++
+	}
+	l.Name, l.Holder, l.ExpiresAt = name, holder, now.Add(ttl)
+	if m.leases == nil {
+		m.leases = make(map[string]Lease)
+	}
+	m.leases[name] = l
+
+	return l, nil
+}
+
+// ReleaseLease implements LeaseStore
+func (m *MemStore) ReleaseLease(ctx context.Context, name, holder string, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.leases[name]; ok && l.Holder == holder {
+		l.ExpiresAt = now
+		m.leases[name] = l
+	}
+	return nil
+}
+
+// Heartbeat implements InstanceStore
+func (m *MemStore) Heartbeat(ctx context.Context, inst Instance) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, v := range m.instances {
+		if v.ID == inst.ID {
+			m.instances[i] = inst
+			return nil
+		}
+	}
+	m.instances = append(m.instances, inst)
+	return nil
+}
+
+// ListInstances implements InstanceStore
+func (m *MemStore) ListInstances(ctx context.Context) ([]Instance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Instance(nil), m.instances...), nil
+}
+
 var (
 	// EntriesTable in SQL table that store cron entries
 	EntriesTable = "_entries"
 	// EventsTable is SQL table that store executed entries
 	EventsTable = "_events"
+	// LeasesTable is SQL table that store the leases, see NewLeaseCoordinator
+	LeasesTable = "_leases"
+	// InstancesTable is SQL table that store the heartbeat of the instances, see WithInstance
+	InstancesTable = "_instances"
 )
 
 type SqlStore struct {
-	db     *sql.DB
+	db *sql.DB
+
+	lockCh chan struct{} // held from Lock to Unlock so that the goroutines of the process wait for each other
+	mu     sync.Mutex    // guards tx, locked and lockConn
 	tx     *sql.Tx
 	locked bool
+
+	advisoryLock    string        // name of the advisory lock, empty to lock the tables
+	advisoryTimeout time.Duration // wait for the advisory lock
+	lockConn        *sql.Conn     // connection holding the advisory lock
+
+	tablePrefix string // prepended to the table names, see WithTablePrefix
+}
+
+// SQLStoreOption configures the SqlStore
+type SQLStoreOption func(s *SqlStore)
+
+// WithAdvisoryLock makes Lock and Unlock use a MySQL named lock (GET_LOCK and RELEASE_LOCK) instead of locking the
+// tables, so that the tables stay readable and writable by the rest of the application while only one instance runs
+// the check. Lock fails with ErrLockTimeout if the lock is not acquired within timeout (rounded up to seconds).
+//
+// The lock is held by the connection, the operations while the store is locked run on that connection. If the
+// connection is lost the server releases the lock, the operations fail and Unlock returns ErrLockLost. The next Lock
+// acquires the lock again on a new connection.
+func WithAdvisoryLock(name string, timeout time.Duration) SQLStoreOption {
+	return func(s *SqlStore) {
+		s.advisoryLock = name
+		s.advisoryTimeout = timeout
+	}
+}
+
+// WithTablePrefix prepends prefix to the names of the tables of the store, ex: "myapp" stores the entries in
+// "myapp_entries" and the events in "myapp_events". Stores with different prefixes do not see each other entries and
+// events, so that several schedulers can share a database. Without prefix the store uses EntriesTable, EventsTable,
+// LeasesTable and InstancesTable.
+func WithTablePrefix(prefix string) SQLStoreOption {
+	return func(s *SqlStore) {
+		s.tablePrefix = prefix
+	}
 }
 
-func NewSQLStore(db *sql.DB) (*SqlStore, error) {
-	store := &SqlStore{db: db}
+func (s *SqlStore) entriesTable() string   { return s.tablePrefix + EntriesTable }
+func (s *SqlStore) eventsTable() string    { return s.tablePrefix + EventsTable }
+func (s *SqlStore) leasesTable() string    { return s.tablePrefix + LeasesTable }
+func (s *SqlStore) instancesTable() string { return s.tablePrefix + InstancesTable }
+
+// ErrLockTimeout is returned by Lock when the advisory lock is held by another instance for longer than the timeout
+var ErrLockTimeout = errors.New("timeout waiting for lock")
+
+// ErrLockLost is returned (wrapped) by Unlock when the connection holding the advisory lock is lost and the lock was
+// released by the server
+var ErrLockLost = errors.New("lock lost")
+
+// querier is *sql.Tx while the store is locked or *sql.DB otherwise
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction or the connection of the lock, or the DB when the store is not locked (ex: with
+// LeaseCoordinator)
+func (s *SqlStore) conn() querier {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tx != nil {
+		return s.tx
+	}
+	if s.lockConn != nil {
+		return s.lockConn
+	}
+	return s.db
+}
+
+func NewSQLStore(db *sql.DB, opts ...SQLStoreOption) (*SqlStore, error) {
+	store := &SqlStore{db: db, lockCh: make(chan struct{}, 1)}
+	for _, opt := range opts {
+		opt(store)
+	}
 
 	return store, nil
 }
 
-// Initialize the sql tables if not present
+// initializeAttempts is the number of attempts of Initialize when it races with another instance creating the tables
+const initializeAttempts = 3
+
+// Initialize the sql tables if not present. It is safe to be called by instances starting at the same time, it is
+// retried when the DDL races with another instance.
 func (s *SqlStore) Initialize(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		err := s.initialize(ctx)
+		if err == nil || attempt >= initializeAttempts || !isDDLRace(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+		}
+	}
+}
+
+// isDDLRace reports whether err is caused by another session creating or altering the same table
+func isDDLRace(err error) bool {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return false
+	}
+	switch myErr.Number {
+	case 1050, // table already exists
+		1060, // duplicate column name
+		1061, // duplicate key name
+		1213, // deadlock
+		1412: // table definition has changed
+		return true
+	}
+
+	return false
+}
+
+// isDuplicateKey reports whether err is caused by a row with the same primary key
+func isDuplicateKey(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1062
+}
+
+func (s *SqlStore) initialize(ctx context.Context) error {
 	// For now this is enough with assumption that this table is going to be stable.
 	// If in the future we need to migrate this we can introduce `_version` table for doing db migration
 	// right now, absence of that table marks that this is the initial version
 
-	// create entries table
-	query := fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS %s (
-  expression varchar(255) NOT NULL,
-  location varchar(255) NOT NULL,
-  name varchar(255) NOT NULL,
-  meta blob DEFAULT NULL,
-  active tinyint(1) DEFAULT '1',
-  PRIMARY KEY (expression,location,name)
-)
-`, EntriesTable)
-	_, err := s.db.ExecContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed creating entries table: %v", err)
+	// create entries table
+	query := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+  expression varchar(255) NOT NULL,
+  location varchar(255) NOT NULL,
+  name varchar(255) NOT NULL,
+  meta blob DEFAULT NULL,
+  active tinyint(1) DEFAULT '1',
+  ignore_blackout tinyint(1) NOT NULL DEFAULT '0',
+  timeout_ms bigint NOT NULL DEFAULT '0',
+  depends_on varchar(1024) NOT NULL DEFAULT '',
+  PRIMARY KEY (expression,location,name),
+  KEY name (name)
+)
+`, s.entriesTable())
+	_, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed creating entries table: %w", err)
+	}
+
+	// create events table
+	query = fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+  expression varchar(255) NOT NULL,
+  location varchar(255) NOT NULL,
+  name varchar(255) NOT NULL,
+  meta varchar(1024) DEFAULT NULL,
+  triggered_at timestamp(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  manual tinyint(1) NOT NULL DEFAULT '0',
+  status varchar(32) NOT NULL DEFAULT '',
+  fired_by varchar(255) NOT NULL DEFAULT '',
+  claimed_at datetime(3) DEFAULT NULL,
+  PRIMARY KEY (expression,location,name,triggered_at),
+  KEY name_triggered_at (name,triggered_at),
+  KEY triggered_at (triggered_at)
+)`, s.eventsTable())
+	_, err = s.db.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed creating events table: %w", err)
+	}
+
+	// create leases table
+	query = fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+  name varchar(255) NOT NULL,
+  holder varchar(255) NOT NULL,
+  token bigint NOT NULL DEFAULT '0',
+  expires_at datetime(3) NOT NULL,
+  PRIMARY KEY (name)
+)`, s.leasesTable())
+	_, err = s.db.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed creating leases table: %w", err)
+	}
+
+	// create instances table
+	query = fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+  id varchar(255) NOT NULL,
+  hostname varchar(255) NOT NULL DEFAULT '',
+  version varchar(255) NOT NULL DEFAULT '',
+  last_seen datetime(3) NOT NULL,
+  PRIMARY KEY (id)
+)`, s.instancesTable())
+	_, err = s.db.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed creating instances table: %w", err)
+	}
+
+	// tables created by previous version do not have the newer columns
+	columns := []struct{ table, column, definition string }{
+		{s.eventsTable(), "manual", "tinyint(1) NOT NULL DEFAULT '0'"},
+		{s.eventsTable(), "status", "varchar(32) NOT NULL DEFAULT ''"},
+		{s.eventsTable(), "fired_by", "varchar(255) NOT NULL DEFAULT ''"},
+		{s.eventsTable(), "claimed_at", "datetime(3) DEFAULT NULL"},
+		{s.entriesTable(), "ignore_blackout", "tinyint(1) NOT NULL DEFAULT '0'"},
+		{s.entriesTable(), "timeout_ms", "bigint NOT NULL DEFAULT '0'"},
+		{s.entriesTable(), "depends_on", "varchar(1024) NOT NULL DEFAULT ''"},
+	}
+	for _, c := range columns {
+		if err := s.ensureColumn(ctx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	// the events of TriggerNow are not on the minute, a second precision would round them to the scheduled event of the
+	// next minute
+	if err := s.ensureColumnType(ctx, s.eventsTable(), "triggered_at", "timestamp(6)", "timestamp(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)"); err != nil {
+		return err
+	}
+	if err := s.ensureIndex(ctx, s.eventsTable(), "name_triggered_at", "name,triggered_at"); err != nil {
+		return err
+	}
+	if err := s.ensureIndex(ctx, s.eventsTable(), "triggered_at", "triggered_at"); err != nil {
+		return err
+	}
+	if err := s.ensureIndex(ctx, s.entriesTable(), "name", "name"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ensureColumn adds the column to the table if it does not exist
+func (s *SqlStore) ensureColumn(ctx context.Context, table, column, definition string) error {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?"
+	if err := s.db.QueryRowContext(ctx, query, table, column).Scan(&count); err != nil {
+		return fmt.Errorf("failed checking %s table columns: %w", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	query = "ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed adding %s column to %s table: %w", column, table, err)
+	}
+
+	return nil
+}
+
+// ensureColumnType changes the definition of the column when its type is not columnType
+func (s *SqlStore) ensureColumnType(ctx context.Context, table, column, columnType, definition string) error {
+	var got string
+	query := "SELECT COLUMN_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?"
+	if err := s.db.QueryRowContext(ctx, query, table, column).Scan(&got); err != nil {
+		return fmt.Errorf("failed checking %s table columns: %w", table, err)
+	}
+	if got == columnType {
+		return nil
+	}
+
+	query = "ALTER TABLE " + table + " MODIFY COLUMN " + column + " " + definition
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed changing %s column of %s table: %w", column, table, err)
+	}
+
+	return nil
+}
+
+// ensureIndex adds the index to the table if it does not exist
+func (s *SqlStore) ensureIndex(ctx context.Context, table, index, columns string) error {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?"
+	if err := s.db.QueryRowContext(ctx, query, table, index).Scan(&count); err != nil {
+		return fmt.Errorf("failed checking %s table indexes: %w", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	query = "ALTER TABLE " + table + " ADD INDEX " + index + " (" + columns + ")"
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed adding %s index to %s table: %w", index, table, err)
 	}
 
-	// create events table
-	query = fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS %s (
-  expression varchar(255) NOT NULL,
-  location varchar(255) NOT NULL,
-  name varchar(255) NOT NULL,
-  meta varchar(1024) DEFAULT NULL,
-  triggered_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
-  PRIMARY KEY (expression,location,name,triggered_at)
-)`, EventsTable)
-	_, err = s.db.ExecContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed creating events table: %v", err)
+	return nil
+}
+
+// Health pings the database and runs a trivial query
+func (s *SqlStore) Health(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %v", err)
+	}
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("failed querying database: %v", err)
 	}
 
 	return nil
 }
 
-// Lock the table so that no other session can read or write Entries and Triggered table
+// Lock the table so that no other session can read or write Entries and Triggered table. With WithAdvisoryLock it
+// acquires the named lock instead.
+// Lock locks the tables, or acquires the advisory lock (see WithAdvisoryLock). Another goroutine of the process calling
+// Lock waits until the store is unlocked or the context is done.
 func (s *SqlStore) Lock(ctx context.Context) error {
-	if s.locked || s.tx != nil {
-		return errors.New("already locked or transaction exists")
+	select {
+	case s.lockCh <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// we use transaction because it guaranteed to give the same connection from SQL pool
 	var err error
+	if s.advisoryLock != "" {
+		err = s.lockAdvisory(ctx)
+	} else {
+		err = s.lockTables(ctx)
+	}
+	if err != nil {
+		<-s.lockCh
+	}
+
+	return err
+}
+
+func (s *SqlStore) lockTables(ctx context.Context) error {
+	// we use transaction because it guaranteed to give the same connection from SQL pool
 	txOptions := &sql.TxOptions{
 		Isolation: sql.LevelSerializable, // make sure that none is reading and writing to the table we lock
 	}
-	s.tx, err = s.db.BeginTx(ctx, txOptions)
+	tx, err := s.db.BeginTx(ctx, txOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create transaction: %v", err)
 	}
 
-	_, err = s.tx.ExecContext(ctx, fmt.Sprintf("LOCK TABLE `%s` WRITE, `%s` WRITE", EntriesTable, EventsTable))
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("LOCK TABLE `%s` WRITE, `%s` WRITE", s.entriesTable(), s.eventsTable()))
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
+	s.tx = tx
 	s.locked = true
 
 	return nil
 }
 
+// Unlock releases the lock of Lock. The store is unlocked even if it fails so that the next Lock does not wait forever.
 func (s *SqlStore) Unlock(ctx context.Context) error {
-	if !s.locked || s.tx == nil {
-		return errors.New("not locked or transaction not exists")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.locked {
+		return errors.New("not locked")
 	}
-	_, err := s.tx.ExecContext(ctx, "UNLOCK TABLES")
-	if err != nil {
+	defer func() { <-s.lockCh }()
+
+	if s.advisoryLock != "" {
+		return s.unlockAdvisory(ctx)
+	}
+	tx := s.tx
+	s.tx = nil
+	s.locked = false
+	if _, err := tx.ExecContext(ctx, "UNLOCK TABLES"); err != nil {
+		tx.Rollback()
 		return err
 	}
+
+	return nil
+}
+
+// lockAdvisory acquires the advisory lock on a dedicated connection
+func (s *SqlStore) lockAdvisory(ctx context.Context) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %v", err)
+	}
+
+	// GET_LOCK returns 1 when acquired, 0 on timeout and NULL on error
+	var acquired sql.NullInt64
+	timeout := int64((s.advisoryTimeout + time.Second - 1) / time.Second)
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", s.advisoryLock, timeout).Scan(&acquired); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to get lock %q: %v", s.advisoryLock, err)
+	}
+	if !acquired.Valid {
+		conn.Close()
+		return fmt.Errorf("failed to get lock %q", s.advisoryLock)
+	}
+	if acquired.Int64 != 1 {
+		conn.Close()
+		return ErrLockTimeout
+	}
+	s.lockConn = conn
+	s.locked = true
+
+	return nil
+}
+
+// unlockAdvisory releases the advisory lock and returns the connection to the pool. The store is unlocked even if
+// releasing fails, a lost connection has already released the lock.
+func (s *SqlStore) unlockAdvisory(ctx context.Context) error {
+	if !s.locked || s.lockConn == nil {
+		return errors.New("not locked")
+	}
+	conn := s.lockConn
+	s.lockConn = nil
 	s.locked = false
-	s.tx = nil
+
+	// RELEASE_LOCK returns 1 when released, 0 if the lock is held by another connection and NULL if it does not exist
+	var released sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", s.advisoryLock).Scan(&released)
+	if err != nil {
+		// discard the connection, the server releases the lock of a closed connection if it is still held
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		conn.Close()
+		return fmt.Errorf("%w: failed to release lock %q: %v", ErrLockLost, s.advisoryLock, err)
+	}
+	conn.Close()
+	if released.Int64 != 1 {
+		return fmt.Errorf("%w: lock %q was not held by the connection", ErrLockLost, s.advisoryLock)
+	}
 
 	return nil
 }
 
+// AddEntry stores the entry. The location is stored by name so it must be loadable with time.LoadLocation (ex: not
+// time.FixedZone), otherwise GetEntries would fail to read it back.
 func (s *SqlStore) AddEntry(ctx context.Context, entry Entry) error {
-	if entry.expression == "" {
-		return errors.New("got empty expression")
+	if err := validateStoredEntry(entry); err != nil {
+		return err
+	}
+	dependsOn, err := encodeDependsOn(entry.DependsOn)
+	if err != nil {
+		return err
 	}
-	query := "REPLACE INTO " + EntriesTable + " (expression, location, name, meta) VALUES (?, ?, ?, ?)"
-	_, err := s.tx.ExecContext(ctx, query, entry.expression, entry.Location.String(), entry.Name, entry.Meta)
+	// the active flag of an existing entry is kept, REPLACE would reset it
+	query := "INSERT INTO " + s.entriesTable() + " (expression, location, name, meta, active, ignore_blackout, timeout_ms, depends_on) VALUES (?, ?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE meta=VALUES(meta), ignore_blackout=VALUES(ignore_blackout), timeout_ms=VALUES(timeout_ms), depends_on=VALUES(depends_on)"
+	_, err = s.conn().ExecContext(ctx, query, entry.expression, entry.Location.String(), entry.Name, entry.Meta, !entry.Paused,
+		entry.IgnoreBlackout, entry.Timeout.Milliseconds(), dependsOn)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %v", err)
 	}
@@ -209,66 +1260,418 @@ func (s *SqlStore) AddEntry(ctx context.Context, entry Entry) error {
 	return nil
 }
 
-func (s *SqlStore) GetEntries(ctx context.Context) ([]Entry, error) {
-	entries := make([]Entry, 0)
-	query := "SELECT expression, location, name, meta FROM " + EntriesTable + " WHERE active=1"
-	rows, err := s.tx.QueryContext(ctx, query)
+func (s *SqlStore) GetEntries(ctx context.Context, opts ...EntriesOption) ([]Entry, error) {
+	q := NewEntriesQuery(opts...)
+	query := "SELECT expression, location, name, meta, active, ignore_blackout, timeout_ms, depends_on FROM " + s.entriesTable()
+	var (
+		where []string
+		args  []interface{}
+	)
+	if !q.IncludeInactive {
+		where = append(where, "active=1")
+	}
+	if q.NamePrefix != "" {
+		where = append(where, `name LIKE ? ESCAPE '\\'`)
+		args = append(args, likeEscaper.Replace(q.NamePrefix)+"%")
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if q.OrderByName {
+		query += " ORDER BY name, expression, location"
+	}
+	if q.Limit > 0 || q.Offset > 0 {
+		// MySQL has no OFFSET without LIMIT, the maximum is used for no limit
+		limit := uint64(math.MaxUint64)
+		if q.Limit > 0 {
+			limit = uint64(q.Limit)
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, max(q.Offset, 0))
+	}
+	return s.queryEntries(ctx, query, args...)
+}
+
+// encodeDependsOn encodes the dependencies as JSON array since a name can contain any character, empty if there is
+// none
+func encodeDependsOn(dependsOn []string) (string, error) {
+	if len(dependsOn) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(dependsOn)
+	if err != nil {
+		return "", fmt.Errorf("failed encoding dependencies: %v", err)
+	}
+	return string(b), nil
+}
+
+// likeEscaper escapes the wildcards of LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// validateStoredEntry checks that the entry can be stored by a persistent store (ex: SqlStore or FileStore) and read
+// back
+func validateStoredEntry(entry Entry) error {
+	if entry.Name == "" {
+		return errors.New("got empty name")
+	}
+	if entry.expression == "" {
+		return errors.New("got empty expression")
+	}
+	if _, err := time.LoadLocation(entry.Location.String()); err != nil {
+		return fmt.Errorf("location %q of entry %q is not in the tz database, use time.LoadLocation: %v",
+			entry.Location, entry.Name, err)
+	}
+	return nil
+}
+
+// GetEntry reads the entries with the name index
+func (s *SqlStore) GetEntry(ctx context.Context, name string) (Entry, error) {
+	query := "SELECT expression, location, name, meta, active, ignore_blackout, timeout_ms, depends_on FROM " + s.entriesTable() +
+		" WHERE name=?"
+	entries, err := s.queryEntries(ctx, query, name)
+	if err != nil {
+		return Entry{}, err
+	}
+	return singleEntry(name, entries)
+}
+
+// queryEntries runs the query selecting the entry columns and reads the entries
+func (s *SqlStore) queryEntries(ctx context.Context, query string, args ...interface{}) ([]Entry, error) {
+	rows, err := s.conn().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query entries from DB: %v", err)
 	}
+	defer rows.Close()
 
+	return scanEntries(rows)
+}
+
+// scanEntries reads the rows of expression, location, name, meta, active, ignore_blackout, timeout_ms and depends_on
+func scanEntries(rows *sql.Rows) ([]Entry, error) {
+	entries := make([]Entry, 0)
 	for rows.Next() {
-		var expression, location, name string
+		var expression, location, name, dependsOn string
 		var meta sql.NullString
-		if err := rows.Scan(&expression, &location, &name, &meta); err != nil {
+		var active, ignoreBlackout bool
+		var timeoutMs int64
+		if err := rows.Scan(&expression, &location, &name, &meta, &active, &ignoreBlackout, &timeoutMs, &dependsOn); err != nil {
 			return nil, fmt.Errorf("failed reading a row: %v", err)
 		}
 		loc, err := time.LoadLocation(location)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load location %q: %v", location, err)
 		}
-		entry, err := Parse(expression, loc, name)
+		entry, err := ParseStored(expression, loc, name)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse expression:%q loc:%q name:%q: %v", expression, loc, name, err)
 		}
 		entry.Meta = meta.String
+		entry.Paused = !active
+		entry.IgnoreBlackout = ignoreBlackout
+		entry.Timeout = time.Duration(timeoutMs) * time.Millisecond
+		if dependsOn != "" {
+			if err := json.Unmarshal([]byte(dependsOn), &entry.DependsOn); err != nil {
+				return nil, fmt.Errorf("failed to decode dependencies of %q: %v", name, err)
+			}
+		}
 
 		entries = append(entries, entry)
 	}
 
-	return entries, nil
+	return entries, rows.Err()
 }
 
 func (s *SqlStore) DeleteEntry(ctx context.Context, entry Entry) error {
-	query := "DELETE FROM " + EntriesTable + " WHERE expression=? AND location=? AND name=?"
-	_, err := s.tx.ExecContext(ctx, query, entry.expression, entry.Location.String(), entry.Name)
+	query := "DELETE FROM " + s.entriesTable() + " WHERE expression=? AND location=? AND name=?"
+	_, err := s.conn().ExecContext(ctx, query, entry.expression, entry.Location.String(), entry.Name)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateEntry inserts the updated entry and deletes the other rows with the name in a transaction so that other
+// instances do not see the entry half updated. While Lock holds the table lock they run on the connection of the lock,
+// the insert runs first so that the entry is not lost when it fails.
+func (s *SqlStore) UpdateEntry(ctx context.Context, name string, updated Entry) error {
+	if err := validateStoredEntry(updated); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	lockTx, lockConn := s.tx, s.lockConn
+	s.mu.Unlock()
+	if lockTx != nil {
+		return s.updateEntry(ctx, lockTx, name, updated)
+	}
+
+	var (
+		tx  *sql.Tx
+		err error
+	)
+	if lockConn != nil {
+		tx, err = lockConn.BeginTx(ctx, nil)
+	} else {
+		tx, err = s.db.BeginTx(ctx, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create transaction: %v", err)
+	}
+	if err := s.updateEntry(ctx, tx, name, updated); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *SqlStore) updateEntry(ctx context.Context, q querier, name string, updated Entry) error {
+	var count int
+	query := "SELECT COUNT(*) FROM " + s.entriesTable() + " WHERE name=?"
+	if err := q.QueryRowContext(ctx, query, name).Scan(&count); err != nil {
+		return fmt.Errorf("failed to query entry: %v", err)
+	}
+	if count == 0 {
+		return ErrEntryNotFound
+	}
+
+	dependsOn, err := encodeDependsOn(updated.DependsOn)
+	if err != nil {
+		return err
+	}
+	// unlike AddEntry the active flag is replaced by the updated entry
+	query = "INSERT INTO " + s.entriesTable() + " (expression, location, name, meta, active, ignore_blackout, timeout_ms, depends_on) VALUES (?, ?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE meta=VALUES(meta), active=VALUES(active), ignore_blackout=VALUES(ignore_blackout), timeout_ms=VALUES(timeout_ms), depends_on=VALUES(depends_on)"
+	expression, location := updated.expression, updated.Location.String()
+	_, err = q.ExecContext(ctx, query, expression, location, updated.Name, updated.Meta, !updated.Paused,
+		updated.IgnoreBlackout, updated.Timeout.Milliseconds(), dependsOn)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %v", err)
 	}
 
+	query = "DELETE FROM " + s.entriesTable() + " WHERE name=? AND NOT (expression=? AND location=? AND name=?)"
+	if _, err := q.ExecContext(ctx, query, name, expression, location, updated.Name); err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	return nil
+}
+
+func (s *SqlStore) SetEntryActive(ctx context.Context, name string, active bool) error {
+	var count int
+	query := "SELECT COUNT(*) FROM " + s.entriesTable() + " WHERE name=?"
+	if err := s.conn().QueryRowContext(ctx, query, name).Scan(&count); err != nil {
+		return fmt.Errorf("failed to query entry: %v", err)
+	}
+	if count == 0 {
+		return ErrEntryNotFound
+	}
+
+	query = "UPDATE " + s.entriesTable() + " SET active=? WHERE name=?"
+	if _, err := s.conn().ExecContext(ctx, query, active, name); err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+
 	return nil
 }
 
 func (s *SqlStore) AddEvent(ctx context.Context, e Event) error {
-	query := "REPLACE INTO " + EventsTable + " (expression, location, name, triggered_at, meta) VALUES (?, ?, ?, ?, ?)"
 	expression := e.Entry.expression
 	location := e.Entry.Location.String()
 	name := e.Entry.Name
-	_, err := s.tx.ExecContext(ctx, query, expression, location, name, e.Time, e.Entry.Meta)
+	l, fenced := LeaseFromContext(ctx)
+	if !fenced {
+		query := "INSERT INTO " + s.eventsTable() + " (expression, location, name, triggered_at, meta, manual, status, fired_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+		_, err := s.conn().ExecContext(ctx, query, expression, location, name, e.Time, e.Entry.Meta, e.Manual, e.Status, e.FiredBy)
+		if isDuplicateKey(err) {
+			return ErrAlreadyTriggered
+		}
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %v", err)
+		}
+		return nil
+	}
+
+	// the event is only written if the token of the lease is still the current one
+	query := "INSERT INTO " + s.eventsTable() + " (expression, location, name, triggered_at, meta, manual, status, fired_by) " +
+		"SELECT ?, ?, ?, ?, ?, ?, ?, ? FROM " + s.leasesTable() + " WHERE name=? AND token=?"
+	res, err := s.conn().ExecContext(ctx, query, expression, location, name, e.Time, e.Entry.Meta, e.Manual, e.Status, e.FiredBy,
+		l.Name, l.Token)
+	if isDuplicateKey(err) {
+		return ErrAlreadyTriggered
+	}
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %v", err)
 	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// eventsBatchSize is the maximum number of rows of a statement of SqlStore.AddEvents so that it stays under the
+// max_allowed_packet of the server
+var eventsBatchSize = 100
+
+// AddEvents records the events with a multi-row statement for every eventsBatchSize events. The events of a failed
+// statement (ex: one of them is already triggered) are recorded one by one so that a bad event does not fail the
+// others. The events are recorded one by one
+// when the context has a lease since every event is fenced, the tables are not locked with a lease.
+func (s *SqlStore) AddEvents(ctx context.Context, events []Event) error {
+	if _, fenced := LeaseFromContext(ctx); fenced {
+		return addEachEvent(ctx, s, events)
+	}
+
+	var errs EventErrors
+	for start := 0; start < len(events); start += eventsBatchSize {
+		end := start + eventsBatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		batch := events[start:end]
+		if err := s.insertEvents(ctx, batch); err == nil {
+			continue
+		}
+		var batchErrs EventErrors
+		if !errors.As(addEachEvent(ctx, s, batch), &batchErrs) {
+			continue
+		}
+		if errs == nil {
+			errs = make(EventErrors, len(events))
+		}
+		copy(errs[start:end], batchErrs)
+	}
+	if errs == nil {
+		return nil
+	}
+	return errs
+}
+
+// insertEvents records the events in a single statement
+func (s *SqlStore) insertEvents(ctx context.Context, events []Event) error {
+	args := make([]interface{}, 0, 8*len(events))
+	for _, e := range events {
+		args = append(args, e.Entry.expression, e.Entry.Location.String(), e.Entry.Name, e.Time, e.Entry.Meta, e.Manual,
+			e.Status, e.FiredBy)
+	}
+	query := "INSERT INTO " + s.eventsTable() + " (expression, location, name, triggered_at, meta, manual, status, fired_by) VALUES " +
+		"(?, ?, ?, ?, ?, ?, ?, ?)" + strings.Repeat(", (?, ?, ?, ?, ?, ?, ?, ?)", len(events)-1)
+	if _, err := s.conn().ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
 
 	return nil
 }
 
 func (s *SqlStore) GetEvents(ctx context.Context, from, to time.Time) ([]Event, error) {
-	query := `SELECT expression, location, name, meta, triggered_at from ` + EventsTable + ` WHERE triggered_at >= ? AND triggered_at < ?`
-	rows, err := s.tx.QueryContext(ctx, query, from, to)
+	query := `SELECT expression, location, name, meta, triggered_at, manual, status, fired_by from ` + s.eventsTable() + ` WHERE triggered_at >= ? AND triggered_at < ? ORDER BY triggered_at, name, expression, location`
+	return s.queryEvents(ctx, query, from, to)
+}
+
+func (s *SqlStore) QueryEvents(ctx context.Context, from, to time.Time, opts ...EventsOption) ([]Event, error) {
+	q := NewEventsQuery(opts...)
+	query := `SELECT expression, location, name, meta, triggered_at, manual, status, fired_by from ` + s.eventsTable() +
+		` WHERE triggered_at >= ? AND triggered_at < ?`
+	args := []interface{}{from, to}
+	if len(q.Names) > 0 {
+		query += ` AND name IN (?` + strings.Repeat(",?", len(q.Names)-1) + `)`
+		for _, n := range q.Names {
+			args = append(args, n)
+		}
+	}
+	if q.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, q.Status)
+	}
+	query += ` ORDER BY triggered_at, name, expression, location`
+	if q.Limit > 0 || q.Offset > 0 {
+		// MySQL has no OFFSET without LIMIT, the maximum is used for no limit
+		limit := uint64(math.MaxUint64)
+		if q.Limit > 0 {
+			limit = uint64(q.Limit)
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, max(q.Offset, 0))
+	}
+	return s.queryEvents(ctx, query, args...)
+}
+
+// GetEventsForEntries reads the events with the name_triggered_at index
+func (s *SqlStore) GetEventsForEntries(ctx context.Context, names []string, from, to time.Time) ([]Event, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, 0, len(names)+2)
+	for _, n := range names {
+		args = append(args, n)
+	}
+	args = append(args, from, to)
+	query := `SELECT expression, location, name, meta, triggered_at, manual, status, fired_by from ` + s.eventsTable() +
+		` WHERE name IN (?` + strings.Repeat(",?", len(names)-1) + `) AND triggered_at >= ? AND triggered_at < ?`
+	return s.queryEvents(ctx, query, args...)
+}
+
+// GetEventsByName reads the events with the name_triggered_at index
+func (s *SqlStore) GetEventsByName(ctx context.Context, name string, from, to time.Time) ([]Event, error) {
+	query := `SELECT expression, location, name, meta, triggered_at, manual, status, fired_by from ` + s.eventsTable() +
+		` WHERE name = ? AND triggered_at >= ? AND triggered_at < ? ORDER BY triggered_at ASC`
+	events, err := s.queryEvents(ctx, query, name, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = make([]Event, 0)
+	}
+	return events, nil
+}
+
+func (s *SqlStore) CountEvents(ctx context.Context, from, to time.Time) (int64, error) {
+	var n int64
+	query := "SELECT COUNT(*) FROM " + s.eventsTable() + " WHERE triggered_at >= ? AND triggered_at < ?"
+	if err := s.conn().QueryRowContext(ctx, query, from, to).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed querying database: %v", err)
+	}
+	return n, nil
+}
+
+// LastEvent reads the event with the name_triggered_at index
+func (s *SqlStore) LastEvent(ctx context.Context, name string, opts ...EventsOption) (Event, bool, error) {
+	query := `SELECT expression, location, name, meta, triggered_at, manual, status, fired_by from ` + s.eventsTable() +
+		` WHERE name = ?`
+	args := []interface{}{name}
+	if status := NewEventsQuery(opts...).Status; status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY triggered_at DESC LIMIT 1`
+	events, err := s.queryEvents(ctx, query, args...)
+	if err != nil || len(events) == 0 {
+		return Event{}, false, err
+	}
+	return events[0], true, nil
+}
+
+// queryEvents runs the query selecting the event columns and reads the events
+func (s *SqlStore) queryEvents(ctx context.Context, query string, args ...interface{}) ([]Event, error) {
+	rows, err := s.conn().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed querying database: %v", err)
 	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
 
+// scanEvents reads the rows of expression, location, name, meta, triggered_at, manual, status and fired_by
+func scanEvents(rows *sql.Rows) ([]Event, error) {
 	var events []Event
 	for rows.Next() {
 		var ev Event
@@ -276,7 +1679,7 @@ func (s *SqlStore) GetEvents(ctx context.Context, from, to time.Time) ([]Event,
 		var meta sql.NullString
 		var triggeredAt time.Time
 
-		if err := rows.Scan(&expression, &location, &name, &meta, &triggeredAt); err != nil {
+		if err := rows.Scan(&expression, &location, &name, &meta, &triggeredAt, &ev.Manual, &ev.Status, &ev.FiredBy); err != nil {
 			return nil, fmt.Errorf("failed reading a row: %v", err)
 		}
 
@@ -284,7 +1687,7 @@ func (s *SqlStore) GetEvents(ctx context.Context, from, to time.Time) ([]Event,
 		if err != nil {
 			return nil, fmt.Errorf("failed to load location %q: %v", location, err)
 		}
-		entry, err := Parse(expression, loc, name)
+		entry, err := ParseStored(expression, loc, name)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load entry expression:%q loc:%q name:%q: %v", expression, loc, name, err)
 		}
@@ -294,15 +1697,144 @@ func (s *SqlStore) GetEvents(ctx context.Context, from, to time.Time) ([]Event,
 		events = append(events, ev)
 	}
 
-	return events, nil
+	return events, rows.Err()
+}
+
+// SetEventStatus implements EventStatusStore. It does not use the connection of the lock, the update waits until the
+// tables are unlocked.
+func (s *SqlStore) SetEventStatus(ctx context.Context, e Event, status string) error {
+	query := "UPDATE " + s.eventsTable() + " SET status=? WHERE expression=? AND location=? AND name=? AND triggered_at=?"
+	_, err := s.db.ExecContext(ctx, query, status, e.Entry.expression, e.Entry.Location.String(), e.Entry.Name, e.Time)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	return nil
+}
+
+// GetEventStatuses implements EventStatusStore, like SetEventStatus it does not use the connection of the lock
+func (s *SqlStore) GetEventStatuses(ctx context.Context, names []string, from, to time.Time) (map[string]string, error) {
+	statuses := make(map[string]string)
+	if len(names) == 0 {
+		return statuses, nil
+	}
+	args := make([]interface{}, 0, len(names)+2)
+	for _, n := range names {
+		args = append(args, n)
+	}
+	args = append(args, from, to)
+	query := `SELECT name, status FROM ` + s.eventsTable() + ` WHERE name IN (?` + strings.Repeat(",?", len(names)-1) +
+		`) AND triggered_at >= ? AND triggered_at < ? AND manual = 0`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying database: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, status string
+		if err := rows.Scan(&name, &status); err != nil {
+			return nil, fmt.Errorf("failed reading a row: %v", err)
+		}
+		statuses[name] = status
+	}
+
+	return statuses, rows.Err()
+}
+
+// StaleClaims implements ClaimStore, claimed_at is only set when the event is reclaimed
+func (s *SqlStore) StaleClaims(ctx context.Context, before time.Time) ([]Event, error) {
+	query := `SELECT expression, location, name, meta, triggered_at, manual, status, fired_by from ` + s.eventsTable() +
+		` WHERE status = ? AND COALESCE(claimed_at, triggered_at) < ?`
+	return s.queryEvents(ctx, query, EventStatusClaimed, before)
+}
+
+// ReclaimEvent implements ClaimStore
+func (s *SqlStore) ReclaimEvent(ctx context.Context, e Event, staleBefore, now time.Time) (bool, error) {
+	query := "UPDATE " + s.eventsTable() + " SET fired_by=?, claimed_at=? WHERE expression=? AND location=? AND name=? AND " +
+		"triggered_at=? AND status=? AND COALESCE(claimed_at, triggered_at) < ?"
+	res, err := s.conn().ExecContext(ctx, query, e.FiredBy, now, e.Entry.expression, e.Entry.Location.String(), e.Entry.Name,
+		e.Time, EventStatusClaimed, staleBefore)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute query: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	return n > 0, nil
 }
 
 func (s *SqlStore) DeleteEvents(ctx context.Context, until time.Time) error {
-	query := "DELETE FROM " + EventsTable + " WHERE triggered_at < ?"
-	_, err := s.tx.ExecContext(ctx, query, until)
+	query := "DELETE FROM " + s.eventsTable() + " WHERE triggered_at < ?"
+	_, err := s.conn().ExecContext(ctx, query, until)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %v", err)
 	}
 
 	return nil
 }
+
+// AcquireLease implements LeaseStore. The token is incremented when the holder changes so that the events written with
+// the token of the former holder are rejected.
+func (s *SqlStore) AcquireLease(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (Lease, error) {
+	query := "INSERT IGNORE INTO " + s.leasesTable() + " (name, holder, token, expires_at) VALUES (?, '', 0, ?)"
+	if _, err := s.db.ExecContext(ctx, query, name, now); err != nil {
+		return Lease{}, fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	// MySQL assigns from left to right, token is compared with the previous holder
+	query = "UPDATE " + s.leasesTable() + " SET token=IF(holder=?, token, token+1), holder=?, expires_at=? WHERE name=? AND (holder=? OR expires_at<=?)"
+	if _, err := s.db.ExecContext(ctx, query, holder, holder, now.Add(ttl), name, holder, now); err != nil {
+		return Lease{}, fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	l := Lease{Name: name}
+	query = "SELECT holder, token, expires_at FROM " + s.leasesTable() + " WHERE name=?"
+	if err := s.db.QueryRowContext(ctx, query, name).Scan(&l.Holder, &l.Token, &l.ExpiresAt); err != nil {
+		return Lease{}, fmt.Errorf("failed to query lease: %v", err)
+	}
+
+	return l, nil
+}
+
+// ReleaseLease implements LeaseStore
+func (s *SqlStore) ReleaseLease(ctx context.Context, name, holder string, now time.Time) error {
+	query := "UPDATE " + s.leasesTable() + " SET expires_at=? WHERE name=? AND holder=?"
+	if _, err := s.db.ExecContext(ctx, query, now, name, holder); err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	return nil
+}
+
+// Heartbeat implements InstanceStore
+func (s *SqlStore) Heartbeat(ctx context.Context, inst Instance) error {
+	query := "REPLACE INTO " + s.instancesTable() + " (id, hostname, version, last_seen) VALUES (?, ?, ?, ?)"
+	if _, err := s.db.ExecContext(ctx, query, inst.ID, inst.Hostname, inst.Version, inst.LastSeen); err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	return nil
+}
+
+// ListInstances implements InstanceStore
+func (s *SqlStore) ListInstances(ctx context.Context) ([]Instance, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, hostname, version, last_seen FROM "+s.instancesTable())
+	if err != nil {
+		return nil, fmt.Errorf("failed querying database: %v", err)
+	}
+	defer rows.Close()
+
+	var instances []Instance
+	for rows.Next() {
+		var inst Instance
+		if err := rows.Scan(&inst.ID, &inst.Hostname, &inst.Version, &inst.LastSeen); err != nil {
+			return nil, fmt.Errorf("failed reading a row: %v", err)
+		}
+		instances = append(instances, inst)
+	}
+
+	return instances, rows.Err()
+}
@@ -3,13 +3,33 @@ package cron
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+)
+
+// the stores implement Store and the optional interfaces
+var (
+	_ Store             = (*MemStore)(nil)
+	_ EventsAdder       = (*MemStore)(nil)
+	_ EntryEventsGetter = (*MemStore)(nil)
+	_ LeaseStore        = (*MemStore)(nil)
+	_ InstanceStore     = (*MemStore)(nil)
+	_ ClaimStore        = (*MemStore)(nil)
+
+	_ Store             = (*SqlStore)(nil)
+	_ EventsAdder       = (*SqlStore)(nil)
+	_ EntryEventsGetter = (*SqlStore)(nil)
+	_ LeaseStore        = (*SqlStore)(nil)
+	_ InstanceStore     = (*SqlStore)(nil)
+	_ ClaimStore        = (*SqlStore)(nil)
 )
 
 func TestCron_MemStore(t *testing.T) {
@@ -22,6 +42,481 @@ func TestCron_SQLStore(t *testing.T) {
 		t.Skip()
 	}
 
+	store, err := NewSQLStore(openTestDB(t)) // This will check and create necessary table if not exists
+	if err != nil {
+		t.Fatalf("Failed to initialize MysqlPersister: %v", err)
+	}
+	storeTest(t, store)
+}
+
+func TestCron_SQLStoreAdvisoryLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	store1, _ := NewSQLStore(db, WithAdvisoryLock("cron_check_test", time.Second))
+	store2, _ := NewSQLStore(db, WithAdvisoryLock("cron_check_test", time.Second))
+	storeTest(t, store1)
+
+	if err := store1.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := store2.Lock(ctx), ErrLockTimeout; got != want {
+		t.Fatalf("got error %v want %v", got, want)
+	}
+
+	// the tables are not locked for the rest of the application
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+EventsTable).Scan(&count); err != nil {
+		t.Fatalf("failed reading events while locked: %v", err)
+	}
+
+	// the server releases the lock of a lost connection
+	var id int64
+	if err := store1.conn().QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("KILL %d", id)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Lock(ctx); err != nil {
+		t.Fatalf("lock is not released after the connection is lost: %v", err)
+	}
+	if err := store1.Unlock(ctx); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("got error %v want %v", err, ErrLockLost)
+	}
+	if err := store2.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the instance acquires the lock again on a new connection
+	if err := store1.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store1.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCron_SQLStoreLockConcurrent(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	for _, opts := range [][]SQLStoreOption{nil, {WithAdvisoryLock("cron_lock_test", time.Second)}} {
+		store, err := NewSQLStore(db, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Initialize(ctx); err != nil {
+			t.Fatal(err)
+		}
+		s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store)
+
+		// the goroutines of the scheduler wait for the lock of each other instead of failing, run with -race
+		on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+		var entries []Entry
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			entry, err := Parse("* * * * *", time.UTC, fmt.Sprintf("LOCK_%d", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if err := s.AddEntry(ctx, entry); err != nil {
+					t.Error(err)
+				}
+			}()
+			go func(i int) {
+				defer wg.Done()
+				if err := s.check(ctx, on.Add(time.Duration(i)*time.Minute)); err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+		s.inFlight.Wait()
+
+		got, err := store.GetEntries(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(entries) {
+			t.Errorf("got entries %d want %d", len(got), len(entries))
+		}
+		for _, entry := range entries {
+			if err := store.DeleteEntry(ctx, entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestCron_SQLStoreTriggerNow(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	store, err := NewSQLStore(openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	triggerNowTest(t, store)
+}
+
+func TestCron_SQLStoreInitializeConcurrent(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	for _, table := range []string{EntriesTable, EventsTable, LeasesTable, InstancesTable} {
+		if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store, _ := NewSQLStore(db)
+			errs <- store.Initialize(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent initialize failed: %v", err)
+		}
+	}
+}
+
+func TestCron_SQLStoreAddEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := context.Background()
+	store, err := NewSQLStore(openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer func(size int) { eventsBatchSize = size }(eventsBatchSize)
+	eventsBatchSize = 2
+
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	var events []Event
+	for i := 0; i < 5; i++ {
+		e, err := Parse("* * * * *", time.UTC, fmt.Sprintf("ENTRY_%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, Event{Entry: e, Time: on})
+	}
+	defer store.DeleteEvents(ctx, on.Add(time.Minute))
+
+	if err := store.AddEvents(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetEvents(ctx, on, on.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(events); len(got) != want {
+		t.Errorf("got %d events want %d", len(got), want)
+	}
+}
+
+func TestCron_SQLStoreUpdateEntryInsertFails(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := context.Background()
+	store, err := NewSQLStore(openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Parse("* * * * *", time.UTC, "UPDATE_FAILS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	defer store.DeleteEntry(ctx, entry)
+
+	// the name does not fit in the column so the insert fails
+	updated, err := Parse("0 * * * *", time.UTC, strings.Repeat("A", 256))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, locked := range []bool{false, true} {
+		if locked {
+			if err := store.Lock(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.UpdateEntry(ctx, entry.Name, updated); err == nil {
+			t.Fatalf("locked %t: got no error updating entry with too long name", locked)
+		}
+		if locked {
+			if err := store.Unlock(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := store.GetEntry(ctx, entry.Name)
+		if err != nil {
+			t.Fatalf("locked %t: entry is lost after failed update: %v", locked, err)
+		}
+		if got.Expression() != entry.Expression() {
+			t.Errorf("locked %t: got expression %q want %q", locked, got.Expression(), entry.Expression())
+		}
+	}
+}
+
+func TestCron_SQLStoreTablePrefix(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := context.Background()
+	db := openTestDB(t)
+	store1, err := NewSQLStore(db, WithTablePrefix("app1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store2, err := NewSQLStore(db, WithTablePrefix("app2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, store := range []*SqlStore{store1, store2} {
+		if err := store.Initialize(ctx); err != nil {
+			t.Fatal(err)
+		}
+		defer func(store *SqlStore) {
+			for _, table := range []string{store.entriesTable(), store.eventsTable(), store.leasesTable(), store.instancesTable()} {
+				db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table)
+			}
+		}(store)
+	}
+	storeTest(t, store1)
+
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	if err := store1.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := store1.AddEvent(ctx, Event{Entry: entry, Time: on}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := store2.GetEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d entries of the other prefix want 0", len(entries))
+	}
+	events, err := store2.GetEvents(ctx, on, on.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("got %d events of the other prefix want 0", len(events))
+	}
+	if err := store2.AddEvent(ctx, Event{Entry: entry, Time: on}); err != nil {
+		t.Errorf("event is already triggered in the other prefix: %v", err)
+	}
+}
+
+func TestSQLStore_TablePrefix(t *testing.T) {
+	store, err := NewSQLStore(nil, WithTablePrefix("myapp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{store.entriesTable(), store.eventsTable(), store.leasesTable(), store.instancesTable()}
+	want := []string{"myapp_entries", "myapp_events", "myapp_leases", "myapp_instances"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got tables %v want %v", got, want)
+	}
+
+	store, err = NewSQLStore(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := store.entriesTable(), EntriesTable; got != want {
+		t.Errorf("got default table %q want %q", got, want)
+	}
+}
+
+func TestSQLStore_Health(t *testing.T) {
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:3306)/cron")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewSQLStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if err := store.Health(context.Background()); err == nil {
+		t.Error("expected error on closed database")
+	}
+}
+
+func TestSQLStore_AddEntryLocation(t *testing.T) {
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:3306)/cron")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewSQLStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// the location is validated before the database is used
+	entry, err := Parse("* * * * *", time.FixedZone("custom", 3600), "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `location "custom" of entry "ENTRY_1" is not in the tz database, use time.LoadLocation: unknown time zone custom`
+	if got := fmt.Sprint(store.AddEntry(context.Background(), entry)); got != want {
+		t.Errorf("got error %q want %q", got, want)
+	}
+	if got := fmt.Sprint(store.UpdateEntry(context.Background(), "ENTRY_1", entry)); got != want {
+		t.Errorf("got update error %q want %q", got, want)
+	}
+}
+
+func TestIsDDLRace(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: fmt.Errorf("failed creating entries table: %w", &mysql.MySQLError{Number: 1050}), want: true},
+		{err: fmt.Errorf("failed adding column: %w", &mysql.MySQLError{Number: 1060}), want: true},
+		{err: &mysql.MySQLError{Number: 1045}, want: false}, // access denied
+		{err: errors.New("table already exists"), want: false},
+	}
+	for _, tt := range tests {
+		if got := isDDLRace(tt.err); got != tt.want {
+			t.Errorf("isDDLRace(%v) got %t want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestGetEventsForEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	mem := &MemStore{}
+	for _, name := range []string{"ENTRY_1", "ENTRY_2", "ENTRY_3"} {
+		e, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mem.AddEvent(ctx, Event{Entry: e, Time: now})
+	}
+
+	// the embedded interface hides GetEventsForEntries of MemStore so that GetEvents is filtered
+	for _, store := range []Store{mem, struct{ Store }{mem}} {
+		events, err := getEventsForEntries(ctx, store, []string{"ENTRY_1", "ENTRY_3", "ENTRY_4"}, now, now.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range events {
+			got = append(got, e.Entry.Name)
+		}
+		if want := []string{"ENTRY_1", "ENTRY_3"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%T got events of %v want %v", store, got, want)
+		}
+
+		events, err = getEventsForEntries(ctx, store, nil, now, now.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(events), 0; got != want {
+			t.Errorf("%T got %d events without names want %d", store, got, want)
+		}
+	}
+}
+
+// BenchmarkSQLStore_GetEvents compares reading the events of every entry in the minute with reading the events of the
+// matching entries only, with 5000 entries of which 50 match
+func BenchmarkSQLStore_GetEvents(b *testing.B) {
+	if testing.Short() {
+		b.Skip()
+	}
+	ctx := context.Background()
+	db := openTestDB(b)
+	store, err := NewSQLStore(db)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := store.Initialize(ctx); err != nil {
+		b.Fatal(err)
+	}
+
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	var names []string
+	for i := 0; i < 5000; i++ {
+		e, err := Parse("* * * * *", time.UTC, fmt.Sprintf("BENCH_%d", i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := store.AddEvent(ctx, Event{Entry: e, Time: on}); err != nil {
+			b.Fatal(err)
+		}
+		if i%100 == 0 {
+			names = append(names, e.Name)
+		}
+	}
+	defer store.DeleteEvents(ctx, on.Add(time.Minute))
+
+	b.Run("all", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			events, err := store.GetEvents(ctx, on, on.Add(time.Minute))
+			if err != nil {
+				b.Fatal(err)
+			}
+			filterEvents(events, names)
+		}
+	})
+	b.Run("entries", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.GetEventsForEntries(ctx, names, on, on.Add(time.Minute)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// openTestDB opens the MySQL test database configured by the MYSQL_TEST_* environment variables
+func openTestDB(t testing.TB) *sql.DB {
+	t.Helper()
 	env := func(key, defaultValue string) string {
 		if value := os.Getenv(key); value != "" {
 			return value
@@ -41,11 +536,7 @@ func TestCron_SQLStore(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	store, err := NewSQLStore(db) // This will check and create necessary table if not exists
-	if err != nil {
-		t.Fatalf("Failed to initialize MysqlPersister: %v", err)
-	}
-	storeTest(t, store)
+	return db
 }
 
 func storeTest(t *testing.T, store Store) {
@@ -54,6 +545,9 @@ func storeTest(t *testing.T, store Store) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := store.Health(ctx); err != nil {
+		t.Fatal(err)
+	}
 
 	err = store.Lock(ctx)
 	if err != nil {
@@ -87,19 +581,19 @@ func storeTest(t *testing.T, store Store) {
 		Entry: entry,
 		Time:  now,
 	}
-	err = store.AddEvent(ctx, ev)
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	ev2 := Event{
 		Entry: entry,
 		Time:  now.Add(time.Minute),
 	}
+	// the events are added out of order, GetEvents returns them ordered by time
 	err = store.AddEvent(ctx, ev2)
 	if err != nil {
 		t.Fatal(err)
 	}
+	err = store.AddEvent(ctx, ev)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	events, err := store.GetEvents(ctx, ev.Time, ev.Time.Add(time.Minute))
 	if err != nil {
@@ -112,16 +606,174 @@ func storeTest(t *testing.T, store Store) {
 	if got, want := events[0], ev; !reflect.DeepEqual(got, want) {
 		t.Fatalf("got events %+v want %+v", got, want)
 	}
+	if g, ok := store.(EntryEventsGetter); ok {
+		for _, tt := range []struct {
+			names []string
+			want  int
+		}{
+			{names: []string{"ENTRY_1"}, want: 1},
+			{names: []string{"ENTRY_0", "ENTRY_1", "ENTRY_2"}, want: 1},
+			{names: []string{"ENTRY_2"}, want: 0},
+		} {
+			events, err := g.GetEventsForEntries(ctx, tt.names, ev.Time, ev.Time.Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(events); got != tt.want {
+				t.Fatalf("got events %d of %v want %d", got, tt.names, tt.want)
+			}
+		}
+	}
+
+	for _, w := range []struct {
+		from, to time.Time
+		want     int64
+	}{
+		{from: ev.Time, to: ev.Time.Add(time.Minute), want: 1},
+		{from: ev.Time, to: ev2.Time.Add(time.Minute), want: 2},
+		{from: ev.Time.Add(-time.Minute), to: ev.Time, want: 0},
+	} {
+		count, err := store.CountEvents(ctx, w.from, w.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := store.GetEvents(ctx, w.from, w.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != w.want || count != int64(len(events)) {
+			t.Fatalf("got count %d on [%s, %s) want %d and %d events", count, w.from, w.to, w.want, len(events))
+		}
+		for i := 1; i < len(events); i++ {
+			if events[i].Time.Before(events[i-1].Time) {
+				t.Fatalf("got events out of order %v", events)
+			}
+		}
+	}
+
+	last, ok, err := store.LastEvent(ctx, entry.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || !reflect.DeepEqual(last, ev2) {
+		t.Fatalf("got last event (%+v, %t) want %+v", last, ok, ev2)
+	}
+	if _, ok, err := store.LastEvent(ctx, "ENTRY_2"); err != nil || ok {
+		t.Fatalf("got last event of an entry that never ran ok %t error %v", ok, err)
+	}
 
 	entry2, err := Parse("* * * * *", time.UTC, "ENTRY_2")
 	if err != nil {
 		t.Fatal(err)
 	}
+	entry2.DependsOn = []string{"ENTRY_1", "ENTRY,3"}
 	err = store.AddEntry(ctx, entry2)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	// paused entry is only returned with IncludeInactive
+	err = store.SetEntryActive(ctx, entry2.Name, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = store.GetEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 1; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	entries, err = store.GetEntries(ctx, IncludeInactive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	for _, e := range entries {
+		if got, want := e.Paused, e.Name == entry2.Name; got != want {
+			t.Fatalf("got paused %t want %t for entry %q", got, want, e.Name)
+		}
+	}
+
+	// adding again the paused entry keeps it paused
+	if err := store.AddEntry(ctx, entry2); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = store.GetEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 1; got != want {
+		t.Fatalf("got entries %d after adding again the paused entry want %d", got, want)
+	}
+
+	// a single entry is read by name, paused or not
+	got, err := store.GetEntry(ctx, entry2.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := entry2; got.expression != want.expression || !reflect.DeepEqual(got.DependsOn, want.DependsOn) || !got.Paused {
+		t.Fatalf("got entry %+v want paused %+v", got, want)
+	}
+	if _, err := store.GetEntry(ctx, "UNKNOWN"); err != ErrEntryNotFound {
+		t.Fatalf("got error %v want %v", err, ErrEntryNotFound)
+	}
+	hourly, err := Parse("0 * * * *", time.UTC, entry.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+	var ambiguous AmbiguousEntryError
+	if _, err := store.GetEntry(ctx, entry.Name); !errors.As(err, &ambiguous) || len(ambiguous.Entries) != 2 {
+		t.Fatalf("got error %v want AmbiguousEntryError of 2 entries", err)
+	}
+	if err := store.DeleteEntry(ctx, hourly); err != nil {
+		t.Fatal(err)
+	}
+	err = store.SetEntryActive(ctx, entry2.Name, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := store.SetEntryActive(ctx, "UNKNOWN", false), ErrEntryNotFound; got != want {
+		t.Fatalf("got error %v want %v", got, want)
+	}
+
+	// update the schedule, name and the flags of an entry then update it back
+	updated, err := Parse("*/5 * * * *", time.UTC, "ENTRY_2_RENAMED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated.Paused = true
+	updated.Meta = "META"
+	if err := store.UpdateEntry(ctx, entry2.Name, updated); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = store.GetEntries(ctx, IncludeInactive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+	for _, e := range entries {
+		if e.Name == entry.Name {
+			continue
+		}
+		if got, want := e, updated; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got updated entry %+v want %+v", got, want)
+		}
+	}
+	if err := store.UpdateEntry(ctx, updated.Name, entry2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := store.UpdateEntry(ctx, updated.Name, entry2), ErrEntryNotFound; got != want {
+		t.Fatalf("got error %v want %v", got, want)
+	}
+
 	err = store.DeleteEntry(ctx, entry)
 	if err != nil {
 		t.Fatal(err)
@@ -180,8 +832,496 @@ func storeTest(t *testing.T, store Store) {
 		t.Fatalf("got events %d want %d", got, want)
 	}
 
+	// events of two entries are read by name, added out of order
+	for _, e := range []Event{
+		{Entry: entry, Time: now.Add(time.Minute)},
+		{Entry: entry2, Time: now},
+		{Entry: entry, Time: now},
+	} {
+		if err := store.AddEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		name string
+		want []time.Time
+	}{
+		{name: entry.Name, want: []time.Time{now, now.Add(time.Minute)}},
+		{name: entry2.Name, want: []time.Time{now}},
+		{name: "UNKNOWN", want: []time.Time{}},
+	} {
+		events, err := store.GetEventsByName(ctx, tt.name, now, now.Add(2*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if events == nil {
+			t.Fatalf("got nil events of %s want an empty slice", tt.name)
+		}
+		got := make([]time.Time, 0, len(events))
+		for _, e := range events {
+			if e.Entry.Name != tt.name {
+				t.Fatalf("got event of %s want %s", e.Entry.Name, tt.name)
+			}
+			got = append(got, e.Time.UTC())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("got event times %v of %s want %v", got, tt.name, tt.want)
+		}
+	}
+	if err := store.DeleteEvents(ctx, now.Add(3*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	entriesQueryTest(t, store)
+	eventsQueryTest(t, store)
+
 	err = store.Unlock(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// the status is recorded and read without locking the store
+	if s, ok := store.(EventStatusStore); ok {
+		if err := store.Lock(ctx); err != nil {
+			t.Fatal(err)
+		}
+		manual := Event{Entry: entry2, Time: now.Add(30 * time.Second), Manual: true}
+		for _, e := range []Event{ev, manual} {
+			if err := store.AddEvent(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.Unlock(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.SetEventStatus(ctx, ev, EventStatusSucceeded); err != nil {
+			t.Fatal(err)
+		}
+		statuses, err := s.GetEventStatuses(ctx, []string{"ENTRY_1", "ENTRY_2"}, now, now.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := statuses, map[string]string{"ENTRY_1": EventStatusSucceeded}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got statuses %v want %v", got, want)
+		}
+
+		if err := store.Lock(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if c, ok := store.(ClaimStore); ok {
+			claimed := Event{Entry: entry2, Time: now, Status: EventStatusClaimed, FiredBy: "i1"}
+			if err := store.AddEvent(ctx, claimed); err != nil {
+				t.Fatal(err)
+			}
+			stale, err := c.StaleClaims(ctx, now.Add(time.Second))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stale) != 1 || !reflect.DeepEqual(stale[0], claimed) {
+				t.Fatalf("got stale claims %+v want %+v", stale, claimed)
+			}
+			claimed.FiredBy = "i2"
+			for i, want := range []bool{true, false} {
+				ok, err := c.ReclaimEvent(ctx, claimed, now.Add(time.Second), now.Add(time.Minute))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok != want {
+					t.Fatalf("[%d] got reclaimed %t want %t", i, ok, want)
+				}
+			}
+			if stale, err := c.StaleClaims(ctx, now.Add(time.Second)); err != nil || len(stale) != 0 {
+				t.Fatalf("got stale claims %+v error %v after reclaim", stale, err)
+			}
+		}
+		if err := store.DeleteEvents(ctx, now.Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Unlock(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// entriesQueryTest checks the options of GetEntries on an empty store, it must be called while the store is locked
+func entriesQueryTest(t *testing.T, store Store) {
+	ctx := context.Background()
+	var entries []Entry
+	for _, v := range []struct {
+		name, expression string
+		paused           bool
+	}{
+		{name: "JOB_B", expression: "0 * * * *"},
+		{name: "JOB_A", expression: "* * * * *", paused: true},
+		{name: "JOBXC", expression: "* * * * *"},
+		{name: "JOB_B", expression: "* * * * *"},
+		{name: "OTHER", expression: "* * * * *"},
+	} {
+		e, err := Parse(v.expression, time.UTC, v.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Paused = v.paused
+		if err := store.AddEntry(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	// JOBXC is not ordered with the JOB_ names since the order of '_' and the letters depends on the collation
+	tests := []struct {
+		name string
+		opts []EntriesOption
+		want []string // name and expression, nil to only check the count
+		n    int
+	}{
+		{name: "no options", n: 4},
+		{name: "include inactive", opts: []EntriesOption{IncludeInactive()}, n: 5},
+		{name: "prefix escapes wildcard", opts: []EntriesOption{NamePrefix("JOB_")}, n: 2},
+		{
+			name: "ordered", opts: []EntriesOption{NamePrefix("JOB_"), IncludeInactive(), OrderByName()},
+			want: []string{"JOB_A * * * * *", "JOB_B * * * * *", "JOB_B 0 * * * *"},
+		},
+		{
+			name: "first page", opts: []EntriesOption{NamePrefix("JOB_"), Page(2, 0)},
+			want: []string{"JOB_B * * * * *", "JOB_B 0 * * * *"},
+		},
+		{
+			name: "last page", opts: []EntriesOption{NamePrefix("JOB_"), IncludeInactive(), Page(2, 2)},
+			want: []string{"JOB_B 0 * * * *"},
+		},
+		{
+			name: "offset only", opts: []EntriesOption{NamePrefix("JOB_"), IncludeInactive(), Page(0, 1)},
+			want: []string{"JOB_B * * * * *", "JOB_B 0 * * * *"},
+		},
+		{name: "past the end", opts: []EntriesOption{IncludeInactive(), Page(2, 5)}, want: []string{}},
+	}
+	for _, tt := range tests {
+		got, err := store.GetEntries(ctx, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == nil {
+			if len(got) != tt.n {
+				t.Errorf("%s: got %d entries want %d", tt.name, len(got), tt.n)
+			}
+			continue
+		}
+		names := make([]string, 0, len(got))
+		for _, e := range got {
+			names = append(names, e.Name+" "+e.expression)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%s: got entries %q want %q", tt.name, names, tt.want)
+		}
+	}
+
+	for _, e := range entries {
+		if err := store.DeleteEntry(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// eventsQueryTest checks the order and the options of QueryEvents on a store without events, it must be called while
+// the store is locked
+func eventsQueryTest(t *testing.T, store Store) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	a, err := Parse("* * * * *", time.UTC, "ENTRY_A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("* * * * *", time.UTC, "ENTRY_B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the events of the entries are interleaved and added out of order
+	for _, e := range []Event{
+		{Entry: b, Time: now.Add(2 * time.Minute), Status: EventStatusFailed},
+		{Entry: b, Time: now, Status: EventStatusSucceeded},
+		{Entry: a, Time: now.Add(time.Minute), Status: EventStatusSucceeded},
+		{Entry: a, Time: now, Status: EventStatusSucceeded},
+		{Entry: b, Time: now.Add(time.Minute)},
+	} {
+		if err := store.AddEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts []EventsOption
+		want []string // name and minute of the events
+	}{
+		{name: "no options", want: []string{"ENTRY_A 0", "ENTRY_B 0", "ENTRY_A 1", "ENTRY_B 1", "ENTRY_B 2"}},
+		{name: "entry", opts: []EventsOption{ForEntries("ENTRY_B")}, want: []string{"ENTRY_B 0", "ENTRY_B 1", "ENTRY_B 2"}},
+		{name: "unknown entry", opts: []EventsOption{ForEntries("UNKNOWN")}, want: []string{}},
+		{
+			name: "page of entries", opts: []EventsOption{ForEntries("ENTRY_A", "ENTRY_B"), EventsPage(2, 1)},
+			want: []string{"ENTRY_B 0", "ENTRY_A 1"},
+		},
+		{name: "last page", opts: []EventsOption{EventsPage(2, 4)}, want: []string{"ENTRY_B 2"}},
+		{name: "offset only", opts: []EventsOption{EventsPage(0, 3)}, want: []string{"ENTRY_B 1", "ENTRY_B 2"}},
+		{name: "past the end", opts: []EventsOption{EventsPage(2, 5)}, want: []string{}},
+		{
+			name: "status", opts: []EventsOption{EventsWithStatus(EventStatusSucceeded)},
+			want: []string{"ENTRY_A 0", "ENTRY_B 0", "ENTRY_A 1"},
+		},
+	}
+	for _, tt := range tests {
+		events, err := store.QueryEvents(ctx, now, now.Add(3*time.Minute), tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0, len(events))
+		for _, e := range events {
+			got = append(got, fmt.Sprintf("%s %d", e.Entry.Name, int(e.Time.Sub(now)/time.Minute)))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got events %q want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// the last event regardless of the status or with the status
+	for _, tt := range []struct {
+		name   string
+		opts   []EventsOption
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "ENTRY_B", want: now.Add(2 * time.Minute), wantOK: true},
+		{name: "ENTRY_B", opts: []EventsOption{EventsWithStatus(EventStatusSucceeded)}, want: now, wantOK: true},
+		{name: "ENTRY_A", opts: []EventsOption{EventsWithStatus(EventStatusFailed)}},
+		{name: "UNKNOWN"},
+	} {
+		last, ok, err := store.LastEvent(ctx, tt.name, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.wantOK || ok && !last.Time.Equal(tt.want) {
+			t.Errorf("got last event of %s (%s, %t) want (%s, %t)", tt.name, last.Time, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// GetEvents has the same order
+	events, err := store.GetEvents(ctx, now, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Entry.Name != "ENTRY_A" || events[1].Entry.Name != "ENTRY_B" {
+		t.Errorf("got events %+v want ENTRY_A then ENTRY_B", events)
+	}
+
+	if err := store.DeleteEvents(ctx, now.Add(3*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemStore_canceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	store := &MemStore{}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	_, errGetEntries := store.GetEntries(ctx)
+	_, errGetEntry := store.GetEntry(ctx, entry.Name)
+	_, errGetEvents := store.GetEvents(ctx, now, now.Add(time.Minute))
+	_, errGetEventsByName := store.GetEventsByName(ctx, entry.Name, now, now.Add(time.Minute))
+	errs := map[string]error{
+		"Initialize":      store.Initialize(ctx),
+		"Health":          store.Health(ctx),
+		"Lock":            store.Lock(ctx),
+		"GetEntries":      errGetEntries,
+		"GetEntry":        errGetEntry,
+		"AddEntry":        store.AddEntry(ctx, entry),
+		"DeleteEntry":     store.DeleteEntry(ctx, entry),
+		"UpdateEntry":     store.UpdateEntry(ctx, entry.Name, entry),
+		"SetEntryActive":  store.SetEntryActive(ctx, entry.Name, false),
+		"AddEvent":        store.AddEvent(ctx, Event{Entry: entry, Time: now}),
+		"GetEvents":       errGetEvents,
+		"GetEventsByName": errGetEventsByName,
+		"DeleteEvents":    store.DeleteEvents(ctx, now),
+	}
+	for method, err := range errs {
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("%s: got error %v want %v", method, got, want)
+		}
+	}
+	if got, want := len(store.entries), 0; got != want {
+		t.Errorf("got %d entries want %d", got, want)
+	}
+
+	// the lock is not taken
+	if !store.Mutex.TryLock() {
+		t.Errorf("store is locked with canceled context")
+	}
+}
+
+func TestMemStore_LastEvent(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	// the events are not added in time order
+	for _, at := range []time.Time{now.Add(time.Minute), now.Add(2 * time.Minute), now} {
+		store.AddEvent(ctx, Event{Entry: entry, Time: at})
+	}
+
+	last, ok, err := store.LastEvent(ctx, entry.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(2 * time.Minute); !ok || !last.Time.Equal(want) {
+		t.Errorf("got last event (%s, %t) want %s", last.Time, ok, want)
+	}
+}
+
+func TestMemStore_GetEntries(t *testing.T) {
+	ctx := context.Background()
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, entry)
+
+	// the caller can change the returned entries without changing the store
+	entries, err := store.GetEntries(ctx, IncludeInactive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries[0].Meta = "META"
+	if got := store.entries[0].Meta; got != "" {
+		t.Errorf("got meta %q of the stored entry want empty", got)
+	}
+}
+
+func TestMemStore_GetEntry(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	var entries []Entry
+	for _, name := range []string{"ENTRY_1", "ENTRY_2", "ENTRY_3"} {
+		entry, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.AddEntry(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	// the index by name follows the entries that move after a delete and an update
+	if err := store.DeleteEntry(ctx, entries[0]); err != nil {
+		t.Fatal(err)
+	}
+	updated := entries[1]
+	updated.Meta = "META"
+	if err := store.UpdateEntry(ctx, updated.Name, updated); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetEntryActive(ctx, "ENTRY_3", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetEntry(ctx, "ENTRY_1"); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("got error %v want %v", err, ErrEntryNotFound)
+	}
+	got, err := store.GetEntry(ctx, "ENTRY_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Meta != "META" {
+		t.Errorf("got meta %q want %q", got.Meta, "META")
+	}
+	if got, err := store.GetEntry(ctx, "ENTRY_3"); err != nil || !got.Paused {
+		t.Errorf("got entry %s paused %t error %v want paused", got.Name, got.Paused, err)
+	}
+}
+
+func TestMemStore_DeleteEntry(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	jkt, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Parse("* * * * *", jkt, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []Entry{entry, other} {
+		if err := store.AddEntry(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the entry of another location is kept
+	if err := store.DeleteEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetEntry(ctx, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := got.Location.String(), jkt.String(); got != want {
+		t.Errorf("got location %q want %q", got, want)
+	}
+}
+
+func TestMemStore_AddEntry(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noName := entry
+	noName.Name = ""
+	if got, want := fmt.Sprint(store.AddEntry(ctx, noName)), "got empty name"; got != want {
+		t.Errorf("got error %q want %q", got, want)
+	}
+	if got, want := fmt.Sprint(store.AddEntry(ctx, Entry{Name: "ENTRY_1"})), "got empty expression"; got != want {
+		t.Errorf("got error %q want %q", got, want)
+	}
+
+	// exact duplicate replaces the existing entry
+	if err := store.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = "META"
+	if err := store.AddEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(store.entries), 1; got != want {
+		t.Fatalf("got %d entries want %d", got, want)
+	}
+	if got, want := store.entries[0].Meta, "META"; got != want {
+		t.Errorf("got meta %q want %q", got, want)
+	}
+
+	// same name with other expression is another entry
+	other, err := Parse("0 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddEntry(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(store.entries), 2; got != want {
+		t.Errorf("got %d entries want %d", got, want)
+	}
 }
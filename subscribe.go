@@ -0,0 +1,45 @@
+package cron
+
+import "sync"
+
+// SubscribeBuffer is the number of events buffered for each subscriber, see Scheduler.Subscribe
+const SubscribeBuffer = 64
+
+// Subscribe returns a channel that receives a copy of every triggered event after its handler returned, with or
+// without error. The Status of the event is EventStatusSucceeded or EventStatusFailed and Duration is the run time of
+// the handler. Every subscriber has its own buffer of SubscribeBuffer events. When the buffer is full the event is
+// dropped for that subscriber (counted in Stats.DroppedEvents) so that a slow subscriber does not block the handlers.
+// unsubscribe closes the channel, it can be called more than once and while events are delivered.
+func (s *Scheduler) Subscribe() (_ <-chan Event, unsubscribe func()) {
+	ch := make(chan Event, SubscribeBuffer)
+	s.subMu.Lock()
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan Event]struct{})
+	}
+	s.subscribers[ch] = struct{}{}
+	s.subMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.subMu.Lock()
+			defer s.subMu.Unlock()
+			delete(s.subscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// publish delivers the event to the subscribers without blocking
+func (s *Scheduler) publish(ev Event) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			s.incStats(&s.stats.DroppedEvents)
+		}
+	}
+}
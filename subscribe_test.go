@@ -0,0 +1,121 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Subscribe(t *testing.T) {
+	ctx := context.Background()
+	on := time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)
+	store := &MemStore{}
+	for _, name := range []string{"ENTRY_1", "ENTRY_2"} {
+		entry, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.AddEntry(ctx, entry)
+	}
+
+	var (
+		mu       sync.Mutex
+		returned = make(map[string]bool)
+	)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		returned[e.Name] = true
+		if e.Name == "ENTRY_2" {
+			return errors.New("failed")
+		}
+		return nil
+	}, store)
+	events1, unsubscribe1 := s.Subscribe()
+	defer unsubscribe1()
+	events2, unsubscribe2 := s.Subscribe()
+	defer unsubscribe2()
+
+	if err := s.check(ctx, on); err != nil {
+		t.Fatal(err)
+	}
+	for _, events := range []<-chan Event{events1, events2} {
+		got := make(map[string]bool)
+		for i := 0; i < 2; i++ {
+			select {
+			case ev := <-events:
+				mu.Lock()
+				if !returned[ev.Entry.Name] {
+					t.Errorf("got event of %q before its handler returned", ev.Entry.Name)
+				}
+				mu.Unlock()
+				if !ev.Time.Equal(on) {
+					t.Errorf("got event time %s want %s", ev.Time, on)
+				}
+				want := EventStatusSucceeded
+				if ev.Entry.Name == "ENTRY_2" {
+					want = EventStatusFailed
+				}
+				if ev.Status != want {
+					t.Errorf("got status %q of %q want %q", ev.Status, ev.Entry.Name, want)
+				}
+				if ev.Duration <= 0 {
+					t.Errorf("got duration %s of %q want positive", ev.Duration, ev.Entry.Name)
+				}
+				got[ev.Entry.Name] = true
+			case <-time.After(time.Second):
+				t.Fatal("event is not delivered")
+			}
+		}
+		if !got["ENTRY_1"] || !got["ENTRY_2"] {
+			t.Errorf("got events of %v want ENTRY_1 and ENTRY_2", got)
+		}
+	}
+}
+
+func TestScheduler_SubscribeSlow(t *testing.T) {
+	s := NewScheduler(nil, &MemStore{})
+	_, unsubscribeSlow := s.Subscribe() // never reads
+	defer unsubscribeSlow()
+	events, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	const extra = 5
+	for i := 0; i < SubscribeBuffer+extra; i++ {
+		s.publish(Event{})
+		<-events
+	}
+	if got, want := s.Stats().DroppedEvents, uint64(extra); got != want {
+		t.Errorf("got dropped %d want %d", got, want)
+	}
+}
+
+func TestScheduler_SubscribeUnsubscribe(t *testing.T) {
+	s := NewScheduler(nil, &MemStore{})
+	events, unsubscribe := s.Subscribe()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			s.publish(Event{})
+		}
+	}()
+
+	// unsubscribe while the events are delivered, the channel is closed
+	<-events
+	unsubscribe()
+	unsubscribe()
+	for range events {
+	}
+	wg.Wait()
+
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	if got, want := len(s.subscribers), 0; got != want {
+		t.Errorf("got %d subscribers want %d", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrHandlerTimeout is returned (wrapped) as the handler error when the handler does not return within the timeout
+var ErrHandlerTimeout = errors.New("handler timed out")
+
+// WithDefaultTimeout sets the timeout of handlers of entries without Entry.Timeout. Zero (default) means no timeout.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.defaultTimeout = d
+	}
+}
+
+// timeoutFor returns the handler timeout of the entry
+func (s *Scheduler) timeoutFor(e Entry) time.Duration {
+	if e.Timeout > 0 {
+		return e.Timeout
+	}
+	return s.defaultTimeout
+}
+
+// callWithTimeout calls fn with a deadline on ctx. A go routine can not be killed, so when fn does not return on
+// time it keeps running in the background and it is counted in Stats.RunningAfterTimeout until it returns.
+func (s *Scheduler) callWithTimeout(ctx context.Context, fn HandlerFunc, ev Event, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fn(ctx, ev.Entry)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx, ev.Entry)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// canceled by the parent (ex: RemoveEntry), it is not a timeout
+		return <-done
+	}
+
+	// the handler may still return right at the deadline
+	select {
+	case err := <-done:
+		return err
+	default:
+	}
+
+	s.addRunningAfterTimeout(1)
+	s.logger.Error("handler still running after timeout", s.entryKV(ev.Entry, "at", ev.Time, "timeout", timeout)...)
+	go func() {
+		<-done
+		s.addRunningAfterTimeout(-1)
+		s.logger.Info("handler returned after timeout", s.entryKV(ev.Entry, "at", ev.Time)...)
+	}()
+
+	return fmt.Errorf("%w after %s", ErrHandlerTimeout, timeout)
+}
+
+func (s *Scheduler) addRunningAfterTimeout(delta int) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.stats.RunningAfterTimeout += delta
+}
@@ -0,0 +1,74 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler_timeout(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	for _, name := range []string{"HONOR", "IGNORE"} {
+		e, err := Parse("* * * * *", time.UTC, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "IGNORE" {
+			e.Timeout = 20 * time.Millisecond
+		}
+		store.AddEntry(ctx, e)
+	}
+
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	failed := make(chan error, 2)
+	s := NewScheduler(func(ctx context.Context, e Entry) error {
+		if e.Name == "HONOR" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		<-release
+		close(returned)
+		return nil
+	}, store, WithDefaultTimeout(10*time.Millisecond), WithHooks(Hooks{
+		OnError: func(ev Event, d time.Duration, err error) { failed <- err },
+	}))
+
+	if err := s.check(ctx, time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-failed:
+			if !errors.Is(err, ErrHandlerTimeout) && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got error %v want timeout", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("handler is not timed out")
+		}
+	}
+
+	// the handler honoring the timeout may return just after the deadline, it is only counted until it returns
+	deadline := time.Now().Add(time.Second)
+	for s.Stats().RunningAfterTimeout != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d running after timeout want 1", s.Stats().RunningAfterTimeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := s.Stats().Errored, uint64(2); got != want {
+		t.Errorf("got errored %d want %d", got, want)
+	}
+
+	close(release)
+	<-returned
+	deadline = time.Now().Add(time.Second)
+	for s.Stats().RunningAfterTimeout != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("handler returned after timeout is still counted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
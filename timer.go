@@ -0,0 +1,37 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Start calls fn on every run of the entry until ctx is done, ex: to run one job without a Store and Scheduler. fn
+// gets the time of the run (see Next) and is called on the goroutine of Start, the runs that pass while fn is running
+// are skipped. It returns the error of ctx, or an error if the entry has no next run (ex: @reboot).
+func (e Entry) Start(ctx context.Context, fn func(time.Time)) error {
+	return e.start(ctx, fn, time.Now, time.After)
+}
+
+// start is Start with the clock, replaced in tests
+func (e Entry) start(ctx context.Context, fn func(time.Time), now func() time.Time, after func(d time.Duration) <-chan time.Time) error {
+	var last time.Time
+	for {
+		// a timer that fires early must not run the same minute again
+		from := now()
+		if from.Before(last) {
+			from = last
+		}
+		next := e.Next(from)
+		if next.IsZero() {
+			return fmt.Errorf("entry %q has no next run after %s", e.Name, from)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-after(next.Sub(from)):
+			last = next
+			fn(next)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEntry_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entry, err := Parse("*/15 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeNow{t: time.Date(2018, 12, 15, 0, 5, 30, 0, time.UTC)}
+
+	// the timer fires when the test sends the time of the clock
+	waits := make(chan time.Duration, 1)
+	ticks := make(chan time.Time)
+	after := func(d time.Duration) <-chan time.Time {
+		waits <- d
+		return ticks
+	}
+	runs := make(chan time.Time, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- entry.start(ctx, func(t time.Time) { runs <- t }, clock.Now, after)
+	}()
+
+	minute := time.Date(2018, 12, 15, 0, 15, 0, 0, time.UTC)
+	tests := []struct {
+		fireAt   time.Time
+		wantWait time.Duration
+		want     time.Time
+	}{
+		{fireAt: minute, wantWait: 9*time.Minute + 30*time.Second, want: minute},
+		{fireAt: minute.Add(15*time.Minute + 2*time.Second), wantWait: 15 * time.Minute, want: minute.Add(15 * time.Minute)},
+		// fires early, it runs on the expected minute and the next wait is from that minute
+		{fireAt: minute.Add(30*time.Minute - time.Millisecond), wantWait: 15*time.Minute - 2*time.Second, want: minute.Add(30 * time.Minute)},
+		{fireAt: minute.Add(45 * time.Minute), wantWait: 15 * time.Minute, want: minute.Add(45 * time.Minute)},
+	}
+	for _, tt := range tests {
+		if got := <-waits; got != tt.wantWait {
+			t.Errorf("got wait %s want %s", got, tt.wantWait)
+		}
+		clock.Set(tt.fireAt)
+		ticks <- tt.fireAt
+		if got := <-runs; !got.Equal(tt.want) {
+			t.Errorf("got run on %s want %s", got, tt.want)
+		}
+	}
+
+	<-waits
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v want %v", err, context.Canceled)
+	}
+
+	reboot, err := Parse("@reboot", time.UTC, "ENTRY_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reboot.Start(context.Background(), func(time.Time) {}); err == nil {
+		t.Error("expected error for entry without next run")
+	}
+}
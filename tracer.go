@@ -0,0 +1,24 @@
+package cron
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer traces check and handler execution. It is an adapter so that a tracing library (ex: OpenTelemetry) does not
+// become a dependency of this package.
+type Tracer interface {
+	// StartCheck is called on every check tick. The returned context is passed down to the handlers and end is called
+	// when the check is finished.
+	StartCheck(ctx context.Context, on time.Time) (_ context.Context, end func(err error))
+	// StartHandler is called right before the handler is called with the returned context. end is called with the
+	// error returned by the handler.
+	StartHandler(ctx context.Context, ev Event) (_ context.Context, end func(err error))
+}
+
+// WithTracer traces the scheduler with t
+func WithTracer(t Tracer) Option {
+	return func(s *Scheduler) {
+		s.tracer = t
+	}
+}
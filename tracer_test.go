@@ -0,0 +1,75 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ctxKey string
+
+// fakeTracer puts the span names on the context
+type fakeTracer struct {
+	mu    sync.Mutex
+	ended map[string]error
+}
+
+func (f *fakeTracer) start(ctx context.Context, name string) (context.Context, func(err error)) {
+	return context.WithValue(ctx, ctxKey(name), true), func(err error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.ended[name] = err
+	}
+}
+
+func (f *fakeTracer) StartCheck(ctx context.Context, on time.Time) (context.Context, func(err error)) {
+	return f.start(ctx, "check")
+}
+
+func (f *fakeTracer) StartHandler(ctx context.Context, ev Event) (context.Context, func(err error)) {
+	return f.start(ctx, "handler "+ev.Entry.Name)
+}
+
+func TestScheduler_tracer(t *testing.T) {
+	ctx := context.Background()
+	store := &MemStore{}
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.AddEntry(ctx, entry)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var handlerCtx context.Context
+	handler := func(ctx context.Context, e Entry) error {
+		handlerCtx = ctx
+		return errors.New("failed")
+	}
+	tracer := &fakeTracer{ended: make(map[string]error)}
+	s := NewScheduler(handler, store, WithTracer(tracer), WithHooks(Hooks{
+		OnError: func(ev Event, d time.Duration, err error) { wg.Done() },
+	}))
+
+	if err := s.check(ctx, time.Date(2018, 12, 15, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	for _, key := range []ctxKey{"check", "handler ENTRY_1"} {
+		if handlerCtx.Value(key) == nil {
+			t.Errorf("handler context does not carry %q", key)
+		}
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if err, ok := tracer.ended["check"]; !ok || err != nil {
+		t.Errorf("got check ended %t with error %v want ended without error", ok, err)
+	}
+	if err, ok := tracer.ended["handler ENTRY_1"]; !ok || err == nil || err.Error() != "failed" {
+		t.Errorf("got handler ended %t with error %v want ended with error", ok, err)
+	}
+}
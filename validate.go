@@ -0,0 +1,94 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons of EntryWarning
+const (
+	// WarningNeverFires is an entry that never matches, ex: a day of month that does not exist in the months
+	WarningNeverFires = "never_fires"
+	// WarningFiresTooOften is an entry that fires more often than the threshold, see WithFrequencyWarning
+	WarningFiresTooOften = "fires_too_often"
+	// WarningUnknownLocation is an entry whose location is not in the tz database
+	WarningUnknownLocation = "unknown_location"
+)
+
+// EntryWarning is a problem of an entry that is likely a misconfiguration, the entry is still loaded
+type EntryWarning struct {
+	Name   string // name of the entry
+	Reason string // see the Warning constants
+	Detail string
+}
+
+func (w EntryWarning) Error() string {
+	return fmt.Sprintf("entry %q %s: %s", w.Name, w.Reason, w.Detail)
+}
+
+// Validate returns the warnings of an entry that never fires or whose location can not be loaded from the tz
+// database. See Scheduler.Entries for the warnings of the entries in the store.
+func (e Entry) Validate() []EntryWarning {
+	var warnings []EntryWarning
+	minute, hour, dom, month, dow := e.Fields()
+	if e.IsReboot() {
+		// it only fires when the scheduler starts
+	} else if len(minute) == 0 || len(hour) == 0 || len(dom) == 0 || len(month) == 0 || len(dow) == 0 || !e.IsSatisfiable() {
+		warnings = append(warnings, EntryWarning{
+			Name: e.Name, Reason: WarningNeverFires, Detail: fmt.Sprintf("expression %q never matches", e.expression),
+		})
+	}
+	if e.Location == nil {
+		warnings = append(warnings, EntryWarning{Name: e.Name, Reason: WarningUnknownLocation, Detail: "empty location"})
+	} else if _, err := time.LoadLocation(e.Location.String()); err != nil {
+		warnings = append(warnings, EntryWarning{Name: e.Name, Reason: WarningUnknownLocation, Detail: err.Error()})
+	}
+
+	return warnings
+}
+
+// WithFrequencyWarning warns about the entries that fire more than maxPerHour times in an hour, ex: 59 warns about an
+// entry that fires every minute like `* 0 * * *` when it is meant to fire hourly. It is disabled by default since
+// entries that fire every minute are common.
+func WithFrequencyWarning(maxPerHour int) Option {
+	return func(s *Scheduler) {
+		s.maxPerHour = maxPerHour
+	}
+}
+
+// entryWarnings returns the warnings of Entry.Validate and the frequency warning
+func (s *Scheduler) entryWarnings(e Entry) []EntryWarning {
+	warnings := e.Validate()
+	if minute, _, _, _, _ := e.Fields(); s.maxPerHour > 0 && len(minute) > s.maxPerHour {
+		warnings = append(warnings, EntryWarning{
+			Name: e.Name, Reason: WarningFiresTooOften,
+			Detail: fmt.Sprintf("fires %d times an hour, more than %d", len(minute), s.maxPerHour),
+		})
+	}
+
+	return warnings
+}
+
+// validatedKey identifies a validated entry
+type validatedKey struct {
+	name, expression, location string
+}
+
+// reportWarnings logs and sends the warnings of the loaded entries to ErrCh. An entry is only validated the first
+// time it is loaded since loading the location reads the tz database.
+func (s *Scheduler) reportWarnings(entries []Entry) {
+	for _, e := range entries {
+		key := validatedKey{name: e.Name, expression: e.expression, location: e.Location.String()}
+		s.statusMu.Lock()
+		validated := s.validated[key]
+		s.validated[key] = true
+		s.statusMu.Unlock()
+		if validated {
+			continue
+		}
+		for _, w := range s.entryWarnings(e) {
+			s.logger.Error("entry warning", s.entryKV(e, "reason", w.Reason, "detail", w.Detail)...)
+			s.log(w)
+		}
+	}
+}
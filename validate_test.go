@@ -0,0 +1,93 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEntry_Validate(t *testing.T) {
+	parse := func(expression string, loc *time.Location) Entry {
+		e, err := Parse(expression, loc, "ENTRY_1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	// Parse rejects a day of month that does not exist in the months
+	february31 := parse("0 0 31 * *", time.UTC)
+	february31.month = parse("0 0 1 2 *", time.UTC).month
+
+	tests := []struct {
+		name  string
+		entry Entry
+		want  []string
+	}{
+		{name: "valid", entry: parse("*/15 0 1 * 1-5", time.UTC)},
+		{name: "never fires", entry: february31, want: []string{WarningNeverFires}},
+		{name: "zero entry", entry: Entry{Name: "ENTRY_1"}, want: []string{WarningNeverFires, WarningUnknownLocation}},
+		{name: "unknown location", entry: parse("0 0 * * *", time.FixedZone("Mars/Phobos", 3600)), want: []string{WarningUnknownLocation}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, w := range tt.entry.Validate() {
+				if w.Name != "ENTRY_1" {
+					t.Errorf("got name %q", w.Name)
+				}
+				got = append(got, w.Reason)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got reasons %v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduler_entryWarnings(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)
+	everyMinute, err := Parse("* 0 * * *", time.UTC, "EVERY_MINUTE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hourly, err := Parse("0 * * * *", time.UTC, "HOURLY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &MemStore{}
+	store.AddEntry(ctx, everyMinute)
+	store.AddEntry(ctx, hourly)
+
+	s := NewScheduler(func(ctx context.Context, e Entry) error { return nil }, store, WithFrequencyWarning(59))
+	for i := 0; i < 2; i++ {
+		if err := s.check(ctx, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.inFlight.Wait()
+
+	// the warning is reported once
+	var warnings []EntryWarning
+	for len(s.ErrCh()) > 0 {
+		var w EntryWarning
+		if err := <-s.ErrCh(); errors.As(err, &w) {
+			warnings = append(warnings, w)
+		}
+	}
+	if len(warnings) != 1 || warnings[0].Name != "EVERY_MINUTE" || warnings[0].Reason != WarningFiresTooOften {
+		t.Errorf("got warnings %v", warnings)
+	}
+
+	statuses, err := s.Entries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range statuses {
+		if got, want := len(st.Warnings), map[string]int{"EVERY_MINUTE": 1, "HOURLY": 0}[st.Entry.Name]; got != want {
+			t.Errorf("got %d warnings of %s want %d", got, st.Entry.Name, want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// webhookPayload is the JSON body sent by WebhookHandler and NewWebhookHandler
+type webhookPayload struct {
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Time       time.Time `json:"time"`
+	Meta       string    `json:"meta,omitempty"`
+}
+
+// WebhookHandler creates handler that POSTs the triggered entry as JSON to the URL rendered from urlTemplate.
+// The template is executed with the Entry (ex: `https://example.com/jobs/{{.Name}}`). The body contains the name,
+// expression, triggered time and meta of the entry. Non 2xx response is returned as an error.
+// If client is nil, http.DefaultClient is used.
+func WebhookHandler(client *http.Client, urlTemplate string) HandlerFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	tmpl, tmplErr := template.New("url").Parse(urlTemplate)
+
+	return func(ctx context.Context, e Entry) error {
+		if tmplErr != nil {
+			return fmt.Errorf("invalid url template %q: %v", urlTemplate, tmplErr)
+		}
+		var url strings.Builder
+		if err := tmpl.Execute(&url, e); err != nil {
+			return fmt.Errorf("failed rendering url template: %v", err)
+		}
+
+		body, err := webhookBody(ctx, e)
+		if err != nil {
+			return err
+		}
+
+		return postWebhook(ctx, client, url.String(), body, nil)
+	}
+}
+
+// WebhookSignatureHeader is the header of the HMAC-SHA256 signature of the body, see WithWebhookSecret
+const WebhookSignatureHeader = "X-Cron-Signature"
+
+// WebhookOption configures NewWebhookHandler
+type WebhookOption func(c *webhookConfig)
+
+type webhookConfig struct {
+	secret   []byte
+	attempts int
+	backoff  time.Duration
+}
+
+// WithWebhookSecret signs the body with HMAC-SHA256 of secret. The hex encoded signature is sent in the
+// WebhookSignatureHeader header as `sha256=<signature>` so that the receiver can verify the request.
+func WithWebhookSecret(secret []byte) WebhookOption {
+	return func(c *webhookConfig) {
+		c.secret = secret
+	}
+}
+
+// WithWebhookRetry retries a request that failed or got 5xx response until it is sent attempts times, waiting backoff
+// before the second attempt doubled on each attempt (default 1 attempt). The retries stop when ctx is done.
+func WithWebhookRetry(attempts int, backoff time.Duration) WebhookOption {
+	return func(c *webhookConfig) {
+		if attempts < 1 {
+			attempts = 1
+		}
+		c.attempts, c.backoff = attempts, backoff
+	}
+}
+
+// webhookMeta is the Entry.Meta of NewWebhookHandler
+type webhookMeta struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Timeout string            `json:"timeout"`
+}
+
+// NewWebhookHandler creates handler that POSTs the triggered entry as JSON (like WebhookHandler) to the URL in the
+// entry meta. The meta is a JSON object with "url", optional "headers" added to the request and optional "timeout" of
+// each request (ex: `{"url": "https://example.com/jobs", "headers": {"Authorization": "Bearer x"}, "timeout": "10s"}`).
+// Non 2xx response is returned as an error. If client is nil, http.DefaultClient is used.
+func NewWebhookHandler(client *http.Client, opts ...WebhookOption) HandlerFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := webhookConfig{attempts: 1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return func(ctx context.Context, e Entry) error {
+		var meta webhookMeta
+		if err := json.Unmarshal([]byte(e.Meta), &meta); err != nil {
+			return fmt.Errorf("invalid webhook meta of entry %q: %v", e.Name, err)
+		}
+		if meta.URL == "" {
+			return fmt.Errorf("missing url in webhook meta of entry %q", e.Name)
+		}
+		var timeout time.Duration
+		if meta.Timeout != "" {
+			var err error
+			if timeout, err = time.ParseDuration(meta.Timeout); err != nil {
+				return fmt.Errorf("invalid webhook timeout of entry %q: %v", e.Name, err)
+			}
+		}
+
+		body, err := webhookBody(ctx, e)
+		if err != nil {
+			return err
+		}
+		header := make(http.Header)
+		for k, v := range meta.Headers {
+			header.Set(k, v)
+		}
+		if c.secret != nil {
+			mac := hmac.New(sha256.New, c.secret)
+			mac.Write(body)
+			header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+
+		send := func() error {
+			ctx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			return postWebhook(ctx, client, meta.URL, body, header)
+		}
+		wait := c.backoff
+		for attempt := 1; ; attempt++ {
+			err := send()
+			var statusErr *webhookStatusError
+			if err == nil || attempt >= c.attempts || (errors.As(err, &statusErr) && statusErr.status < 500) {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+	}
+}
+
+// webhookBody encodes the payload of the triggered entry, the time is the event time if ctx is the handler context
+func webhookBody(ctx context.Context, e Entry) ([]byte, error) {
+	payload := webhookPayload{
+		Name:       e.Name,
+		Expression: e.expression,
+		Time:       time.Now().Truncate(time.Minute),
+		Meta:       e.Meta,
+	}
+	if ev, ok := EventFromContext(ctx); ok {
+		payload.Time = ev.Time
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding payload: %v", err)
+	}
+
+	return body, nil
+}
+
+// webhookStatusError is returned by postWebhook on non 2xx response
+type webhookStatusError struct {
+	url    string
+	status int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook %s got status %d", e.url, e.status)
+}
+
+// postWebhook POSTs the JSON body to url with the additional header
+func postWebhook(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed creating request: %v", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed sending webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &webhookStatusError{url: url, status: resp.StatusCode}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package cron
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	var gotPath, gotBody, gotContentType string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotPath, gotBody, gotContentType = r.URL.Path, string(b), r.Header.Get("Content-Type")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	entry, err := Parse("*/5 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = "META"
+	ev := Event{Entry: entry, Time: time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)}
+	ctx := context.WithValue(context.Background(), eventKey{}, ev)
+
+	handler := WebhookHandler(server.Client(), server.URL+"/jobs/{{.Name}}")
+	if err := handler(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gotPath, "/jobs/ENTRY_1"; got != want {
+		t.Errorf("got path %q want %q", got, want)
+	}
+	if got, want := gotContentType, "application/json"; got != want {
+		t.Errorf("got content type %q want %q", got, want)
+	}
+	want := `{"name":"ENTRY_1","expression":"*/5 * * * *","time":"2018-12-15T00:05:00Z","meta":"META"}`
+	if got := gotBody; got != want {
+		t.Errorf("got body %s want %s", got, want)
+	}
+
+	status = http.StatusInternalServerError
+	err = handler(ctx, entry)
+	if got, want := err, fmt.Sprintf("webhook %s/jobs/ENTRY_1 got status 500", server.URL); got == nil || got.Error() != want {
+		t.Errorf("got error %v want %q", got, want)
+	}
+}
+
+func TestNewWebhookHandler(t *testing.T) {
+	secret := []byte("secret")
+	var gotBody, gotAuth, gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody, gotAuth, gotSignature = string(b), r.Header.Get("Authorization"), r.Header.Get(WebhookSignatureHeader)
+	}))
+	defer server.Close()
+
+	entry, err := Parse("*/5 * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = fmt.Sprintf(`{"url": %q, "headers": {"Authorization": "Bearer token"}}`, server.URL)
+	ev := Event{Entry: entry, Time: time.Date(2018, 12, 15, 0, 5, 0, 0, time.UTC)}
+	ctx := context.WithValue(context.Background(), eventKey{}, ev)
+
+	handler := NewWebhookHandler(server.Client(), WithWebhookSecret(secret))
+	if err := handler(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gotAuth, "Bearer token"; got != want {
+		t.Errorf("got authorization %q want %q", got, want)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(gotBody))
+	if got, want := gotSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("got signature %q want %q", got, want)
+	}
+	if !strings.Contains(gotBody, `"time":"2018-12-15T00:05:00Z"`) {
+		t.Errorf("got body %s without the event time", gotBody)
+	}
+
+	for _, meta := range []string{"", `{"headers": {}}`, fmt.Sprintf(`{"url": %q, "timeout": "soon"}`, server.URL)} {
+		entry.Meta = meta
+		if err := handler(ctx, entry); err == nil {
+			t.Errorf("expected error on meta %q", meta)
+		}
+	}
+}
+
+func TestNewWebhookHandler_retry(t *testing.T) {
+	var requests, failures int
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failures > 0 {
+			failures--
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = fmt.Sprintf(`{"url": %q}`, server.URL)
+	handler := NewWebhookHandler(server.Client(), WithWebhookRetry(3, time.Millisecond))
+
+	tests := []struct {
+		name         string
+		status       int
+		failures     int
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "500 then success", status: http.StatusInternalServerError, failures: 2, wantRequests: 3},
+		{name: "500 exhausted", status: http.StatusInternalServerError, failures: 3, wantRequests: 3, wantErr: true},
+		{name: "400 is not retried", status: http.StatusBadRequest, failures: 1, wantRequests: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, failures, status = 0, tt.failures, tt.status
+			err := handler(context.Background(), entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v wantErr %t", err, tt.wantErr)
+			}
+			if got, want := requests, tt.wantRequests; got != want {
+				t.Errorf("got %d requests want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestNewWebhookHandler_timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	entry, err := Parse("* * * * *", time.UTC, "ENTRY_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Meta = fmt.Sprintf(`{"url": %q, "timeout": "20ms"}`, server.URL)
+
+	start := time.Now()
+	if err := NewWebhookHandler(server.Client())(context.Background(), entry); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("got request returned after %s want the timeout", d)
+	}
+}
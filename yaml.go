@@ -0,0 +1,84 @@
+package cron
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// job is a schedule definition in YAML config
+type job struct {
+	Name       string                 `yaml:"name"`
+	Expression string                 `yaml:"expression"`
+	Timezone   string                 `yaml:"timezone"`
+	Meta       map[string]interface{} `yaml:"meta"`
+	DependsOn  []string               `yaml:"depends_on"`
+}
+
+// LoadYAML loads entries from a YAML list of jobs. Meta is attached to Entry.Meta as JSON object, empty timezone
+// is UTC. Names of the jobs must be unique. depends_on is the names of other jobs in the list that must succeed first,
+// see Entry.DependsOn, a dependency cycle is returned as DependencyCycleError.
+//
+//	# jobs.yaml
+//	- name: ENTRY_1
+//	  expression: "0 0 * * *"
+//	  timezone: Asia/Jakarta
+//	  meta:
+//	    type: report
+//	- name: ENTRY_2
+//	  expression: "0 0 * * *"
+//	  depends_on: [ENTRY_1]
+func LoadYAML(r io.Reader) ([]Entry, error) {
+	var jobs []job
+	if err := yaml.NewDecoder(r).Decode(&jobs); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed decoding yaml: %v", err)
+	}
+
+	entries := make([]Entry, 0, len(jobs))
+	names := make(map[string]struct{})
+	for i, j := range jobs {
+		if j.Name == "" {
+			return nil, fmt.Errorf("job %d: empty name", i)
+		}
+		if _, ok := names[j.Name]; ok {
+			return nil, fmt.Errorf("job %q: duplicate name", j.Name)
+		}
+		names[j.Name] = struct{}{}
+
+		loc, err := time.LoadLocation(j.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("job %q: failed to load location %q: %v", j.Name, j.Timezone, err)
+		}
+		entry, err := Parse(j.Expression, loc, j.Name)
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %v", j.Name, err)
+		}
+		if len(j.Meta) > 0 {
+			meta, err := json.Marshal(j.Meta)
+			if err != nil {
+				return nil, fmt.Errorf("job %q: failed encoding meta: %v", j.Name, err)
+			}
+			entry.Meta = string(meta)
+		}
+
+		entry.DependsOn = j.DependsOn
+
+		entries = append(entries, entry)
+	}
+
+	for _, e := range entries {
+		for _, d := range e.DependsOn {
+			if _, ok := names[d]; !ok {
+				return nil, fmt.Errorf("job %q: depends on unknown job %q", e.Name, d)
+			}
+		}
+	}
+	if err := ValidateDependencies(entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadYAML(t *testing.T) {
+	config := `
+- name: ENTRY_1
+  expression: "0 0 * * *"
+- name: ENTRY_2
+  expression: "*/5 * * * *"
+  timezone: Asia/Jakarta
+  meta:
+    type: report
+    retry: 3
+  depends_on: [ENTRY_1]
+`
+	entries, err := LoadYAML(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got entries %d want %d", got, want)
+	}
+
+	tests := []struct {
+		want string
+		meta string
+	}{
+		{want: `{ name:"ENTRY_1" schedule:"0 0 * * *", location:"UTC" }`, meta: ""},
+		{
+			want: `{ name:"ENTRY_2" schedule:"*/5 * * * *", location:"Asia/Jakarta" }`,
+			meta: `{"retry":3,"type":"report"}`,
+		},
+	}
+	for i, tt := range tests {
+		if got, want := entries[i].String(), tt.want; got != want {
+			t.Errorf("[%d] got entry %s want %s", i, got, want)
+		}
+		if got, want := entries[i].Meta, tt.meta; got != want {
+			t.Errorf("[%d] got meta %q want %q", i, got, want)
+		}
+	}
+	if got, want := entries[1].DependsOn, []string{"ENTRY_1"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("got depends on %v want %v", got, want)
+	}
+}
+
+func TestLoadYAML_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "duplicate name",
+			config: `
+- name: ENTRY_1
+  expression: "0 0 * * *"
+- name: ENTRY_1
+  expression: "0 1 * * *"
+`,
+			wantErr: `job "ENTRY_1": duplicate name`,
+		},
+		{
+			name: "invalid expression",
+			config: `
+- name: ENTRY_1
+  expression: "60 0 * * *"
+`,
+			wantErr: `job "ENTRY_1": failed parsing 'minute' field "60": value out of range (0 - 59): 60`,
+		},
+		{
+			name: "invalid timezone",
+			config: `
+- name: ENTRY_1
+  expression: "0 0 * * *"
+  timezone: Mars/Phobos
+`,
+			wantErr: `job "ENTRY_1": failed to load location "Mars/Phobos": unknown time zone Mars/Phobos`,
+		},
+		{
+			name: "unknown dependency",
+			config: `
+- name: ENTRY_1
+  expression: "0 0 * * *"
+  depends_on: [ENTRY_2]
+`,
+			wantErr: `job "ENTRY_1": depends on unknown job "ENTRY_2"`,
+		},
+		{
+			name: "dependency cycle",
+			config: `
+- name: ENTRY_1
+  expression: "0 0 * * *"
+  depends_on: [ENTRY_2]
+- name: ENTRY_2
+  expression: "0 0 * * *"
+  depends_on: [ENTRY_1]
+`,
+			wantErr: `dependency cycle: ENTRY_1 -> ENTRY_2 -> ENTRY_1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadYAML(strings.NewReader(tt.config))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("LoadYAML() error = %v, wantErr %q", err, tt.wantErr)
+			}
+		})
+	}
+}